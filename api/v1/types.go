@@ -34,3 +34,26 @@ type LeptonComponentInfo struct {
 	EndTime   time.Time       `json:"endTime"`
 	Info      components.Info `json:"info"`
 }
+
+// LeptonHealth is the health of the node aggregated from all the components.
+type LeptonHealth struct {
+	// Healthy is true if all the components are healthy.
+	Healthy bool `json:"healthy"`
+	// Ready is true if all the critical components are healthy.
+	Ready bool `json:"ready"`
+	// Severity is the most severe level among the components.
+	Severity components.Severity `json:"severity,omitempty"`
+	// SuggestedActions are the merged suggested actions of all the components.
+	SuggestedActions []components.SuggestedAction `json:"suggested_actions,omitempty"`
+	// Components is the per-component health breakdown.
+	Components []LeptonComponentHealth `json:"components"`
+}
+
+type LeptonComponentHealth struct {
+	Component string `json:"component"`
+	// Critical is true if the component is marked as critical in the configuration,
+	// thus the node is not ready when the component is unhealthy.
+	Critical bool `json:"critical"`
+
+	components.HealthStatus
+}
@@ -1,14 +1,26 @@
 package client
 
 import (
+	"context"
 	"crypto/tls"
+	"net"
 	"net/http"
 	"time"
+
+	"github.com/leptonai/gpud/internal/server"
 )
 
 type Op struct {
 	httpClient    *http.Client
+	tlsConfig     *tls.Config
+	unixSocket    string
+	bearerToken   string
 	checkInterval time.Duration
+
+	components         []string
+	since              time.Duration
+	requestContentType string
+	requestGzip        bool
 }
 
 type OpOption func(*Op)
@@ -19,10 +31,21 @@ func (op *Op) applyOpts(opts []OpOption) error {
 	}
 
 	if op.httpClient == nil {
+		tlsConfig := op.tlsConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{InsecureSkipVerify: true}
+		}
+		transport := &http.Transport{
+			TLSClientConfig: tlsConfig,
+		}
+		if op.unixSocket != "" {
+			transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", op.unixSocket)
+			}
+		}
 		op.httpClient = &http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-			},
+			Transport: transport,
 		}
 	}
 
@@ -30,6 +53,10 @@ func (op *Op) applyOpts(opts []OpOption) error {
 		op.checkInterval = time.Second
 	}
 
+	if op.requestContentType == "" {
+		op.requestContentType = server.RequestHeaderJSON
+	}
+
 	return nil
 }
 
@@ -39,8 +66,71 @@ func WithHTTPClient(cli *http.Client) OpOption {
 	}
 }
 
+// WithTLSConfig sets the TLS config of the default HTTP client
+// (e.g., to verify the server certificate).
+// Ignored if the HTTP client is set with "WithHTTPClient".
+func WithTLSConfig(cfg *tls.Config) OpOption {
+	return func(op *Op) {
+		op.tlsConfig = cfg
+	}
+}
+
+// WithUnixSocket sets the default HTTP client to connect to the Unix-domain socket
+// served by gpud, in which case the address should be "http://localhost".
+// Ignored if the HTTP client is set with "WithHTTPClient".
+func WithUnixSocket(file string) OpOption {
+	return func(op *Op) {
+		op.unixSocket = file
+	}
+}
+
+// WithBearerToken sets the bearer token to authenticate the requests.
+func WithBearerToken(token string) OpOption {
+	return func(op *Op) {
+		op.bearerToken = token
+	}
+}
+
+// setAuthHeader sets the "Authorization" header, if the bearer token is set.
+func (op *Op) setAuthHeader(req *http.Request) {
+	if op.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+op.bearerToken)
+	}
+}
+
 func WithCheckInterval(interval time.Duration) OpOption {
 	return func(op *Op) {
 		op.checkInterval = interval
 	}
 }
+
+// WithComponents sets the components to query.
+// If not set, all the components are queried.
+func WithComponents(components ...string) OpOption {
+	return func(op *Op) {
+		op.components = append(op.components, components...)
+	}
+}
+
+// WithSince sets the duration to query the events, metrics, and info since.
+// If not set, the server defaults are used.
+func WithSince(since time.Duration) OpOption {
+	return func(op *Op) {
+		op.since = since
+	}
+}
+
+// WithRequestContentType sets the content type of the response to request,
+// either "server.RequestHeaderJSON" (default) or "server.RequestHeaderYAML".
+func WithRequestContentType(contentType string) OpOption {
+	return func(op *Op) {
+		op.requestContentType = contentType
+	}
+}
+
+// WithRequestGzip requests the gzip-compressed response.
+func WithRequestGzip() OpOption {
+	return func(op *Op) {
+		op.requestGzip = true
+	}
+}
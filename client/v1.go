@@ -0,0 +1,149 @@
+package client
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/internal/server"
+
+	"sigs.k8s.io/yaml"
+)
+
+// GetComponents returns the names of all the components enabled in the server.
+func GetComponents(ctx context.Context, addr string, opts ...OpOption) ([]string, error) {
+	var ret []string
+	if err := get(ctx, addr, server.URLPathComponents, nil, &ret, opts...); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GetStates returns the current states of the components.
+func GetStates(ctx context.Context, addr string, opts ...OpOption) (v1.LeptonStates, error) {
+	var ret v1.LeptonStates
+	if err := get(ctx, addr, server.URLPathStates, nil, &ret, opts...); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GetEvents returns the events of the components.
+// Use "WithSince" to set the start of the query window,
+// which defaults to "server.DefaultQuerySince".
+func GetEvents(ctx context.Context, addr string, opts ...OpOption) (v1.LeptonEvents, error) {
+	var ret v1.LeptonEvents
+	setSince := func(op *Op, q url.Values) {
+		since := op.since
+		if since == 0 {
+			since = server.DefaultQuerySince
+		}
+		now := time.Now()
+		q.Set("startTime", strconv.FormatInt(now.Add(-since).Unix(), 10))
+		q.Set("endTime", strconv.FormatInt(now.Unix(), 10))
+	}
+	if err := get(ctx, addr, server.URLPathEvents, setSince, &ret, opts...); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GetMetrics returns the metrics of the components.
+// Use "WithSince" to set the start of the query window.
+func GetMetrics(ctx context.Context, addr string, opts ...OpOption) (v1.LeptonMetrics, error) {
+	var ret v1.LeptonMetrics
+	if err := get(ctx, addr, server.URLPathMetrics, setSinceDuration, &ret, opts...); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GetInfo returns the events, metrics, and states of the components.
+// Use "WithSince" to set the start of the metrics query window.
+func GetInfo(ctx context.Context, addr string, opts ...OpOption) (v1.LeptonInfo, error) {
+	var ret v1.LeptonInfo
+	if err := get(ctx, addr, server.URLPathInfo, setSinceDuration, &ret, opts...); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func setSinceDuration(op *Op, q url.Values) {
+	if op.since > 0 {
+		q.Set("since", op.since.String())
+	}
+}
+
+// get sends the GET request to the v1 API path and decodes the response into "v".
+// "setQuery" sets the path-specific query parameters.
+func get(ctx context.Context, addr string, path string, setQuery func(*Op, url.Values), v any, opts ...OpOption) error {
+	op := &Op{}
+	if err := op.applyOpts(opts); err != nil {
+		return err
+	}
+
+	q := url.Values{}
+	if len(op.components) > 0 {
+		q.Set("components", strings.Join(op.components, ","))
+	}
+	if setQuery != nil {
+		setQuery(op, q)
+	}
+	u := fmt.Sprintf("%s/v1%s", addr, path)
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set(server.RequestHeaderContentType, op.requestContentType)
+	op.setAuthHeader(req)
+	if op.requestGzip {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+
+	resp, err := op.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request to %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	var body io.Reader = resp.Body
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		gr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		defer gr.Close()
+		body = gr
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d from %s: %s", resp.StatusCode, path, string(b))
+	}
+
+	switch op.requestContentType {
+	case server.RequestHeaderYAML:
+		err = yaml.Unmarshal(b, v)
+	default:
+		err = json.Unmarshal(b, v)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
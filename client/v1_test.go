@@ -0,0 +1,114 @@
+package client
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/internal/server"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestGetStates(t *testing.T) {
+	states := v1.LeptonStates{
+		{Component: "cpu", States: []components.State{{Name: "cpu", Healthy: true}}},
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		gzip        bool
+	}{
+		{"json", server.RequestHeaderJSON, false},
+		{"yaml", server.RequestHeaderYAML, false},
+		{"json gzip", server.RequestHeaderJSON, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1"+server.URLPathStates {
+					t.Errorf("unexpected path %s", r.URL.Path)
+				}
+				if got := r.URL.Query().Get("components"); got != "cpu,memory" {
+					t.Errorf("unexpected components %q", got)
+				}
+				if got := r.Header.Get(server.RequestHeaderContentType); got != tt.contentType {
+					t.Errorf("unexpected content type %q", got)
+				}
+
+				var b []byte
+				var err error
+				if tt.contentType == server.RequestHeaderYAML {
+					b, err = yaml.Marshal(states)
+				} else {
+					b, err = json.Marshal(states)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if tt.gzip {
+					if r.Header.Get("Accept-Encoding") != "gzip" {
+						t.Errorf("expected gzip request")
+					}
+					w.Header().Set("Content-Encoding", "gzip")
+					gw := gzip.NewWriter(w)
+					defer gw.Close()
+					_, _ = gw.Write(b)
+					return
+				}
+				_, _ = w.Write(b)
+			}))
+			defer srv.Close()
+
+			opts := []OpOption{WithComponents("cpu", "memory"), WithRequestContentType(tt.contentType)}
+			if tt.gzip {
+				opts = append(opts, WithRequestGzip())
+			}
+			got, err := GetStates(context.Background(), srv.URL, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 1 || got[0].Component != "cpu" || len(got[0].States) != 1 || !got[0].States[0].Healthy {
+				t.Fatalf("unexpected states %+v", got)
+			}
+		})
+	}
+}
+
+func TestGetMetricsSince(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("since"); got != "1h0m0s" {
+			t.Errorf("unexpected since %q", got)
+		}
+		_, _ = w.Write([]byte(`[{"component":"cpu","metrics":[]}]`))
+	}))
+	defer srv.Close()
+
+	got, err := GetMetrics(context.Background(), srv.URL, WithSince(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Component != "cpu" {
+		t.Fatalf("unexpected metrics %+v", got)
+	}
+}
+
+func TestGetError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":"invalid argument","message":"failed to parse components"}`))
+	}))
+	defer srv.Close()
+
+	if _, err := GetEvents(context.Background(), srv.URL, WithComponents("unknown")); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -22,6 +22,7 @@ var (
 	logLevel string
 	debug    bool
 
+	configFile    string
 	annotations   string
 	listenAddress string
 
@@ -35,6 +36,14 @@ var (
 
 	tailLines      int
 	disableArchive bool
+
+	queryAddress    string
+	queryComponents string
+	querySince      time.Duration
+	queryAuthToken  string
+	queryUnixSocket string
+	outputJSON      bool
+	outputYAML      bool
 )
 
 const (
@@ -72,6 +81,47 @@ sudo gpud login --token <LEPTON_AI_TOKEN>
 				},
 			},
 		},
+		{
+			Name:  "control-plane",
+			Usage: "runs the reference control plane server that gpud logs into",
+			UsageText: `# to run the control plane that accepts the login with the token
+gpud control-plane --listen-address 0.0.0.0:8080 --tokens <TOKEN> --operator-token <OPERATOR_TOKEN> --state-file /var/lib/gpud/control-plane.json
+
+# to log gpud into the control plane
+sudo gpud login --endpoint http://<CONTROL_PLANE_HOST>:8080 --token <TOKEN>
+
+# to query the states of all the machines logged in
+curl -X POST http://<CONTROL_PLANE_HOST>:8080/api/v1/fanout -H "Authorization: Bearer <OPERATOR_TOKEN>" -d '{"request":{"method":"states"}}'
+`,
+			Action: cmdControlPlane,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "listen-address",
+					Usage: "address to serve the control plane",
+					Value: "0.0.0.0:8080",
+				},
+				cli.StringFlag{
+					Name:  "tokens",
+					Usage: "comma-separated tokens to accept the login with (any token if empty)",
+				},
+				cli.StringFlag{
+					Name:  "operator-token",
+					Usage: "bearer token required to list the machines and fan out the requests (operator API disabled if empty)",
+				},
+				cli.StringFlag{
+					Name:  "state-file",
+					Usage: "file to persist the machines logged in across the restarts (kept in memory if empty)",
+				},
+				cli.StringFlag{
+					Name:  "tls-cert-file",
+					Usage: "TLS certificate file to serve HTTPS",
+				},
+				cli.StringFlag{
+					Name:  "tls-key-file",
+					Usage: "TLS key file to serve HTTPS",
+				},
+			},
+		},
 		{
 			Name:  "up",
 			Usage: "initialize and start gpud in a daemon mode (systemd)",
@@ -123,6 +173,11 @@ sudo rm /etc/systemd/system/gpud.service
 					Usage:       "set the logging level [debug, info, warn, error, fatal, panic, dpanic]",
 					Destination: &logLevel,
 				},
+				&cli.StringFlag{
+					Name:        "config",
+					Usage:       "set the config file to load (reloaded on SIGHUP or file change, default config is auto-detected if empty)",
+					Destination: &configFile,
+				},
 				&cli.StringFlag{
 					Name:        "listen-address",
 					Usage:       "set the listen address",
@@ -319,6 +374,80 @@ sudo rm /etc/systemd/system/gpud.service
 			},
 		},
 
+		// for querying the running gpud
+		{
+			Name:  "components",
+			Usage: "lists the components of the running gpud",
+			UsageText: `# to list the components
+gpud components
+`,
+			Action: cmdComponents,
+			Flags:  queryFlags(false),
+		},
+		{
+			Name:  "states",
+			Usage: "queries the component states of the running gpud",
+			UsageText: `# to query the states of all components
+gpud states
+
+# to query the states of the specific components in YAML
+gpud states --components accelerator-nvidia-error-xid,accelerator-nvidia-ecc --yaml
+`,
+			Action: cmdStates,
+			Flags:  queryFlags(false),
+		},
+		{
+			Name:  "events",
+			Usage: "queries the component events of the running gpud",
+			UsageText: `# to query the events in the last hour
+gpud events --since 1h
+`,
+			Action: cmdEvents,
+			Flags:  queryFlags(true),
+		},
+		{
+			Name:  "metrics",
+			Usage: "queries the component metrics of the running gpud",
+			UsageText: `# to query the metrics in the last 10 minutes in JSON
+gpud metrics --since 10m --json
+`,
+			Action: cmdMetrics,
+			Flags:  queryFlags(true),
+		},
+		{
+			Name:  "fleet",
+			Usage: "queries many running gpud instances at once",
+			UsageText: `# to list the unhealthy components of the hosts
+gpud fleet states --hosts-file nodes.txt
+
+# to list the warning and error events of the hosts in the last hour in JSON
+gpud fleet events --hosts-file nodes.txt --since 1h --json
+
+# to check the health of the hosts, 64 at the same time
+gpud fleet health --hosts-file nodes.txt --concurrency 64
+`,
+			Subcommands: []cli.Command{
+				{
+					Name:   "states",
+					Usage:  "lists the unhealthy component states of the hosts",
+					Action: cmdFleetStates,
+					Flags:  fleetFlags(false),
+				},
+				{
+					Name:   "events",
+					Usage:  "lists the warning and error events of the hosts",
+					Action: cmdFleetEvents,
+					Flags:  fleetFlags(true),
+				},
+				{
+					Name:   "health",
+					Usage:  "checks the health and the unhealthy components of the hosts",
+					Action: cmdFleetHealth,
+					Flags:  fleetFlags(false),
+				},
+			},
+		},
+
 		// for diagnose + quick scanning
 		{
 			Name:  "diagnose",
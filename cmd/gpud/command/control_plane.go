@@ -0,0 +1,79 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/signal"
+	"strings"
+	"time"
+
+	cpserver "github.com/leptonai/gpud/internal/controlplane/server"
+	"github.com/leptonai/gpud/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/urfave/cli"
+	"golang.org/x/sys/unix"
+)
+
+func cmdControlPlane(cliContext *cli.Context) error {
+	gin.SetMode(gin.ReleaseMode)
+
+	var tokens []string
+	for _, t := range strings.Split(cliContext.String("tokens"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tokens = append(tokens, t)
+		}
+	}
+	if len(tokens) == 0 {
+		log.Logger.Warnw("no token specified, accepting the login with any token")
+	}
+
+	certFile, keyFile := cliContext.String("tls-cert-file"), cliContext.String("tls-key-file")
+	if (certFile == "") != (keyFile == "") {
+		return errors.New("both --tls-cert-file and --tls-key-file must be set")
+	}
+
+	operatorToken := cliContext.String("operator-token")
+	if operatorToken == "" {
+		log.Logger.Warnw("no operator token specified, disabling the operator API")
+	}
+	cp, err := cpserver.New(
+		cpserver.WithTokens(tokens...),
+		cpserver.WithOperatorToken(operatorToken),
+		cpserver.WithStateFile(cliContext.String("state-file")),
+	)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              cliContext.String("listen-address"),
+		Handler:           cp.Handler(),
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), unix.SIGINT, unix.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		log.Logger.Infow("serving control plane", "address", srv.Addr, "tls", certFile != "")
+		if certFile != "" {
+			errc <- srv.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			errc <- srv.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("failed to serve control plane: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,420 @@
+package command
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/client"
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/config"
+
+	"github.com/urfave/cli"
+)
+
+var (
+	fleetHostsFile          string
+	fleetConcurrency        int
+	fleetTimeout            time.Duration
+	fleetCAFile             string
+	fleetServerName         string
+	fleetInsecureSkipVerify bool
+)
+
+func fleetFlags(withSince bool) []cli.Flag {
+	flags := []cli.Flag{
+		&cli.StringFlag{
+			Name:        "hosts-file",
+			Usage:       "set the file of the gpud addresses to query, one per line (e.g., node-1, 10.0.0.2:15132, https://node-3:15132)",
+			Destination: &fleetHostsFile,
+		},
+		&cli.IntFlag{
+			Name:        "concurrency",
+			Usage:       "set the number of the hosts to query at the same time",
+			Destination: &fleetConcurrency,
+			Value:       16,
+		},
+		&cli.DurationFlag{
+			Name:        "timeout",
+			Usage:       "set the timeout to query each host",
+			Destination: &fleetTimeout,
+			Value:       15 * time.Second,
+		},
+		&cli.StringFlag{
+			Name:        "components,c",
+			Usage:       "set the comma-separated components to query (all components if empty)",
+			Destination: &queryComponents,
+		},
+		&cli.StringFlag{
+			Name:        "auth-token",
+			Usage:       "set the bearer token to authenticate with (if the gpud auth is enabled)",
+			Destination: &queryAuthToken,
+		},
+		&cli.StringFlag{
+			Name:        "tls-ca-file",
+			Usage:       "set the CA certificate file to verify the gpud server certificates with (not verified if empty)",
+			Destination: &fleetCAFile,
+		},
+		&cli.StringFlag{
+			Name:        "tls-server-name",
+			Usage:       "set the server name to verify the gpud server certificates with (the host name if empty)",
+			Destination: &fleetServerName,
+		},
+		&cli.BoolFlag{
+			Name:        "tls-insecure-skip-verify",
+			Usage:       "skip verifying the gpud server certificates, even if the CA certificate file is set",
+			Destination: &fleetInsecureSkipVerify,
+		},
+		&cli.BoolFlag{
+			Name:        "json",
+			Usage:       "print in JSON",
+			Destination: &outputJSON,
+		},
+	}
+	if withSince {
+		flags = append(flags, &cli.DurationFlag{
+			Name:        "since",
+			Usage:       "set the duration to query since",
+			Destination: &querySince,
+			Value:       30 * time.Minute,
+		})
+	}
+	return flags
+}
+
+// fleetHostResult is the query result of a host.
+type fleetHostResult struct {
+	Host  string `json:"host"`
+	Error string `json:"error,omitempty"`
+
+	// Healthy is set for the "health" query, true if the host responds to the health check.
+	Healthy *bool `json:"healthy,omitempty"`
+
+	States v1.LeptonStates `json:"states,omitempty"`
+	Events v1.LeptonEvents `json:"events,omitempty"`
+}
+
+// readHostsFile reads the gpud addresses, one per line,
+// skipping the empty lines and the comments starting with "#".
+// The address defaults to the HTTPS scheme and the default gpud port.
+func readHostsFile(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var hosts []string
+	seen := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" {
+			continue
+		}
+
+		addr, err := normalizeHostAddress(line)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[addr]; ok {
+			continue
+		}
+		seen[addr] = struct{}{}
+		hosts = append(hosts, addr)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no host found in %s", file)
+	}
+	return hosts, nil
+}
+
+func normalizeHostAddress(host string) (string, error) {
+	if !strings.HasPrefix(host, "http://") && !strings.HasPrefix(host, "https://") {
+		host = "https://" + host
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return "", fmt.Errorf("invalid host %q: %w", host, err)
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("invalid host %q: empty host name", host)
+	}
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(config.DefaultGPUdPort))
+	}
+	return u.Scheme + "://" + u.Host, nil
+}
+
+// fleetTLSConfig returns the TLS config to verify the gpud server certificates,
+// or nil to use the client default (not verified, since gpud serves the self-signed certificate by default).
+func fleetTLSConfig() (*tls.Config, error) {
+	if fleetCAFile == "" || fleetInsecureSkipVerify {
+		return nil, nil
+	}
+	b, err := os.ReadFile(fleetCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no certificate found in %s", fleetCAFile)
+	}
+	return &tls.Config{RootCAs: pool, ServerName: fleetServerName}, nil
+}
+
+func fleetOpts() ([]client.OpOption, error) {
+	opts := []client.OpOption{client.WithRequestGzip()}
+	if queryComponents != "" {
+		opts = append(opts, client.WithComponents(strings.Split(queryComponents, ",")...))
+	}
+	if querySince > 0 {
+		opts = append(opts, client.WithSince(querySince))
+	}
+	if queryAuthToken != "" {
+		opts = append(opts, client.WithBearerToken(queryAuthToken))
+	}
+	tlsConfig, err := fleetTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		opts = append(opts, client.WithTLSConfig(tlsConfig))
+	}
+	return opts, nil
+}
+
+// queryFleet queries the hosts with at most "concurrency" hosts at the same time,
+// each with the timeout, and returns the results in the order of the hosts.
+func queryFleet(ctx context.Context, hosts []string, concurrency int, timeout time.Duration, query func(ctx context.Context, host string) fleetHostResult) []fleetHostResult {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	results := make([]fleetHostResult, len(hosts))
+	sema := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func(i int, host string) {
+			defer wg.Done()
+
+			select {
+			case sema <- struct{}{}:
+			case <-ctx.Done():
+				results[i] = fleetHostResult{Host: host, Error: ctx.Err().Error()}
+				return
+			}
+			defer func() { <-sema }()
+
+			cctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			results[i] = query(cctx, host)
+			results[i].Host = host
+		}(i, host)
+	}
+	wg.Wait()
+	return results
+}
+
+func queryFleetStates(ctx context.Context, host string, opts ...client.OpOption) fleetHostResult {
+	states, err := client.GetStates(ctx, host, opts...)
+	if err != nil {
+		return fleetHostResult{Error: err.Error()}
+	}
+	return fleetHostResult{States: states}
+}
+
+func queryFleetEvents(ctx context.Context, host string, opts ...client.OpOption) fleetHostResult {
+	events, err := client.GetEvents(ctx, host, opts...)
+	if err != nil {
+		return fleetHostResult{Error: err.Error()}
+	}
+	return fleetHostResult{Events: events}
+}
+
+// queryFleetHealth checks the health endpoint, and the component states of the healthy host.
+func queryFleetHealth(ctx context.Context, host string, opts ...client.OpOption) fleetHostResult {
+	healthy := client.CheckHealthz(ctx, host, opts...) == nil
+	ret := fleetHostResult{Healthy: &healthy}
+	if !healthy {
+		ret.Error = "health check failed"
+		return ret
+	}
+
+	states, err := client.GetStates(ctx, host, opts...)
+	if err != nil {
+		ret.Error = err.Error()
+		return ret
+	}
+	ret.States = states
+	return ret
+}
+
+func cmdFleetStates(cliContext *cli.Context) error {
+	return runFleet(queryFleetStates, printFleetStates)
+}
+
+func cmdFleetEvents(cliContext *cli.Context) error {
+	return runFleet(queryFleetEvents, printFleetEvents)
+}
+
+func cmdFleetHealth(cliContext *cli.Context) error {
+	return runFleet(queryFleetHealth, printFleetHealth)
+}
+
+func runFleet(
+	query func(ctx context.Context, host string, opts ...client.OpOption) fleetHostResult,
+	printResults func(w io.Writer, results []fleetHostResult),
+) error {
+	if fleetHostsFile == "" {
+		return errors.New("--hosts-file is required")
+	}
+	hosts, err := readHostsFile(fleetHostsFile)
+	if err != nil {
+		return err
+	}
+	opts, err := fleetOpts()
+	if err != nil {
+		return err
+	}
+
+	results := queryFleet(context.Background(), hosts, fleetConcurrency, fleetTimeout, func(ctx context.Context, host string) fleetHostResult {
+		return query(ctx, host, opts...)
+	})
+	if printed, err := printOutput(results); printed {
+		return err
+	}
+	printResults(os.Stdout, results)
+	return nil
+}
+
+// printFleetStates prints the unhealthy component states of all the hosts in a table,
+// and the hosts failed to query.
+func printFleetStates(w io.Writer, results []fleetHostResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "HOST\tCOMPONENT\tNAME\tSEVERITY\tREASON")
+	unhealthyHosts := 0
+	for _, r := range results {
+		if r.Error != "" {
+			fmt.Fprintf(tw, "%s\t-\t-\t-\t%s\n", r.Host, r.Error)
+			unhealthyHosts++
+			continue
+		}
+		unhealthy := false
+		for _, cs := range r.States {
+			for _, s := range cs.States {
+				if s.Healthy {
+					continue
+				}
+				unhealthy = true
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Host, cs.Component, s.Name, s.Severity, s.Reason)
+			}
+		}
+		if unhealthy {
+			unhealthyHosts++
+		}
+	}
+	tw.Flush()
+	printFleetSummary(w, len(results), unhealthyHosts)
+}
+
+// printFleetEvents prints the warning and error events of all the hosts in a table,
+// sorted by time, and the hosts failed to query.
+func printFleetEvents(w io.Writer, results []fleetHostResult) {
+	type row struct {
+		host      string
+		component string
+		event     components.Event
+	}
+	var rows []row
+	unhealthyHosts := 0
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "HOST\tCOMPONENT\tTIME\tTYPE\tNAME\tMESSAGE")
+	for _, r := range results {
+		if r.Error != "" {
+			fmt.Fprintf(tw, "%s\t-\t-\t-\t-\t%s\n", r.Host, r.Error)
+			unhealthyHosts++
+			continue
+		}
+		unhealthy := false
+		for _, ce := range r.Events {
+			for _, ev := range ce.Events {
+				if ev.Type != components.EventTypeWarn && ev.Type != components.EventTypeError {
+					continue
+				}
+				unhealthy = true
+				rows = append(rows, row{host: r.Host, component: ce.Component, event: ev})
+			}
+		}
+		if unhealthy {
+			unhealthyHosts++
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].event.Time.Time.Before(rows[j].event.Time.Time)
+	})
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", r.host, r.component, r.event.Time.UTC().Format(time.RFC3339), colorEventType(r.event.Type), r.event.Name, r.event.Message)
+	}
+	tw.Flush()
+	printFleetSummary(w, len(results), unhealthyHosts)
+}
+
+// printFleetHealth prints the health of each host with its unhealthy components.
+func printFleetHealth(w io.Writer, results []fleetHostResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "HOST\tHEALTHY\tUNHEALTHY COMPONENTS\tERROR")
+	unhealthyHosts := 0
+	for _, r := range results {
+		var unhealthy []string
+		for _, cs := range r.States {
+			if !components.EvaluateHealth(cs.States).Healthy {
+				unhealthy = append(unhealthy, cs.Component)
+			}
+		}
+		healthy := r.Error == "" && len(unhealthy) == 0
+		if !healthy {
+			unhealthyHosts++
+		}
+
+		names, errMsg := "-", "-"
+		if len(unhealthy) > 0 {
+			names = strings.Join(unhealthy, ",")
+		}
+		if r.Error != "" {
+			errMsg = r.Error
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Host, colorHealthy(healthy), names, errMsg)
+	}
+	tw.Flush()
+	printFleetSummary(w, len(results), unhealthyHosts)
+}
+
+func printFleetSummary(w io.Writer, total int, unhealthy int) {
+	mark := checkMark
+	if unhealthy > 0 {
+		mark = warningSign
+	}
+	fmt.Fprintf(w, "\n%s %d/%d host(s) healthy\n", mark, total-unhealthy, total)
+}
@@ -0,0 +1,179 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/client"
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/internal/server"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newFleetTestServer serves the health check and the states of the gpud.
+func newFleetTestServer(t *testing.T, states v1.LeptonStates, delay time.Duration) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		b, err := server.DefaultHealthz.JSON()
+		if err != nil {
+			t.Error(err)
+		}
+		_, _ = w.Write(b)
+	})
+	mux.HandleFunc("/v1"+server.URLPathStates, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		_ = json.NewEncoder(w).Encode(states)
+	})
+	ts := httptest.NewTLSServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestReadHostsFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "nodes.txt")
+	content := `# GPU nodes
+node-1
+10.0.0.2:8080 # custom port
+http://node-3
+
+node-1
+`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hosts, err := readHostsFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"https://node-1:15132", "https://10.0.0.2:8080", "http://node-3:15132"}
+	if !reflect.DeepEqual(hosts, want) {
+		t.Fatalf("expected %v, got %v", want, hosts)
+	}
+
+	if err := os.WriteFile(file, []byte("# empty\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readHostsFile(file); err == nil {
+		t.Fatal("expected error for no host")
+	}
+}
+
+func TestQueryFleet(t *testing.T) {
+	healthy := newFleetTestServer(t, v1.LeptonStates{
+		{Component: "cpu", States: []components.State{{Name: "cpu", Healthy: true}}},
+	}, 0)
+	unhealthy := newFleetTestServer(t, v1.LeptonStates{
+		{Component: "cpu", States: []components.State{{Name: "cpu", Healthy: true}}},
+		{Component: "xid", States: []components.State{{Name: "xid", Healthy: false, Severity: components.SeverityFatal, Reason: "xid 79"}}},
+	}, 0)
+	slow := newFleetTestServer(t, nil, time.Minute)
+	down := newFleetTestServer(t, nil, 0)
+	down.Close()
+
+	hosts := []string{healthy.URL, unhealthy.URL, slow.URL, down.URL}
+	results := queryFleet(context.Background(), hosts, 2, time.Second, func(ctx context.Context, host string) fleetHostResult {
+		return queryFleetHealth(ctx, host)
+	})
+	if len(results) != len(hosts) {
+		t.Fatalf("expected %d results, got %d", len(hosts), len(results))
+	}
+	for i, r := range results {
+		if r.Host != hosts[i] {
+			t.Fatalf("expected host %s at %d, got %s", hosts[i], i, r.Host)
+		}
+	}
+	if results[0].Error != "" || results[1].Error != "" {
+		t.Fatalf("unexpected errors %q, %q", results[0].Error, results[1].Error)
+	}
+	if results[2].Error == "" || results[3].Error == "" {
+		t.Fatal("expected errors for the slow and down hosts")
+	}
+	if results[3].Healthy == nil || *results[3].Healthy {
+		t.Fatal("expected the down host to fail the health check")
+	}
+
+	buf := new(bytes.Buffer)
+	printFleetHealth(buf, results)
+	out := buf.String()
+	if !strings.Contains(out, "xid") {
+		t.Errorf("expected the unhealthy component:\n%s", out)
+	}
+	if !strings.Contains(out, "1/4 host(s) healthy") {
+		t.Errorf("expected the summary:\n%s", out)
+	}
+
+	buf.Reset()
+	printFleetStates(buf, results)
+	out = buf.String()
+	if !strings.Contains(out, "xid 79") || strings.Contains(out, "\tcpu\t") {
+		t.Errorf("expected only the unhealthy states:\n%s", out)
+	}
+}
+
+func TestQueryFleetConcurrency(t *testing.T) {
+	var cur, peak atomic.Int32
+	hosts := make([]string, 10)
+	for i := range hosts {
+		hosts[i] = "host"
+	}
+	queryFleet(context.Background(), hosts, 3, time.Second, func(ctx context.Context, host string) fleetHostResult {
+		n := cur.Add(1)
+		defer cur.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return fleetHostResult{}
+	})
+	if p := peak.Load(); p > 3 {
+		t.Fatalf("expected at most 3 concurrent queries, got %d", p)
+	}
+}
+
+func TestQueryFleetEvents(t *testing.T) {
+	now := time.Now().UTC()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1"+server.URLPathEvents, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(v1.LeptonEvents{
+			{Component: "xid", Events: []components.Event{
+				{Time: metav1.Time{Time: now}, Name: "xid", Type: components.EventTypeError, Message: "xid 79 detected"},
+				{Time: metav1.Time{Time: now}, Name: "boot", Type: components.EventTypeInfo, Message: "rebooted"},
+			}},
+		})
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	results := queryFleet(context.Background(), []string{ts.URL}, 1, time.Second, func(ctx context.Context, host string) fleetHostResult {
+		return queryFleetEvents(ctx, host, client.WithSince(time.Hour))
+	})
+	if results[0].Error != "" {
+		t.Fatal(results[0].Error)
+	}
+
+	buf := new(bytes.Buffer)
+	printFleetEvents(buf, results)
+	out := buf.String()
+	if !strings.Contains(out, "xid 79 detected") || strings.Contains(out, "rebooted") {
+		t.Errorf("expected only the error event:\n%s", out)
+	}
+}
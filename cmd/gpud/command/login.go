@@ -9,6 +9,7 @@ import (
 	"github.com/leptonai/gpud/client"
 	"github.com/leptonai/gpud/components/state"
 	"github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/internal/controlplane"
 	"github.com/leptonai/gpud/internal/login"
 	"github.com/leptonai/gpud/internal/server"
 
@@ -74,7 +75,7 @@ func cmdLogin(cliContext *cli.Context) error {
 		if err != nil {
 			hostname = "UnknownName"
 		}
-		if err := login.Login(hostname, token, endpoint, components, uid); err != nil {
+		if err := login.Login(rootCtx, controlplane.New(endpoint), hostname, token, components, uid); err != nil {
 			return err
 		}
 	} else {
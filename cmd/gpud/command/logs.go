@@ -7,11 +7,12 @@ import (
 	"time"
 
 	"github.com/leptonai/gpud/components/query/log/tail"
+	"github.com/leptonai/gpud/log"
 
 	"github.com/urfave/cli"
 )
 
-const logFile = "/var/log/gpud.log"
+const logFile = log.DefaultLogFile
 
 func cmdLogs(cliContext *cli.Context) error {
 	if _, err := os.Stat(logFile); err != nil {
@@ -0,0 +1,256 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/client"
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/config"
+
+	"github.com/urfave/cli"
+	"sigs.k8s.io/yaml"
+)
+
+func queryFlags(withSince bool) []cli.Flag {
+	flags := []cli.Flag{
+		&cli.StringFlag{
+			Name:        "address",
+			Usage:       "set the address of the running gpud",
+			Destination: &queryAddress,
+			Value:       fmt.Sprintf("https://localhost:%d", config.DefaultGPUdPort),
+		},
+		&cli.StringFlag{
+			Name:        "components,c",
+			Usage:       "set the comma-separated components to query (all components if empty)",
+			Destination: &queryComponents,
+		},
+		&cli.StringFlag{
+			Name:        "auth-token",
+			Usage:       "set the bearer token to authenticate with (if the gpud auth is enabled)",
+			Destination: &queryAuthToken,
+		},
+		&cli.StringFlag{
+			Name:        "unix-socket",
+			Usage:       "set the unix socket to connect to, instead of the address (if the gpud serves one)",
+			Destination: &queryUnixSocket,
+		},
+		&cli.BoolFlag{
+			Name:        "json",
+			Usage:       "print in JSON",
+			Destination: &outputJSON,
+		},
+		&cli.BoolFlag{
+			Name:        "yaml",
+			Usage:       "print in YAML",
+			Destination: &outputYAML,
+		},
+	}
+	if withSince {
+		flags = append(flags, &cli.DurationFlag{
+			Name:        "since",
+			Usage:       "set the duration to query since",
+			Destination: &querySince,
+			Value:       30 * time.Minute,
+		})
+	}
+	return flags
+}
+
+func queryOpts() []client.OpOption {
+	opts := []client.OpOption{client.WithRequestGzip()}
+	if queryComponents != "" {
+		opts = append(opts, client.WithComponents(strings.Split(queryComponents, ",")...))
+	}
+	if querySince > 0 {
+		opts = append(opts, client.WithSince(querySince))
+	}
+	if queryAuthToken != "" {
+		opts = append(opts, client.WithBearerToken(queryAuthToken))
+	}
+	if queryUnixSocket != "" {
+		opts = append(opts, client.WithUnixSocket(queryUnixSocket))
+	}
+	return opts
+}
+
+// queryAddr returns the address of the running gpud.
+func queryAddr() string {
+	if queryUnixSocket != "" {
+		return "http://localhost"
+	}
+	return queryAddress
+}
+
+func queryContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), time.Minute)
+}
+
+// printOutput prints the value in JSON or YAML if requested,
+// and returns false if the value should be printed in tables.
+func printOutput(v any) (bool, error) {
+	switch {
+	case outputJSON && outputYAML:
+		return true, errors.New("only one of --json and --yaml can be set")
+	case outputJSON:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return true, err
+		}
+		fmt.Println(string(b))
+		return true, nil
+	case outputYAML:
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return true, err
+		}
+		fmt.Print(string(b))
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+func cmdComponents(cliContext *cli.Context) error {
+	ctx, cancel := queryContext()
+	defer cancel()
+
+	names, err := client.GetComponents(ctx, queryAddr(), queryOpts()...)
+	if err != nil {
+		return err
+	}
+	if printed, err := printOutput(names); printed {
+		return err
+	}
+	for _, name := range names {
+		fmt.Println(name)
+	}
+	return nil
+}
+
+func cmdStates(cliContext *cli.Context) error {
+	ctx, cancel := queryContext()
+	defer cancel()
+
+	states, err := client.GetStates(ctx, queryAddr(), queryOpts()...)
+	if err != nil {
+		return err
+	}
+	if printed, err := printOutput(states); printed {
+		return err
+	}
+	printStates(os.Stdout, states)
+	return nil
+}
+
+func printStates(w io.Writer, states v1.LeptonStates) {
+	for _, cs := range states {
+		mark := checkMark
+		if !components.EvaluateHealth(cs.States).Healthy {
+			mark = warningSign
+		}
+		fmt.Fprintf(w, "%s %s\n", mark, cs.Component)
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "\tNAME\tHEALTHY\tSEVERITY\tREASON")
+		for _, s := range cs.States {
+			fmt.Fprintf(tw, "\t%s\t%s\t%s\t%s\n", s.Name, colorHealthy(s.Healthy), s.Severity, s.Reason)
+		}
+		tw.Flush()
+		fmt.Fprintln(w)
+	}
+}
+
+func cmdEvents(cliContext *cli.Context) error {
+	ctx, cancel := queryContext()
+	defer cancel()
+
+	events, err := client.GetEvents(ctx, queryAddr(), queryOpts()...)
+	if err != nil {
+		return err
+	}
+	if printed, err := printOutput(events); printed {
+		return err
+	}
+	printEvents(os.Stdout, events)
+	return nil
+}
+
+func printEvents(w io.Writer, events v1.LeptonEvents) {
+	for _, ce := range events {
+		fmt.Fprintf(w, "%s (%d event(s))\n", ce.Component, len(ce.Events))
+		if len(ce.Events) == 0 {
+			fmt.Fprintln(w)
+			continue
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "\tTIME\tTYPE\tNAME\tMESSAGE")
+		for _, ev := range ce.Events {
+			fmt.Fprintf(tw, "\t%s\t%s\t%s\t%s\n", ev.Time.UTC().Format(time.RFC3339), colorEventType(ev.Type), ev.Name, ev.Message)
+		}
+		tw.Flush()
+		fmt.Fprintln(w)
+	}
+}
+
+func cmdMetrics(cliContext *cli.Context) error {
+	ctx, cancel := queryContext()
+	defer cancel()
+
+	metrics, err := client.GetMetrics(ctx, queryAddr(), queryOpts()...)
+	if err != nil {
+		return err
+	}
+	if printed, err := printOutput(metrics); printed {
+		return err
+	}
+	printMetrics(os.Stdout, metrics)
+	return nil
+}
+
+func printMetrics(w io.Writer, metrics v1.LeptonMetrics) {
+	for _, cm := range metrics {
+		fmt.Fprintf(w, "%s (%d metric(s))\n", cm.Component, len(cm.Metrics))
+		if len(cm.Metrics) == 0 {
+			fmt.Fprintln(w)
+			continue
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "\tTIME\tNAME\tSECONDARY NAME\tVALUE")
+		for _, m := range cm.Metrics {
+			fmt.Fprintf(tw, "\t%s\t%s\t%s\t%v\n", time.Unix(m.UnixSeconds, 0).UTC().Format(time.RFC3339), m.MetricName, m.MetricSecondaryName, m.Value)
+		}
+		tw.Flush()
+		fmt.Fprintln(w)
+	}
+}
+
+// the color codes do not change the column width,
+// since each cell in a column has the same escape sequence length
+func colorHealthy(healthy bool) string {
+	if healthy {
+		return "\033[32mtrue\033[0m"
+	}
+	return "\033[31mfalse\033[0m"
+}
+
+func colorEventType(typ string) string {
+	switch typ {
+	case components.EventTypeError:
+		return "\033[31m" + typ + "\033[0m"
+	case components.EventTypeWarn:
+		return "\033[33m" + typ + "\033[0m"
+	default:
+		return "\033[39m" + typ + "\033[0m"
+	}
+}
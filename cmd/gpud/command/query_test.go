@@ -0,0 +1,31 @@
+package command
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/components"
+)
+
+func TestPrintStates(t *testing.T) {
+	states := v1.LeptonStates{
+		{Component: "cpu", States: []components.State{{Name: "cpu", Healthy: true}}},
+		{Component: "xid", States: []components.State{{Name: "xid", Healthy: false, Severity: components.SeverityFatal, Reason: "xid 79"}}},
+	}
+
+	buf := new(bytes.Buffer)
+	printStates(buf, states)
+	out := buf.String()
+
+	if !strings.Contains(out, checkMark+" cpu") {
+		t.Errorf("expected cpu to be healthy:\n%s", out)
+	}
+	if !strings.Contains(out, warningSign+" xid") {
+		t.Errorf("expected xid to be unhealthy:\n%s", out)
+	}
+	if !strings.Contains(out, "xid 79") || !strings.Contains(out, "fatal") {
+		t.Errorf("expected the reason and severity:\n%s", out)
+	}
+}
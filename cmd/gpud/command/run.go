@@ -37,13 +37,37 @@ func cmdRun(cliContext *cli.Context) error {
 		gin.SetMode(gin.DebugMode)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
-	cfg, err := config.DefaultConfig(ctx)
-	cancel()
-	if err != nil {
-		return err
+	var cfg *config.Config
+	if configFile != "" {
+		var err error
+		cfg, err = config.LoadConfigYAML(configFile)
+		if err != nil {
+			return err
+		}
+		// same as the default config, otherwise the states are kept in memory
+		// and lost on restart
+		if cfg.State == "" {
+			cfg.State, err = config.DefaultStateFile()
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		var err error
+		cfg, err = config.DefaultConfig(ctx)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+	if cfg.Web == nil {
+		cfg.Web = &config.Web{}
 	}
 
+	// flags with default values only override the config file when explicitly set
+	overrideDefaults := configFile == ""
+
 	if annotations != "" {
 		annot := make(map[string]string)
 		if err := json.Unmarshal([]byte(annotations), &annot); err != nil {
@@ -51,13 +75,13 @@ func cmdRun(cliContext *cli.Context) error {
 		}
 		cfg.Annotations = annot
 	}
-	if listenAddress != "" {
+	if listenAddress != "" && (overrideDefaults || cliContext.IsSet("listen-address")) {
 		cfg.Address = listenAddress
 	}
 	if pprof {
 		cfg.Pprof = true
 	}
-	if retentionPeriod > 0 {
+	if retentionPeriod > 0 && (overrideDefaults || cliContext.IsSet("retention-period")) {
 		cfg.RetentionPeriod = metav1.Duration{Duration: retentionPeriod}
 		cfg.Web.SincePeriod = metav1.Duration{Duration: retentionPeriod}
 	}
@@ -67,7 +91,7 @@ func cmdRun(cliContext *cli.Context) error {
 	if webAdmin {
 		cfg.Web.Admin = true
 	}
-	if webRefreshPeriod > 0 {
+	if webRefreshPeriod > 0 && (overrideDefaults || cliContext.IsSet("web-refresh-period")) {
 		cfg.Web.RefreshPeriod = metav1.Duration{Duration: webRefreshPeriod}
 	}
 	if err := cfg.Validate(); err != nil {
@@ -88,7 +112,7 @@ func cmdRun(cliContext *cli.Context) error {
 	// we don't miss any signals during boot
 	signal.Notify(signals, handledSignals...)
 
-	server, err := lepServer.New(rootCtx, cfg, cliContext.String("endpoint"))
+	server, err := lepServer.New(rootCtx, cfg, cliContext.String("endpoint"), configFile)
 	if err != nil {
 		return err
 	}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/leptonai/gpud/internal/server"
 	"github.com/leptonai/gpud/log"
@@ -20,6 +21,7 @@ var handledSignals = []os.Signal{
 	unix.SIGINT,
 	unix.SIGUSR1,
 	unix.SIGPIPE,
+	unix.SIGHUP,
 }
 
 func handleSignals(ctx context.Context, cancel context.CancelFunc, signals chan os.Signal, serverC chan *server.Server) chan struct{} {
@@ -42,6 +44,12 @@ func handleSignals(ctx context.Context, cancel context.CancelFunc, signals chan
 				switch s {
 				case unix.SIGUSR1:
 					dumpStacks(true)
+				case unix.SIGHUP:
+					if server == nil {
+						log.Logger.Warnw("server not ready, skipping config reload")
+						continue
+					}
+					go reloadConfig(ctx, server)
 				default:
 					cancel()
 
@@ -66,6 +74,16 @@ func handleSignals(ctx context.Context, cancel context.CancelFunc, signals chan
 	return done
 }
 
+// reloadConfig reloads the server configuration file
+// without blocking the signal handler.
+func reloadConfig(ctx context.Context, s *server.Server) {
+	cctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	if _, err := s.ReloadConfig(cctx, server.ConfigReloadTriggerSignal); err != nil {
+		log.Logger.Warnw("failed to reload config", "error", err)
+	}
+}
+
 // notifyReady notifies systemd that the daemon is ready to serve requests
 func notifyReady(ctx context.Context) error {
 	return sdNotify(ctx, sd.SdNotifyReady)
@@ -107,6 +107,7 @@ func (o *Output) States() ([]components.State, error) {
 		)
 	}
 
+	severity, actions := o.EvaluateSeverity()
 	b, _ := o.JSON()
 	state := components.State{
 		Name:    StateNameECCErrors,
@@ -116,6 +117,23 @@ func (o *Output) States() ([]components.State, error) {
 			StateKeyECCErrorsData:     string(b),
 			StateKeyECCErrorsEncoding: StateValueECCErrorsEncodingJSON,
 		},
+		Severity:         severity,
+		SuggestedActions: actions,
 	}
 	return []components.State{state}, nil
 }
+
+// Returns the severity level and the suggested actions.
+// Uncorrected (double bit) errors require a GPU reset to retire the pages,
+// while corrected (single bit) errors are informational.
+func (o *Output) EvaluateSeverity() (components.Severity, []components.SuggestedAction) {
+	if len(o.VolatileUncorrectedErrors) > 0 {
+		return components.SeverityCritical, []components.SuggestedAction{components.SuggestedActionResetGPU}
+	}
+	for _, errs := range o.ErrorCountsNVML {
+		if errs.Volatile.Total.Corrected > 0 {
+			return components.SeverityInfo, []components.SuggestedAction{components.SuggestedActionNone}
+		}
+	}
+	return components.SeverityInfo, nil
+}
@@ -0,0 +1,29 @@
+package ecc
+
+import (
+	"context"
+	"database/sql"
+	"runtime"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	query_config "github.com/leptonai/gpud/components/query/config"
+)
+
+func init() {
+	components.RegisterFactory(components.Factory{
+		Name: Name,
+		AutoDetect: func(ctx context.Context) bool {
+			return runtime.GOOS == "linux" && nvidia_query.SMIExists()
+		},
+		ParseConfig: func(raw any, db *sql.DB) (any, error) {
+			if raw == nil {
+				return &Config{Query: query_config.Config{State: &query_config.State{DB: db}}}, nil
+			}
+			return ParseConfig(raw, db)
+		},
+		New: func(ctx context.Context, cfg any, _ components.FactoryOptions) (components.Component, error) {
+			return New(ctx, *cfg.(*Config)), nil
+		},
+	})
+}
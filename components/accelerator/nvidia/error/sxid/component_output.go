@@ -82,11 +82,37 @@ func (o *Output) Evaluate() (string, bool, error) {
 	return "sxid error found from dmesg\n\n" + string(yb), false, nil
 }
 
+// Returns the most severe level among the sxid errors
+// and the actions to recover from all of them.
+func (o *Output) EvaluateSeverity() (components.Severity, []components.SuggestedAction) {
+	if len(o.DmesgErrors) == 0 {
+		return components.SeverityInfo, nil
+	}
+
+	severity := components.SeverityInfo
+	actions := make([][]components.SuggestedAction, 0, len(o.DmesgErrors))
+	for _, de := range o.DmesgErrors {
+		if de.Detail == nil {
+			// unknown sxid, not in the documented list
+			if components.SeverityWarning.MoreSevere(severity) {
+				severity = components.SeverityWarning
+			}
+			continue
+		}
+		if de.Detail.Severity().MoreSevere(severity) {
+			severity = de.Detail.Severity()
+		}
+		actions = append(actions, de.Detail.SuggestedActions())
+	}
+	return severity, components.MergeSuggestedActions(actions...)
+}
+
 func (o *Output) States() ([]components.State, error) {
 	outputReasons, healthy, err := o.Evaluate()
 	if err != nil {
 		return nil, err
 	}
+	severity, actions := o.EvaluateSeverity()
 	b, _ := o.JSON()
 	state := components.State{
 		Name:    StateNameErrorSXid,
@@ -96,6 +122,8 @@ func (o *Output) States() ([]components.State, error) {
 			StateKeyErrorSXidData:     string(b),
 			StateKeyErrorSXidEncoding: StateValueErrorSXidEncodingJSON,
 		},
+		Severity:         severity,
+		SuggestedActions: actions,
 	}
 
 	return []components.State{state}, nil
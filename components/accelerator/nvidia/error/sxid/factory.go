@@ -0,0 +1,37 @@
+package sxid
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"runtime"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	"github.com/leptonai/gpud/components/dmesg"
+)
+
+func init() {
+	components.RegisterFactory(components.Factory{
+		Name: Name,
+		AutoDetect: func(ctx context.Context) bool {
+			// requires dmesg to scan the sxid errors
+			return runtime.GOOS == "linux" && nvidia_query.SMIExists() && dmesg.DmesgExists()
+		},
+		ParseConfig: func(raw any, db *sql.DB) (any, error) {
+			// no config
+			return nil, nil
+		},
+		New: func(ctx context.Context, cfg any, opts components.FactoryOptions) (components.Component, error) {
+			// sxid cannot be used without dmesg
+			found, err := dmesg.HasSelectFilter(opts.Components[dmesg.Name], dmesg.EventNvidiaNVSwitchSXid)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				return nil, fmt.Errorf("%q enabled but dmesg config missing %q filter", Name, dmesg.EventNvidiaNVSwitchSXid)
+			}
+			return New(), nil
+		},
+	})
+}
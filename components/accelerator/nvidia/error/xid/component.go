@@ -12,6 +12,7 @@ import (
 	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
 	nvidia_query_xid "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid"
 	"github.com/leptonai/gpud/components/dmesg"
+	"github.com/leptonai/gpud/components/k8s/podresources"
 	"github.com/leptonai/gpud/components/query"
 	"github.com/leptonai/gpud/log"
 )
@@ -79,6 +80,7 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 		}
 		o.DmesgErrors = append(o.DmesgErrors, ev)
 	}
+	o.setOwners(getDeviceUUID, podresources.GetDeviceOwner)
 
 	last, err := c.poller.Last()
 	if err != nil {
@@ -136,6 +138,7 @@ func (c *component) Events(ctx context.Context, since time.Time) ([]components.E
 		}
 		o.DmesgErrors = append(o.DmesgErrors, ev)
 	}
+	o.setOwners(getDeviceUUID, podresources.GetDeviceOwner)
 	return o.Events(), nil
 }
 
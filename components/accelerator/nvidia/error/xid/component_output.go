@@ -6,11 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/leptonai/gpud/components"
 	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
 	nvidia_query_xid "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid"
+	"github.com/leptonai/gpud/components/k8s/podresources"
 	components_metrics "github.com/leptonai/gpud/components/metrics"
 	"github.com/leptonai/gpud/components/query"
 
@@ -81,6 +83,10 @@ const (
 	StateKeyErrorXidData           = "data"
 	StateKeyErrorXidEncoding       = "encoding"
 	StateValueErrorXidEncodingJSON = "json"
+
+	// StateKeyErrorXidOwner is the comma-separated pod containers that the GPUs with the xid errors
+	// are allocated to, only set if the kubelet pod-resources component is enabled.
+	StateKeyErrorXidOwner = "owner"
 )
 
 func ParseStateErrorXid(m map[string]string) (*Output, error) {
@@ -130,11 +136,44 @@ func (o *Output) Evaluate() (string, bool, error) {
 	return reason, false, nil
 }
 
+// Returns the most severe level among the xid errors
+// and the actions to recover from all of them.
+func (o *Output) EvaluateSeverity() (components.Severity, []components.SuggestedAction) {
+	details := make([]*nvidia_query_xid.Detail, 0, len(o.DmesgErrors)+1)
+	for _, de := range o.DmesgErrors {
+		details = append(details, de.Detail)
+	}
+	if o.NVMLXidEvent != nil && o.NVMLXidEvent.Xid > 0 {
+		details = append(details, o.NVMLXidEvent.Detail)
+	}
+	if len(details) == 0 {
+		return components.SeverityInfo, nil
+	}
+
+	severity := components.SeverityInfo
+	actions := make([][]components.SuggestedAction, 0, len(details))
+	for _, d := range details {
+		if d == nil {
+			// unknown xid, not in the documented list
+			if components.SeverityWarning.MoreSevere(severity) {
+				severity = components.SeverityWarning
+			}
+			continue
+		}
+		if d.Severity().MoreSevere(severity) {
+			severity = d.Severity()
+		}
+		actions = append(actions, d.SuggestedActions())
+	}
+	return severity, components.MergeSuggestedActions(actions...)
+}
+
 func (o *Output) States() ([]components.State, error) {
 	outputReasons, healthy, err := o.Evaluate()
 	if err != nil {
 		return nil, err
 	}
+	severity, actions := o.EvaluateSeverity()
 	b, _ := o.JSON()
 	state := components.State{
 		Name:    StateNameErrorXid,
@@ -144,10 +183,63 @@ func (o *Output) States() ([]components.State, error) {
 			StateKeyErrorXidData:     string(b),
 			StateKeyErrorXidEncoding: StateValueErrorXidEncodingJSON,
 		},
+		Severity:         severity,
+		SuggestedActions: actions,
+	}
+
+	owners := make([]string, 0)
+	seen := make(map[string]struct{})
+	addOwner := func(uuid string, owner *podresources.DeviceOwner) {
+		if owner == nil {
+			return
+		}
+		if _, ok := seen[uuid]; ok {
+			return
+		}
+		seen[uuid] = struct{}{}
+		owners = append(owners, owner.String())
+		if !healthy {
+			state.Reason += fmt.Sprintf("\n\nGPU %s is allocated to %s", uuid, owner)
+		}
+	}
+	for _, de := range o.DmesgErrors {
+		addOwner(de.DeviceUUID, de.Owner)
+	}
+	if o.NVMLXidEvent != nil {
+		addOwner(o.NVMLXidEvent.DeviceUUID, o.NVMLXidEvent.Owner)
+	}
+	if len(owners) > 0 {
+		state.ExtraInfo[StateKeyErrorXidOwner] = strings.Join(owners, ", ")
 	}
 	return []components.State{state}, nil
 }
 
+// setOwners sets the UUID of the device with the PCI bus ID in each dmesg xid error,
+// and the pod container that the device is currently allocated to.
+func (o *Output) setOwners(getDeviceUUID func(pciBusID string) (string, bool), getOwner func(deviceID string) (*podresources.DeviceOwner, bool)) {
+	for i := range o.DmesgErrors {
+		de := &o.DmesgErrors[i]
+		if de.PCIBusID == "" {
+			continue
+		}
+		uuid, ok := getDeviceUUID(de.PCIBusID)
+		if !ok {
+			continue
+		}
+		de.DeviceUUID = uuid
+		de.Owner, _ = getOwner(uuid)
+	}
+}
+
+// getDeviceUUID returns the UUID of the device with the PCI bus ID via NVML.
+func getDeviceUUID(pciBusID string) (string, bool) {
+	inst := nvidia_query_nvml.DefaultInstance()
+	if inst == nil {
+		return "", false
+	}
+	return inst.DeviceUUIDByPCIBusID(pciBusID)
+}
+
 const (
 	EventNameErroXid = "error_xid"
 
@@ -155,20 +247,28 @@ const (
 	EventKeyErroXidData           = "data"
 	EventKeyErroXidEncoding       = "encoding"
 	EventValueErroXidEncodingJSON = "json"
+
+	// EventKeyErroXidOwner is the pod container that the GPU with the xid error is allocated to,
+	// only set if the kubelet pod-resources component is enabled.
+	EventKeyErroXidOwner = "owner"
 )
 
 func (o *Output) Events() []components.Event {
 	des := make([]components.Event, 0)
 	for _, de := range o.DmesgErrors {
 		b, _ := de.JSON()
-		des = append(des, components.Event{
+		ev := components.Event{
 			Name: EventNameErroXid,
 			ExtraInfo: map[string]string{
 				EventKeyErroXidUnixSeconds: strconv.FormatInt(de.LogItem.Time.Unix(), 10),
 				EventKeyErroXidData:        string(b),
 				EventKeyErroXidEncoding:    StateValueErrorXidEncodingJSON,
 			},
-		})
+		}
+		if de.Owner != nil {
+			ev.ExtraInfo[EventKeyErroXidOwner] = de.Owner.String()
+		}
+		des = append(des, ev)
 	}
 	if len(des) == 0 {
 		return nil
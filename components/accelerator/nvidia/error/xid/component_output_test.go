@@ -0,0 +1,83 @@
+package xid
+
+import (
+	"strings"
+	"testing"
+
+	nvidia_query_xid "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid"
+	"github.com/leptonai/gpud/components/k8s/podresources"
+)
+
+func TestOutputDmesgErrorOwners(t *testing.T) {
+	t.Parallel()
+
+	o := &Output{}
+	for _, line := range []string{
+		"NVRM: Xid (PCI:0000:05:00): 79, pid='<unknown>', name=<unknown>, GPU has fallen off the bus.",
+		"NVRM: Xid (0000:06:00): 79, GPU has fallen off the bus.",
+		"NVRM: Xid (PCI:0000:07:00): 79, GPU has fallen off the bus.",
+	} {
+		de, err := nvidia_query_xid.ParseDmesgLogLine(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		o.DmesgErrors = append(o.DmesgErrors, de)
+	}
+
+	uuids := map[string]string{
+		"0000:05:00": "GPU-0",
+		"0000:06:00": "GPU-1",
+	}
+	owners := map[string]*podresources.DeviceOwner{
+		"GPU-0": {DeviceID: "GPU-0", Namespace: "default", Pod: "train-0", Container: "main"},
+	}
+	o.setOwners(
+		func(pciBusID string) (string, bool) {
+			uuid, ok := uuids[pciBusID]
+			return uuid, ok
+		},
+		func(deviceID string) (*podresources.DeviceOwner, bool) {
+			owner, ok := owners[deviceID]
+			return owner, ok
+		},
+	)
+
+	if de := o.DmesgErrors[0]; de.DeviceUUID != "GPU-0" || de.Owner == nil || de.Owner.Pod != "train-0" {
+		t.Fatalf("unexpected dmesg error %+v", de)
+	}
+	if de := o.DmesgErrors[1]; de.DeviceUUID != "GPU-1" || de.Owner != nil {
+		t.Fatalf("expected the unallocated device, got %+v", de)
+	}
+	if de := o.DmesgErrors[2]; de.DeviceUUID != "" || de.Owner != nil {
+		t.Fatalf("expected the unknown device, got %+v", de)
+	}
+
+	states, err := o.States()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := states[0].ExtraInfo[StateKeyErrorXidOwner]; got != "default/train-0 (container main)" {
+		t.Fatalf("unexpected state owner %q", got)
+	}
+	if !strings.Contains(states[0].Reason, "GPU GPU-0 is allocated to default/train-0 (container main)") {
+		t.Fatalf("expected the owner in the reason, got %q", states[0].Reason)
+	}
+
+	events := o.Events()
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	if got := events[0].ExtraInfo[EventKeyErroXidOwner]; got != "default/train-0 (container main)" {
+		t.Fatalf("unexpected event owner %q", got)
+	}
+	if _, ok := events[1].ExtraInfo[EventKeyErroXidOwner]; ok {
+		t.Fatal("expected no owner for the unallocated device")
+	}
+	de, err := nvidia_query_xid.ParseDmesgErrorJSON([]byte(events[0].ExtraInfo[EventKeyErroXidData]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if de.PCIBusID != "0000:05:00" || de.DeviceUUID != "GPU-0" || de.Owner == nil {
+		t.Fatalf("unexpected event data %+v", de)
+	}
+}
@@ -0,0 +1,40 @@
+package xid
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"runtime"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
+	"github.com/leptonai/gpud/components/dmesg"
+	query_config "github.com/leptonai/gpud/components/query/config"
+)
+
+func init() {
+	components.RegisterFactory(components.Factory{
+		Name: Name,
+		AutoDetect: func(ctx context.Context) bool {
+			// requires dmesg to scan the xid errors
+			return runtime.GOOS == "linux" && nvidia_query.SMIExists() && dmesg.DmesgExists()
+		},
+		ParseConfig: func(raw any, db *sql.DB) (any, error) {
+			if raw == nil {
+				return &Config{Query: query_config.Config{State: &query_config.State{DB: db}}}, nil
+			}
+			return ParseConfig(raw, db)
+		},
+		New: func(ctx context.Context, cfg any, opts components.FactoryOptions) (components.Component, error) {
+			// xid cannot be used without dmesg
+			found, err := dmesg.HasSelectFilter(opts.Components[dmesg.Name], dmesg.EventNvidiaNVRMXid)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				return nil, fmt.Errorf("%q enabled but dmesg config missing %q filter", Name, dmesg.EventNvidiaNVRMXid)
+			}
+			return New(ctx, *cfg.(*Config)), nil
+		},
+	})
+}
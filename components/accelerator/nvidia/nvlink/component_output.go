@@ -86,11 +86,32 @@ func (o *Output) Evaluate() (string, bool, error) {
 	return reason, true, nil
 }
 
+// Returns the severity level and the suggested actions.
+// CRC and replay errors are retried by the link layer, thus only a warning,
+// while recovery errors mean the link had to be retrained.
+func (o *Output) EvaluateSeverity() (components.Severity, []components.SuggestedAction) {
+	severity := components.SeverityInfo
+	var actions []components.SuggestedAction
+	for _, device := range o.NVLinkDevices {
+		for _, link := range device.States {
+			if link.RecoveryErrors > 0 {
+				severity = components.SeverityDegraded
+				actions = []components.SuggestedAction{components.SuggestedActionHardwareInspection}
+			} else if (link.CRCErrors > 0 || link.ReplayErrors > 0) && severity == components.SeverityInfo {
+				severity = components.SeverityWarning
+				actions = []components.SuggestedAction{components.SuggestedActionNone}
+			}
+		}
+	}
+	return severity, actions
+}
+
 func (o *Output) States() ([]components.State, error) {
 	outputReasons, healthy, err := o.Evaluate()
 	if err != nil {
 		return nil, err
 	}
+	severity, actions := o.EvaluateSeverity()
 	b, _ := o.JSON()
 	state := components.State{
 		Name:    StateNameNVLinkDevices,
@@ -100,6 +121,8 @@ func (o *Output) States() ([]components.State, error) {
 			StateKeyNVLinkDevicesData:     string(b),
 			StateKeyNVLinkDevicesEncoding: StateValueNVLinkDevicesEncodingJSON,
 		},
+		Severity:         severity,
+		SuggestedActions: actions,
 	}
 	return []components.State{state}, nil
 }
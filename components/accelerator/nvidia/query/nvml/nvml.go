@@ -10,6 +10,7 @@ import (
 	"sync"
 
 	nvidia_query_xid "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid"
+	"github.com/leptonai/gpud/components/k8s/podresources"
 	"github.com/leptonai/gpud/log"
 
 	"github.com/NVIDIA/go-nvlib/pkg/nvlib/device"
@@ -29,6 +30,8 @@ type Instance interface {
 	Start() error
 	RecvXidEvents() <-chan *XidEvent
 	Exists() bool
+	DeviceCount() int
+	DeviceUUIDByPCIBusID(pciBusID string) (string, bool)
 	Shutdown() error
 	Get() (*Output, error)
 }
@@ -68,6 +71,9 @@ type DeviceInfo struct {
 	Bus uint32 `json:"bus"`
 	// Device ID is the device ID from PCI info API.
 	Device uint32 `json:"device"`
+	// PCIBusID is the "domain:bus:device" from PCI info API,
+	// in the same format as the dmesg xid messages (e.g., "0000:05:00").
+	PCIBusID string `json:"pci_bus_id"`
 
 	Name            string `json:"name"`
 	GPUCores        int    `json:"gpu_cores"`
@@ -86,6 +92,10 @@ type DeviceInfo struct {
 	Processes   Processes   `json:"processes"`
 	ECCErrors   ECCErrors   `json:"ecc_errors"`
 
+	// Owner is the pod container that the device is allocated to,
+	// set if the kubelet pod-resources component is enabled.
+	Owner *podresources.DeviceOwner `json:"owner,omitempty"`
+
 	device device.Device `json:"-"`
 }
 
@@ -97,6 +107,12 @@ type XidEvent struct {
 
 	Detail *nvidia_query_xid.Detail `json:"detail,omitempty"`
 
+	// DeviceUUID is the UUID of the device that the event is received from.
+	DeviceUUID string `json:"device_uuid,omitempty"`
+	// Owner is the pod container that the device was allocated to when the event was received,
+	// set if the kubelet pod-resources component is enabled.
+	Owner *podresources.DeviceOwner `json:"owner,omitempty"`
+
 	Message string `json:"message,omitempty"`
 
 	// Set if any error happens during NVML calls.
@@ -210,6 +226,7 @@ func (inst *instance) Start() error {
 			MinorNumber:     minorNumber,
 			Bus:             pciInfo.Bus,
 			Device:          pciInfo.Device,
+			PCIBusID:        fmt.Sprintf("%04x:%02x:%02x", pciInfo.Domain, pciInfo.Bus, pciInfo.Device),
 			Name:            name,
 			GPUCores:        cores,
 			SupportedEvents: supportedEvents,
@@ -289,6 +306,12 @@ func (inst *instance) pollXidEvents() {
 
 			Message: msg,
 		}
+		if e.Device != nil {
+			if uuid, ret := e.Device.GetUUID(); ret == nvml.SUCCESS {
+				event.DeviceUUID = uuid
+				event.Owner, _ = podresources.GetDeviceOwner(uuid)
+			}
+		}
 		select {
 		case <-inst.rootCtx.Done():
 			return
@@ -315,6 +338,28 @@ func (inst *instance) Exists() bool {
 	return inst.nvmlLib != nil && inst.nvmlExists
 }
 
+// DeviceCount returns the number of the devices found when the instance started.
+func (inst *instance) DeviceCount() int {
+	inst.mu.RLock()
+	defer inst.mu.RUnlock()
+
+	return len(inst.devices)
+}
+
+// DeviceUUIDByPCIBusID returns the UUID of the device with the PCI bus ID (e.g., "0000:05:00").
+// Returns false if no device is found.
+func (inst *instance) DeviceUUIDByPCIBusID(pciBusID string) (string, bool) {
+	inst.mu.RLock()
+	defer inst.mu.RUnlock()
+
+	for uuid, dev := range inst.devices {
+		if dev.PCIBusID == pciBusID {
+			return uuid, true
+		}
+	}
+	return "", false
+}
+
 func (inst *instance) Shutdown() error {
 	inst.mu.Lock()
 	defer inst.mu.Unlock()
@@ -366,6 +411,7 @@ func (inst *instance) Get() (*Output, error) {
 			MinorNumber: devInfo.MinorNumber,
 			Bus:         devInfo.Bus,
 			Device:      devInfo.Device,
+			PCIBusID:    devInfo.PCIBusID,
 
 			Name:            devInfo.Name,
 			GPUCores:        devInfo.GPUCores,
@@ -374,6 +420,7 @@ func (inst *instance) Get() (*Output, error) {
 
 			device: devInfo.device,
 		}
+		latestInfo.Owner, _ = podresources.GetDeviceOwner(devInfo.UUID)
 		st.DeviceInfos = append(st.DeviceInfos, latestInfo)
 
 		var err error
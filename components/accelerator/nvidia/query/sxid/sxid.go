@@ -1,5 +1,7 @@
 package sxid
 
+import "github.com/leptonai/gpud/components"
+
 // Defines the SXID error type.
 // ref. https://docs.nvidia.com/datacenter/tesla/pdf/fabric-manager-user-guide.pdf
 type Detail struct {
@@ -20,6 +22,31 @@ func GetDetail(id int) (*Detail, bool) {
 	return &e, ok
 }
 
+// Severity returns the severity of the SXid error based on its fatality.
+func (d *Detail) Severity() components.Severity {
+	switch {
+	case d.AlwaysFatal:
+		return components.SeverityFatal
+	case d.PotentialFatal:
+		return components.SeverityCritical
+	default:
+		return components.SeverityWarning
+	}
+}
+
+// SuggestedActions returns the actions to recover from the SXid error.
+func (d *Detail) SuggestedActions() []components.SuggestedAction {
+	switch {
+	case d.AlwaysFatal:
+		return []components.SuggestedAction{components.SuggestedActionReboot, components.SuggestedActionHardwareInspection}
+	case d.PotentialFatal:
+		// the GPUs and NVSwitches in the partition need to be reset
+		return []components.SuggestedAction{components.SuggestedActionResetGPU}
+	default:
+		return []components.SuggestedAction{components.SuggestedActionRestartWorkload}
+	}
+}
+
 // These are copied from:
 // "D.4 Non-Fatal NVSwitch SXid Errors"
 // "D.5 Fatal NVSwitch SXid Errors"
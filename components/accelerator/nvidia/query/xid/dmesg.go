@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"regexp"
 	"strconv"
+	"strings"
 
+	"github.com/leptonai/gpud/components/k8s/podresources"
 	query_log "github.com/leptonai/gpud/components/query/log"
 
 	"sigs.k8s.io/yaml"
@@ -19,9 +21,17 @@ const (
 	// ref.
 	// https://docs.nvidia.com/deploy/pdf/XID_Errors.pdf
 	RegexNVRMXidDmesg = `NVRM: Xid.*?: (\d+),`
+
+	// e.g.,
+	// "0000:03:00" in "NVRM: Xid (0000:03:00): 14, Channel 00000001"
+	// "0000:05:00" in "NVRM: Xid (PCI:0000:05:00): 79, pid='<unknown>', ..."
+	RegexNVRMXidPCIBusIDDmesg = `NVRM: Xid \((?:PCI:)?([0-9a-fA-F]+:[0-9a-fA-F]+:[0-9a-fA-F]+)`
 )
 
-var CompiledRegexNVRMXidDmesg = regexp.MustCompile(RegexNVRMXidDmesg)
+var (
+	CompiledRegexNVRMXidDmesg         = regexp.MustCompile(RegexNVRMXidDmesg)
+	CompiledRegexNVRMXidPCIBusIDDmesg = regexp.MustCompile(RegexNVRMXidPCIBusIDDmesg)
+)
 
 // Extracts the nvidia Xid error code from the dmesg log line.
 // Returns 0 if the error code is not found.
@@ -35,10 +45,28 @@ func ExtractNVRMXid(line string) int {
 	return 0
 }
 
+// Extracts the PCI bus ID of the device (e.g., "0000:05:00") from the dmesg log line,
+// in lower case as the NVML PCI bus ID of the device.
+// Returns an empty string if the PCI bus ID is not found.
+func ExtractNVRMXidPCIBusID(line string) string {
+	if match := CompiledRegexNVRMXidPCIBusIDDmesg.FindStringSubmatch(line); match != nil {
+		return strings.ToLower(match[1])
+	}
+	return ""
+}
+
 type DmesgError struct {
 	Detail      *Detail        `json:"detail,omitempty"`
 	DetailFound bool           `json:"detail_found"`
 	LogItem     query_log.Item `json:"log_item"`
+
+	// PCIBusID is the PCI bus ID of the device in the log line (e.g., "0000:05:00").
+	PCIBusID string `json:"pci_bus_id,omitempty"`
+	// DeviceUUID is the UUID of the device with the PCI bus ID, set if found via NVML.
+	DeviceUUID string `json:"device_uuid,omitempty"`
+	// Owner is the pod container that the device is allocated to,
+	// set if the kubelet pod-resources component is enabled.
+	Owner *podresources.DeviceOwner `json:"owner,omitempty"`
 }
 
 func (de *DmesgError) JSON() ([]byte, error) {
@@ -71,6 +99,7 @@ func ParseDmesgLogLine(line string) (DmesgError, error) {
 			Line:    line,
 			Matched: nil,
 		},
+		PCIBusID: ExtractNVRMXidPCIBusID(line),
 	}
 
 	errCode := ExtractNVRMXid(line)
@@ -51,3 +51,19 @@ func TestExtractNVRMXid(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractNVRMXidPCIBusID(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"[111111111.111] NVRM: Xid (PCI:0000:05:00): 79, pid='<unknown>', name=<unknown>, GPU has fallen off the bus.": "0000:05:00",
+		"NVRM: Xid (PCI:0000:B1:00): 79, GPU has fallen off the bus.":                                                  "0000:b1:00",
+		"[...] NVRM: Xid (0000:03:00): 14, Channel 00000001":                                                           "0000:03:00",
+		"NVRM: Xid critical error: 79, details follow":                                                                 "",
+	}
+	for line, want := range tests {
+		if got := ExtractNVRMXidPCIBusID(line); got != want {
+			t.Errorf("ExtractNVRMXidPCIBusID(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
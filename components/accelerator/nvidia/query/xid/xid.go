@@ -1,5 +1,7 @@
 package xid
 
+import "github.com/leptonai/gpud/components"
+
 // Defines the XID error type.
 // ref. https://docs.nvidia.com/deploy/pdf/XID_Errors.pdf
 // ref. https://docs.nvidia.com/deploy/xid-errors/index.html#xid-error-listing
@@ -27,6 +29,41 @@ func GetDetail(id int) (*Detail, bool) {
 	return &e, ok
 }
 
+// Severity returns the severity of the Xid error based on its error types.
+func (d *Detail) Severity() components.Severity {
+	switch {
+	case d.HWError && !d.UserAppError && (d.BusError || d.SystemMemoryCorruption):
+		// e.g., Xid 79 "GPU has fallen off the bus"
+		return components.SeverityFatal
+	case d.HWError && !d.UserAppError:
+		// e.g., Xid 48 "Double Bit ECC Error"
+		return components.SeverityCritical
+	case d.HWError:
+		// e.g., Xid 13 "Graphics Engine Exception" may be caused by the user application
+		return components.SeverityDegraded
+	case d.DriverError || d.UserAppError:
+		return components.SeverityWarning
+	default:
+		return components.SeverityInfo
+	}
+}
+
+// SuggestedActions returns the actions to recover from the Xid error.
+func (d *Detail) SuggestedActions() []components.SuggestedAction {
+	switch d.Severity() {
+	case components.SeverityFatal:
+		return []components.SuggestedAction{components.SuggestedActionReboot, components.SuggestedActionHardwareInspection}
+	case components.SeverityCritical:
+		return []components.SuggestedAction{components.SuggestedActionResetGPU}
+	case components.SeverityDegraded:
+		return []components.SuggestedAction{components.SuggestedActionRestartWorkload, components.SuggestedActionResetGPU}
+	case components.SeverityWarning:
+		return []components.SuggestedAction{components.SuggestedActionRestartWorkload}
+	default:
+		return []components.SuggestedAction{components.SuggestedActionNone}
+	}
+}
+
 // Copied from https://docs.nvidia.com/deploy/xid-details/index.html#xid-error-listing.
 // See https://docs.nvidia.com/deploy/gpu-debug-guidelines/index.html#xid-messages for more details.
 var details = map[int]Detail{
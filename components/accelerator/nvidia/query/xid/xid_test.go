@@ -0,0 +1,32 @@
+package xid
+
+import (
+	"testing"
+
+	"github.com/leptonai/gpud/components"
+)
+
+func TestDetailSeverity(t *testing.T) {
+	tests := []struct {
+		id       int
+		severity components.Severity
+	}{
+		{id: 79, severity: components.SeverityFatal},
+		{id: 48, severity: components.SeverityCritical},
+		{id: 13, severity: components.SeverityDegraded},
+		{id: 43, severity: components.SeverityWarning},
+		{id: 61, severity: components.SeverityInfo},
+	}
+	for _, tt := range tests {
+		d, ok := GetDetail(tt.id)
+		if !ok {
+			t.Fatalf("xid %d not found", tt.id)
+		}
+		if got := d.Severity(); got != tt.severity {
+			t.Errorf("xid %d: severity = %q, want %q", tt.id, got, tt.severity)
+		}
+		if len(d.SuggestedActions()) == 0 {
+			t.Errorf("xid %d: expected suggested actions", tt.id)
+		}
+	}
+}
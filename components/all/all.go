@@ -0,0 +1,39 @@
+// Package all registers all the in-tree component factories.
+// Import this package for its side effects, and blank import
+// any out-of-tree component package in the same way.
+package all
+
+import (
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/clock"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/clock-speed"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/ecc"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/error"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/error/sxid"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/error/xid"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/fabric-manager"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/infiniband"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/info"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/memory"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/nvlink"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/peermem"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/power"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/processes"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/temperature"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/utilization"
+	_ "github.com/leptonai/gpud/components/containerd/pod"
+	_ "github.com/leptonai/gpud/components/cpu"
+	_ "github.com/leptonai/gpud/components/custom-check"
+	_ "github.com/leptonai/gpud/components/disk"
+	_ "github.com/leptonai/gpud/components/dmesg"
+	_ "github.com/leptonai/gpud/components/docker/container"
+	_ "github.com/leptonai/gpud/components/fd"
+	_ "github.com/leptonai/gpud/components/info"
+	_ "github.com/leptonai/gpud/components/k8s/pod"
+	_ "github.com/leptonai/gpud/components/k8s/podresources"
+	_ "github.com/leptonai/gpud/components/memory"
+	_ "github.com/leptonai/gpud/components/network/latency"
+	_ "github.com/leptonai/gpud/components/os"
+	_ "github.com/leptonai/gpud/components/power-supply"
+	_ "github.com/leptonai/gpud/components/systemd"
+	_ "github.com/leptonai/gpud/components/tailscale"
+)
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,6 +20,52 @@ import (
 // Useful to intercept the component states method calls to track metrics.
 type WatchableComponent interface {
 	Component
+
+	// LastHealth returns the health evaluated from the last States call.
+	// Returns false if the States method has not been called yet.
+	LastHealth() (HealthStatus, bool)
+}
+
+// HealthStatus is the health of a component evaluated from its states.
+type HealthStatus struct {
+	Healthy bool `json:"healthy"`
+	// The most severe level among the states, empty if unknown.
+	Severity Severity `json:"severity,omitempty"`
+	// The reasons of the unhealthy states.
+	Reason string `json:"reason,omitempty"`
+	// The merged suggested actions of all the states.
+	SuggestedActions []SuggestedAction `json:"suggested_actions,omitempty"`
+	// The error returned from the States method, if any.
+	Error string `json:"error,omitempty"`
+	// The time when the health was evaluated.
+	Time metav1.Time `json:"time"`
+}
+
+// EvaluateHealth evaluates the component health from its states.
+// The component is healthy only if all the states are healthy.
+func EvaluateHealth(states []State) HealthStatus {
+	hs := HealthStatus{
+		Healthy: true,
+		Time:    metav1.Time{Time: time.Now().UTC()},
+	}
+
+	reasons := make([]string, 0)
+	actions := make([][]SuggestedAction, 0, len(states))
+	for _, st := range states {
+		if !st.Healthy {
+			hs.Healthy = false
+			if st.Reason != "" {
+				reasons = append(reasons, st.Reason)
+			}
+		}
+		if st.Severity.MoreSevere(hs.Severity) {
+			hs.Severity = st.Severity
+		}
+		actions = append(actions, st.SuggestedActions)
+	}
+	hs.Reason = strings.Join(reasons, "; ")
+	hs.SuggestedActions = MergeSuggestedActions(actions...)
+	return hs
 }
 
 // Component represents an individual component of the system.
@@ -70,6 +118,89 @@ type State struct {
 	Reason    string            `json:"reason,omitempty"`     // a detailed and processed reason on why the component is not healthy
 	Error     string            `json:"error,omitempty"`      // the unprocessed error returned from the component
 	ExtraInfo map[string]string `json:"extra_info,omitempty"` // any extra information the component may want to expose
+
+	Severity         Severity          `json:"severity,omitempty"`          // optional: how bad the state is, empty if unknown
+	SuggestedActions []SuggestedAction `json:"suggested_actions,omitempty"` // optional: the actions to recover from the state
+}
+
+// Severity is the severity level of the component state.
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityDegraded Severity = "degraded"
+	SeverityCritical Severity = "critical"
+	SeverityFatal    Severity = "fatal"
+)
+
+var severityRanks = map[Severity]int{
+	SeverityInfo:     1,
+	SeverityWarning:  2,
+	SeverityDegraded: 3,
+	SeverityCritical: 4,
+	SeverityFatal:    5,
+}
+
+// MoreSevere returns true if the severity is more severe than the other.
+// The empty or unknown severity is the least severe.
+func (s Severity) MoreSevere(other Severity) bool {
+	return severityRanks[s] > severityRanks[other]
+}
+
+// SuggestedAction is the action to recover from the component state.
+type SuggestedAction string
+
+const (
+	SuggestedActionNone               SuggestedAction = "none"
+	SuggestedActionRestartWorkload    SuggestedAction = "restart_workload"
+	SuggestedActionResetGPU           SuggestedAction = "reset_gpu"
+	SuggestedActionReboot             SuggestedAction = "reboot"
+	SuggestedActionHardwareInspection SuggestedAction = "hardware_inspection"
+)
+
+// ordered from the least to the most disruptive
+var suggestedActionOrder = []SuggestedAction{
+	SuggestedActionNone,
+	SuggestedActionRestartWorkload,
+	SuggestedActionResetGPU,
+	SuggestedActionReboot,
+	SuggestedActionHardwareInspection,
+}
+
+// MergeSuggestedActions returns the deduplicated suggested actions
+// ordered from the least to the most disruptive.
+// "none" is dropped if any other action is suggested.
+func MergeSuggestedActions(actions ...[]SuggestedAction) []SuggestedAction {
+	found := make(map[SuggestedAction]bool)
+	for _, as := range actions {
+		for _, a := range as {
+			found[a] = true
+		}
+	}
+	if len(found) > 1 {
+		delete(found, SuggestedActionNone)
+	}
+
+	merged := make([]SuggestedAction, 0, len(found))
+	for _, a := range suggestedActionOrder {
+		if found[a] {
+			merged = append(merged, a)
+			delete(found, a)
+		}
+	}
+	// unknown actions are appended last in sorted order
+	unknown := make([]SuggestedAction, 0, len(found))
+	for a := range found {
+		unknown = append(unknown, a)
+	}
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i] < unknown[j] })
+	merged = append(merged, unknown...)
+
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
 }
 
 type Event struct {
@@ -130,8 +261,31 @@ func GetComponent(name string) (Component, error) {
 	return defaultSet[name], nil
 }
 
+// DeregisterComponent removes the component from the default set.
+// The caller is responsible for closing the component.
+func DeregisterComponent(name string) error {
+	defaultSetMu.Lock()
+	defer defaultSetMu.Unlock()
+
+	if defaultSet == nil {
+		return fmt.Errorf("component set not initialized: %w", errdefs.ErrUnavailable)
+	}
+	if _, ok := defaultSet[name]; !ok {
+		return fmt.Errorf("component %s not found: %w", name, errdefs.ErrNotFound)
+	}
+	delete(defaultSet, name)
+	return nil
+}
+
+// GetAllComponents returns a copy of the default set,
+// so that the components can be added or removed while iterating.
 func GetAllComponents() map[string]Component {
 	defaultSetMu.RLock()
 	defer defaultSetMu.RUnlock()
-	return defaultSet
+
+	copied := make(map[string]Component, len(defaultSet))
+	for k, v := range defaultSet {
+		copied[k] = v
+	}
+	return copied
 }
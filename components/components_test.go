@@ -0,0 +1,52 @@
+package components
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSeverityMoreSevere(t *testing.T) {
+	if !SeverityFatal.MoreSevere(SeverityCritical) {
+		t.Fatal("expected fatal to be more severe than critical")
+	}
+	if SeverityInfo.MoreSevere(SeverityWarning) {
+		t.Fatal("expected info to be less severe than warning")
+	}
+	if !SeverityInfo.MoreSevere("") {
+		t.Fatal("expected info to be more severe than empty")
+	}
+}
+
+func TestMergeSuggestedActions(t *testing.T) {
+	tests := []struct {
+		name    string
+		actions [][]SuggestedAction
+		want    []SuggestedAction
+	}{
+		{
+			name: "empty",
+			want: nil,
+		},
+		{
+			name:    "none only",
+			actions: [][]SuggestedAction{{SuggestedActionNone}, {SuggestedActionNone}},
+			want:    []SuggestedAction{SuggestedActionNone},
+		},
+		{
+			name: "dedup and order, drop none",
+			actions: [][]SuggestedAction{
+				{SuggestedActionHardwareInspection, SuggestedActionReboot},
+				{SuggestedActionNone},
+				{SuggestedActionResetGPU, SuggestedActionReboot},
+			},
+			want: []SuggestedAction{SuggestedActionResetGPU, SuggestedActionReboot, SuggestedActionHardwareInspection},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MergeSuggestedActions(tt.actions...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergeSuggestedActions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -97,11 +97,17 @@ var (
 	defaultPoller     query.Poller
 )
 
-// only set once since it relies on the kube client and specific port
+// only created once, and the get func is replaced with the reloaded config
+// since it relies on the config
 func setDefaultPoller(cfg Config) {
+	created := false
 	defaultPollerOnce.Do(func() {
-		defaultPoller = query.New(Name, cfg.Query, CreateGet(cfg))
+		defaultPoller = query.New(Name, cfg.Query, CreateGet(cfg), query.WithDecodeFunc(query.DecodeJSON[Output]))
+		created = true
 	})
+	if !created {
+		defaultPoller.SetGetFunc(CreateGet(cfg))
+	}
 }
 
 func getDefaultPoller() query.Poller {
@@ -0,0 +1,61 @@
+package pod
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	query_config "github.com/leptonai/gpud/components/query/config"
+	"github.com/leptonai/gpud/log"
+)
+
+func init() {
+	components.RegisterFactory(components.Factory{
+		Name: Name,
+		DefaultConfig: func() any {
+			return Config{
+				Query:    query_config.DefaultConfig(),
+				Endpoint: DefaultContainerRuntimeEndpoint,
+			}
+		},
+		AutoDetect: autoDetect,
+		ParseConfig: func(raw any, db *sql.DB) (any, error) {
+			if raw == nil {
+				return &Config{Query: query_config.Config{State: &query_config.State{DB: db}}}, nil
+			}
+			return ParseConfig(raw, db)
+		},
+		New: func(ctx context.Context, cfg any, _ components.FactoryOptions) (components.Component, error) {
+			return New(ctx, *cfg.(*Config)), nil
+		},
+	})
+}
+
+// autoDetect returns true if containerd is installed and running.
+func autoDetect(ctx context.Context) bool {
+	if runtime.GOOS != "linux" {
+		log.Logger.Debugw("ignoring default containerd pod checking since it's not linux", "os", runtime.GOOS)
+		return false
+	}
+
+	if _, err := os.Stat(DefaultSocketFile); err != nil {
+		log.Logger.Debugw("containerd default socket file does not exist, skip containerd check", "file", DefaultSocketFile, "error", err)
+		return false
+	}
+	log.Logger.Debugw("containerd default socket file exists, containerd installed", "file", DefaultSocketFile)
+
+	cctx, ccancel := context.WithTimeout(ctx, 5*time.Second)
+	defer ccancel()
+	_, _, conn, err := Connect(cctx, DefaultContainerRuntimeEndpoint)
+	if err != nil {
+		log.Logger.Debugw("containerd default cri endpoint not open, skip containerd checking", "endpoint", DefaultContainerRuntimeEndpoint, "error", err)
+		return false
+	}
+	_ = conn.Close()
+	log.Logger.Debugw("containerd default cri endpoint open, containerd running", "endpoint", DefaultContainerRuntimeEndpoint)
+
+	return true
+}
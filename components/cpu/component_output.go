@@ -216,7 +216,7 @@ var (
 // only set once since it relies on the kube client and specific port
 func setDefaultPoller(cfg Config) {
 	defaultPollerOnce.Do(func() {
-		defaultPoller = query.New(Name, cfg.Query, Get)
+		defaultPoller = query.New(Name, cfg.Query, Get, query.WithDecodeFunc(query.DecodeJSON[Output]))
 	})
 }
 
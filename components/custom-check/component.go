@@ -0,0 +1,135 @@
+// Package customcheck runs the user-defined commands and reports
+// their results as component states (e.g., site-specific checks).
+package customcheck
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/log"
+)
+
+const Name = "custom-check"
+
+func New(ctx context.Context, cfg Config) components.Component {
+	cfg.SetDefaultsIfNotSet()
+
+	cctx, ccancel := context.WithCancel(ctx)
+
+	// each check has its own poller, since the checks are
+	// specific to this configuration (not shared with other components)
+	checks := make([]checkPoller, 0, len(cfg.Checks))
+	for _, check := range cfg.Checks {
+		pl := query.New(Name+"-"+check.Name, cfg.Query, createGet(check), query.WithDecodeFunc(query.DecodeJSON[Output]))
+		pl.Start(cctx, cfg.Query, Name)
+		checks = append(checks, checkPoller{name: check.Name, poller: pl, events: &checkEvents{}})
+	}
+
+	var db *sql.DB
+	if cfg.Query.State != nil {
+		db = cfg.Query.State.DB
+	}
+	return &component{
+		rootCtx: ctx,
+		cancel:  ccancel,
+		db:      db,
+		checks:  checks,
+	}
+}
+
+var _ components.Component = (*component)(nil)
+
+type checkPoller struct {
+	name   string
+	poller query.Poller
+	events *checkEvents
+}
+
+type component struct {
+	rootCtx context.Context
+	cancel  context.CancelFunc
+	// to read the last persisted events of the checks
+	db     *sql.DB
+	checks []checkPoller
+}
+
+func (c *component) Name() string { return Name }
+
+func (c *component) States(ctx context.Context) ([]components.State, error) {
+	states := make([]components.State, 0, len(c.checks))
+	for _, check := range c.checks {
+		last, err := check.poller.Last()
+		if err != nil {
+			return nil, err
+		}
+		if last == nil { // no data
+			log.Logger.Debugw("nothing found in last state (no data collected yet)", "component", Name, "check", check.name)
+			continue
+		}
+		if last.Error != nil {
+			states = append(states, components.State{
+				Name:    check.name,
+				Healthy: false,
+				Error:   last.Error.Error(),
+				Reason:  "last query failed",
+			})
+			continue
+		}
+
+		output, ok := last.Output.(*Output)
+		if !ok || output == nil {
+			states = append(states, components.State{
+				Name:    check.name,
+				Healthy: false,
+				Reason:  "no output",
+			})
+			continue
+		}
+		states = append(states, output.State())
+	}
+	return states, nil
+}
+
+func (c *component) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
+	events := make([]components.Event, 0)
+	for _, check := range c.checks {
+		// read all to compare with the result right before "since"
+		items, err := check.poller.All(time.Time{})
+		if err != nil {
+			return nil, err
+		}
+
+		check.events.mu.Lock()
+		if !check.events.seeded {
+			check.events.seed(ctx, c.db, check.name)
+		}
+		events = append(events, check.events.update(check.name, items, since)...)
+		check.events.mu.Unlock()
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Time.Time.Before(events[j].Time.Time)
+	})
+	return events, nil
+}
+
+func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
+	log.Logger.Debugw("querying metrics", "since", since)
+
+	return nil, nil
+}
+
+func (c *component) Close() error {
+	log.Logger.Debugw("closing component")
+
+	// safe to call stop multiple times
+	for _, check := range c.checks {
+		check.poller.Stop(Name)
+	}
+	c.cancel()
+
+	return nil
+}
@@ -0,0 +1,271 @@
+package customcheck
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	components_events_state "github.com/leptonai/gpud/components/events/state"
+	components_metrics "github.com/leptonai/gpud/components/metrics"
+	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/log"
+)
+
+// Result is the optional JSON object that the check command writes to stdout.
+type Result struct {
+	Reason    string            `json:"reason,omitempty"`
+	ExtraInfo map[string]string `json:"extra_info,omitempty"`
+}
+
+// Output is the result of a single check run.
+type Output struct {
+	Check string `json:"check"`
+
+	ExitCode int    `json:"exit_code"`
+	TimedOut bool   `json:"timed_out"`
+	Stdout   string `json:"stdout,omitempty"`
+	// Set if the stdout is a valid JSON "Result".
+	Result *Result `json:"result,omitempty"`
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+const (
+	StateKeyExitCode = "exit_code"
+	StateKeyTimedOut = "timed_out"
+
+	// maximum stdout size to keep in the output
+	maxStdoutBytes = 4096
+)
+
+// Returns the output evaluation reason and its healthy-ness.
+func (o *Output) Evaluate() (string, bool) {
+	healthy := o.ExitCode == 0 && !o.TimedOut
+
+	if o.Result != nil && o.Result.Reason != "" {
+		return o.Result.Reason, healthy
+	}
+	if o.TimedOut {
+		return fmt.Sprintf("check %q timed out", o.Check), healthy
+	}
+	return fmt.Sprintf("check %q exited with code %d", o.Check, o.ExitCode), healthy
+}
+
+func (o *Output) State() components.State {
+	reason, healthy := o.Evaluate()
+
+	extraInfo := map[string]string{
+		StateKeyExitCode: strconv.Itoa(o.ExitCode),
+		StateKeyTimedOut: strconv.FormatBool(o.TimedOut),
+	}
+	if o.Result != nil {
+		for k, v := range o.Result.ExtraInfo {
+			extraInfo[k] = v
+		}
+	}
+
+	return components.State{
+		Name:      o.Check,
+		Healthy:   healthy,
+		Reason:    reason,
+		ExtraInfo: extraInfo,
+	}
+}
+
+// createGet returns the poller get function to run the check.
+func createGet(check Check) func(ctx context.Context) (any, error) {
+	return func(ctx context.Context) (_ any, e error) {
+		defer func() {
+			if e != nil {
+				components_metrics.SetGetFailed(Name)
+			} else {
+				components_metrics.SetGetSuccess(Name)
+			}
+		}()
+		return run(ctx, check)
+	}
+}
+
+// run executes the check command.
+// A non-zero exit code is a valid (unhealthy) output,
+// and only returns an error if the command cannot be executed.
+func run(ctx context.Context, check Check) (*Output, error) {
+	cctx, ccancel := context.WithTimeout(ctx, check.Timeout.Duration)
+	defer ccancel()
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(cctx, check.Command[0], check.Command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+
+	o := &Output{
+		Check:    check.Name,
+		TimedOut: errors.Is(cctx.Err(), context.DeadlineExceeded),
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		o.ExitCode = exitErr.ExitCode()
+	case o.TimedOut:
+		o.ExitCode = -1
+	default:
+		return nil, fmt.Errorf("failed to run check %q: %w", check.Name, err)
+	}
+
+	out := strings.TrimSpace(stdout.String())
+	if len(out) > maxStdoutBytes {
+		out = out[:maxStdoutBytes]
+	}
+	o.Stdout = out
+
+	if strings.HasPrefix(out, "{") {
+		r := new(Result)
+		if err := json.Unmarshal([]byte(out), r); err == nil {
+			o.Result = r
+		}
+	}
+
+	return o, nil
+}
+
+// eventsFromItems returns the events for the check results that changed
+// from the previous result (healthy-ness or reason), and the last result.
+// If the previous result is nil, the first result generates an event only if it is unhealthy.
+// The items must be sorted by time in ascending order.
+func eventsFromItems(check string, prev *components.State, items []query.Item) ([]components.Event, *components.State) {
+	var events []components.Event
+	for _, item := range items {
+		if item.Error != nil || item.Output == nil {
+			continue
+		}
+		o, ok := item.Output.(*Output)
+		if !ok {
+			continue
+		}
+
+		cur := o.State()
+		changed := prev == nil && !cur.Healthy
+		if prev != nil {
+			changed = prev.Healthy != cur.Healthy || prev.Reason != cur.Reason
+		}
+		prev = &cur
+
+		if !changed {
+			continue
+		}
+
+		ev := components.Event{
+			Time:      item.Time,
+			Name:      check,
+			Type:      components.EventTypeInfo,
+			Message:   fmt.Sprintf("check %q is healthy: %s", check, cur.Reason),
+			ExtraInfo: cur.ExtraInfo,
+		}
+		if !cur.Healthy {
+			ev.Type = components.EventTypeWarn
+			ev.Message = fmt.Sprintf("check %q is unhealthy: %s", check, cur.Reason)
+		}
+		events = append(events, ev)
+	}
+	return events, prev
+}
+
+// stateFromEvent returns the check result that generated the event,
+// or nil if the event is not generated by "eventsFromItems".
+func stateFromEvent(check string, ev components.Event) *components.State {
+	if reason, ok := strings.CutPrefix(ev.Message, fmt.Sprintf("check %q is healthy: ", check)); ok {
+		return &components.State{Name: check, Healthy: true, Reason: reason}
+	}
+	if reason, ok := strings.CutPrefix(ev.Message, fmt.Sprintf("check %q is unhealthy: ", check)); ok {
+		return &components.State{Name: check, Healthy: false, Reason: reason}
+	}
+	return nil
+}
+
+// checkEvents tracks the results of a check across the poller window rotations,
+// so that only the transitions generate the events, not the first result in each window
+// (e.g., a long-lasting unhealthy result).
+type checkEvents struct {
+	mu sync.Mutex
+
+	seeded bool
+	// the last result and the time of the last processed item
+	prev     *components.State
+	lastTime time.Time
+
+	// the generated events within the poller window
+	events []components.Event
+}
+
+// seed sets the previous result from the last persisted event of the check,
+// so that the same transition is not generated again after the restart.
+func (ce *checkEvents) seed(ctx context.Context, db *sql.DB, check string) {
+	ce.seeded = true
+	if db == nil {
+		return
+	}
+	evs, err := components_events_state.Read(ctx, db, components_events_state.DefaultTableName, Name, time.Time{}, components_events_state.WithEventName(check))
+	if err != nil {
+		log.Logger.Debugw("failed to read the last event", "component", Name, "check", check, "error", err)
+		return
+	}
+	if len(evs) == 0 {
+		return
+	}
+	last := evs[len(evs)-1]
+	if st := stateFromEvent(check, last); st != nil {
+		ce.prev = st
+		ce.lastTime = last.Time.Time
+	}
+}
+
+// update generates the events from the items newer than the last processed one,
+// and returns the events since the given time.
+// The items must be sorted by time in ascending order.
+func (ce *checkEvents) update(check string, items []query.Item, since time.Time) []components.Event {
+	var newItems []query.Item
+	for _, item := range items {
+		if item.Time.Time.After(ce.lastTime) {
+			newItems = append(newItems, item)
+		}
+	}
+	evs, prev := eventsFromItems(check, ce.prev, newItems)
+	ce.events = append(ce.events, evs...)
+	ce.prev = prev
+	if len(newItems) > 0 {
+		ce.lastTime = newItems[len(newItems)-1].Time.Time
+	}
+
+	// the older events are already returned (and persisted) while in the window
+	if len(items) > 0 {
+		oldest := items[0].Time.Time
+		i := 0
+		for i < len(ce.events) && ce.events[i].Time.Time.Before(oldest) {
+			i++
+		}
+		ce.events = ce.events[i:]
+	}
+
+	var events []components.Event
+	for _, ev := range ce.events {
+		if since.IsZero() || !ev.Time.Time.Before(since) {
+			events = append(events, ev)
+		}
+	}
+	return events
+}
@@ -0,0 +1,250 @@
+package customcheck
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	components_events_state "github.com/leptonai/gpud/components/events/state"
+	"github.com/leptonai/gpud/components/query"
+	query_config "github.com/leptonai/gpud/components/query/config"
+	"github.com/leptonai/gpud/components/state"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	timeout := metav1.Duration{Duration: 5 * time.Second}
+
+	tests := []struct {
+		name        string
+		check       Check
+		wantHealthy bool
+		wantReason  string
+		wantExtra   map[string]string
+	}{
+		{
+			name:        "exit 0",
+			check:       Check{Name: "ok", Command: []string{"true"}, Timeout: timeout},
+			wantHealthy: true,
+			wantReason:  `check "ok" exited with code 0`,
+		},
+		{
+			name:        "exit 1",
+			check:       Check{Name: "fail", Command: []string{"sh", "-c", "exit 3"}, Timeout: timeout},
+			wantHealthy: false,
+			wantReason:  `check "fail" exited with code 3`,
+		},
+		{
+			name: "json stdout",
+			check: Check{
+				Name:    "nfs",
+				Command: []string{"sh", "-c", `echo '{"reason":"nfs not mounted","extra_info":{"mount":"/mnt/nfs"}}'; exit 1`},
+				Timeout: timeout,
+			},
+			wantHealthy: false,
+			wantReason:  "nfs not mounted",
+			wantExtra:   map[string]string{"mount": "/mnt/nfs"},
+		},
+		{
+			name:        "timeout",
+			check:       Check{Name: "slow", Command: []string{"sleep", "10"}, Timeout: metav1.Duration{Duration: 100 * time.Millisecond}},
+			wantHealthy: false,
+			wantReason:  `check "slow" timed out`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := run(ctx, tt.check)
+			if err != nil {
+				t.Fatal(err)
+			}
+			st := o.State()
+			if st.Healthy != tt.wantHealthy {
+				t.Errorf("healthy = %v, want %v", st.Healthy, tt.wantHealthy)
+			}
+			if st.Reason != tt.wantReason {
+				t.Errorf("reason = %q, want %q", st.Reason, tt.wantReason)
+			}
+			for k, v := range tt.wantExtra {
+				if st.ExtraInfo[k] != v {
+					t.Errorf("extra info %q = %q, want %q", k, st.ExtraInfo[k], v)
+				}
+			}
+		})
+	}
+
+	if _, err := run(ctx, Check{Name: "missing", Command: []string{"/nonexistent/command"}, Timeout: timeout}); err == nil {
+		t.Fatal("expected error for the missing command")
+	}
+}
+
+func TestEventsFromItems(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	item := func(d time.Duration, exitCode int) query.Item {
+		return query.Item{
+			Time:   metav1.Time{Time: now.Add(d)},
+			Output: &Output{Check: "c", ExitCode: exitCode},
+		}
+	}
+	items := []query.Item{
+		item(-5*time.Minute, 0),
+		item(-4*time.Minute, 1),
+		item(-3*time.Minute, 1),
+		item(-2*time.Minute, 0),
+		item(-time.Minute, 0),
+	}
+
+	events, prev := eventsFromItems("c", nil, items)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d: %+v", len(events), events)
+	}
+	if events[0].Type != components.EventTypeWarn || events[1].Type != components.EventTypeInfo {
+		t.Fatalf("unexpected event types: %+v", events)
+	}
+	if prev == nil || !prev.Healthy {
+		t.Fatalf("expected the last result healthy, got %+v", prev)
+	}
+
+	events, _ = eventsFromItems("c", nil, []query.Item{item(0, 2)})
+	if len(events) != 1 || events[0].Type != components.EventTypeWarn {
+		t.Fatalf("expected the first unhealthy result to generate an event: %+v", events)
+	}
+	last := (&Output{Check: "c", ExitCode: 2}).State()
+	events, _ = eventsFromItems("c", &last, []query.Item{item(0, 2)})
+	if len(events) != 0 {
+		t.Fatalf("expected no event for the same result as the previous one: %+v", events)
+	}
+
+	ce := &checkEvents{}
+	events = ce.update("c", items, now.Add(-150*time.Second))
+	if len(events) != 1 || events[0].Type != components.EventTypeInfo {
+		t.Fatalf("unexpected events since: %+v", events)
+	}
+}
+
+func TestCheckEventsSlidingWindow(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	item := func(i int, exitCode int) query.Item {
+		return query.Item{
+			Time:   metav1.Time{Time: now.Add(time.Duration(i) * time.Minute)},
+			Output: &Output{Check: "c", ExitCode: exitCode},
+		}
+	}
+	window := func(from int, to int, exitCode int) []query.Item {
+		var items []query.Item
+		for i := from; i < to; i++ {
+			items = append(items, item(i, exitCode))
+		}
+		return items
+	}
+
+	// the constant unhealthy result only generates an event at the transition,
+	// not again after the transition rotates out of the window
+	ce := &checkEvents{}
+	events := ce.update("c", window(0, 5, 1), time.Time{})
+	if len(events) != 1 || !events[0].Time.Time.Equal(now) {
+		t.Fatalf("expected the transition event, got %+v", events)
+	}
+	for from := 1; from < 10; from++ {
+		if events := ce.update("c", window(from, from+5, 1), time.Time{}); len(events) != 0 {
+			t.Fatalf("window %d: expected no duplicate event, got %+v", from, events)
+		}
+	}
+
+	events = ce.update("c", append(window(10, 14, 1), item(14, 0)), time.Time{})
+	if len(events) != 1 || events[0].Type != components.EventTypeInfo {
+		t.Fatalf("expected the recovery event, got %+v", events)
+	}
+}
+
+func TestCheckEventsSeed(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db, err := state.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := components_events_state.CreateTable(ctx, db, components_events_state.DefaultTableName); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	item := func(d time.Duration, exitCode int) query.Item {
+		return query.Item{
+			Time:   metav1.Time{Time: now.Add(d)},
+			Output: &Output{Check: "c", ExitCode: exitCode},
+		}
+	}
+
+	// generated before the restart
+	events, _ := eventsFromItems("c", nil, []query.Item{item(-10*time.Minute, 1)})
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %+v", events)
+	}
+	if err := components_events_state.Insert(ctx, db, components_events_state.DefaultTableName, Name, events[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	// the window after the restart starts with the same unhealthy result
+	ce := &checkEvents{}
+	ce.seed(ctx, db, "c")
+	if events := ce.update("c", []query.Item{item(-2*time.Minute, 1), item(-time.Minute, 1)}, time.Time{}); len(events) != 0 {
+		t.Fatalf("expected no duplicate event after the restart, got %+v", events)
+	}
+	if events := ce.update("c", []query.Item{item(-time.Minute, 1), item(0, 0)}, time.Time{}); len(events) != 1 || events[0].Type != components.EventTypeInfo {
+		t.Fatalf("expected the recovery event, got %+v", events)
+	}
+}
+
+func TestComponent(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	component := New(
+		ctx,
+		Config{
+			Query: query_config.Config{
+				Interval: metav1.Duration{Duration: 5 * time.Second},
+			},
+			Checks: []Check{
+				{Name: "ok", Command: []string{"true"}},
+				{Name: "fail", Command: []string{"false"}},
+			},
+		},
+	)
+	defer component.Close()
+
+	time.Sleep(time.Second)
+
+	states, err := component.States(ctx)
+	if err != nil {
+		t.Fatalf("failed to get state: %v", err)
+	}
+	if len(states) != 2 {
+		t.Fatalf("expected 2 states, got %+v", states)
+	}
+	if !states[0].Healthy || states[1].Healthy {
+		t.Fatalf("unexpected states: %+v", states)
+	}
+
+	events, err := component.Events(ctx, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Name != "fail" {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+}
@@ -0,0 +1,88 @@
+package customcheck
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	query_config "github.com/leptonai/gpud/components/query/config"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const DefaultTimeout = 30 * time.Second
+
+type Config struct {
+	Query query_config.Config `json:"query"`
+
+	// Checks to run for each poll interval.
+	// Each check is reported as a separate state named after the check.
+	Checks []Check `json:"checks"`
+}
+
+// Check defines a command to run.
+// The exit code 0 is evaluated as healthy, and any other exit code as unhealthy.
+// If the command writes a JSON object to stdout (see "Result"),
+// its reason and extra info are reported in the state.
+type Check struct {
+	// Name is the unique name of the check.
+	Name string `json:"name"`
+	// Command is the command and its arguments to execute (not run in a shell).
+	// Use "bash -c" to run a shell script.
+	Command []string `json:"command"`
+	// Timeout is the maximum duration to run the command.
+	// The check is evaluated as unhealthy if the command times out.
+	Timeout metav1.Duration `json:"timeout"`
+}
+
+func ParseConfig(b any, db *sql.DB) (*Config, error) {
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	err = json.Unmarshal(raw, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Query.State != nil {
+		cfg.Query.State.DB = db
+	}
+	return cfg, nil
+}
+
+func (cfg Config) Validate() error {
+	if len(cfg.Checks) == 0 {
+		return errors.New("checks is required")
+	}
+	names := make(map[string]struct{}, len(cfg.Checks))
+	for _, c := range cfg.Checks {
+		if c.Name == "" {
+			return errors.New("check name is required")
+		}
+		if _, ok := names[c.Name]; ok {
+			return fmt.Errorf("duplicate check name %q", c.Name)
+		}
+		names[c.Name] = struct{}{}
+
+		if len(c.Command) == 0 {
+			return fmt.Errorf("check %q command is required", c.Name)
+		}
+		if c.Timeout.Duration < 0 {
+			return fmt.Errorf("check %q timeout must be positive", c.Name)
+		}
+	}
+	return nil
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	cfg.Query.SetDefaultsIfNotSet()
+
+	for i := range cfg.Checks {
+		if cfg.Checks[i].Timeout.Duration == 0 {
+			cfg.Checks[i].Timeout = metav1.Duration{Duration: DefaultTimeout}
+		}
+	}
+}
@@ -21,6 +21,7 @@ import (
 
 type Op struct {
 	disableArchive bool
+	outputDir      string
 }
 
 type OpOption func(*Op)
@@ -38,6 +39,14 @@ func WithDisableArchive(b bool) OpOption {
 	}
 }
 
+// WithOutputDir sets the directory to write the diagnose directory, archive, and summary.
+// If not set, writes to the current working directory.
+func WithOutputDir(dir string) OpOption {
+	return func(op *Op) {
+		op.outputDir = dir
+	}
+}
+
 type output struct {
 	dir        string `json:"-"`
 	rawDataDir string `json:"-"`
@@ -69,7 +78,11 @@ func (o *output) SyncYAML(file string) error {
 }
 
 func Run(ctx context.Context, opts ...OpOption) error {
-	return run(ctx, getDir(), opts...)
+	op := &Op{}
+	if err := op.applyOpts(opts); err != nil {
+		return err
+	}
+	return run(ctx, filepath.Join(op.outputDir, getDir()), opts...)
 }
 
 func getDir() string {
@@ -389,7 +402,7 @@ func run(ctx context.Context, dir string, opts ...OpOption) error {
 	if err := o.SyncYAML(summaryFile); err != nil {
 		return err
 	}
-	if err := copyFile(summaryFile, "summary.txt"); err != nil {
+	if err := copyFile(summaryFile, filepath.Join(op.outputDir, "summary.txt")); err != nil {
 		return err
 	}
 
@@ -0,0 +1,30 @@
+package disk
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/leptonai/gpud/components"
+	query_config "github.com/leptonai/gpud/components/query/config"
+)
+
+func init() {
+	components.RegisterFactory(components.Factory{
+		Name: Name,
+		DefaultConfig: func() any {
+			return DefaultConfig()
+		},
+		AutoDetect: func(ctx context.Context) bool {
+			return true
+		},
+		ParseConfig: func(raw any, db *sql.DB) (any, error) {
+			if raw == nil {
+				return &Config{Query: query_config.Config{State: &query_config.State{DB: db}}}, nil
+			}
+			return ParseConfig(raw, db)
+		},
+		New: func(ctx context.Context, cfg any, _ components.FactoryOptions) (components.Component, error) {
+			return New(ctx, *cfg.(*Config)), nil
+		},
+	})
+}
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	nvidia_query_sxid "github.com/leptonai/gpud/components/accelerator/nvidia/query/sxid"
+	nvidia_query_xid "github.com/leptonai/gpud/components/accelerator/nvidia/query/xid"
 	query_log "github.com/leptonai/gpud/components/query/log"
 	query_log_filter "github.com/leptonai/gpud/components/query/log/filter"
 
@@ -123,9 +125,10 @@ func ParseStates(states ...components.State) (*State, error) {
 func (s *State) States() []components.State {
 	cs := make([]components.State, 0)
 	cs = append(cs, components.State{
-		Name:    StateNameDmesg,
-		Healthy: true,
-		Reason:  fmt.Sprintf("scanning file: %s", s.File),
+		Name:     StateNameDmesg,
+		Healthy:  true,
+		Severity: components.SeverityInfo,
+		Reason:   fmt.Sprintf("scanning file: %s", s.File),
 		ExtraInfo: map[string]string{
 			StateKeyDmesgFile:           s.File,
 			StateKeyDmesgLastSeekOffset: fmt.Sprintf("%d", s.LastSeekInfo.Offset),
@@ -140,6 +143,7 @@ func (s *State) States() []components.State {
 			if item.Error != nil {
 				es = item.Error.Error()
 			}
+			severity, actions := evaluateSeverity(item)
 			cs = append(cs, components.State{
 				Name:    StateNameDmesgTailScanMatched,
 				Healthy: item.Error == nil,
@@ -150,14 +154,47 @@ func (s *State) States() []components.State {
 					StateKeyDmesgTailScanMatchedFilter:      string(b),
 					StateKeyDmesgTailScanMatchedError:       es,
 				},
+				Severity:         severity,
+				SuggestedActions: actions,
 			})
 		}
 	} else {
 		cs = append(cs, components.State{
-			Name:    StateNameDmesgTailScanMatched,
-			Healthy: true,
-			Reason:  "no matched line",
+			Name:     StateNameDmesgTailScanMatched,
+			Healthy:  true,
+			Reason:   "no matched line",
+			Severity: components.SeverityInfo,
 		})
 	}
 	return cs
 }
+
+// evaluateSeverity returns the severity level and the suggested actions
+// of the matched line based on the filter that matched it.
+func evaluateSeverity(item query_log.Item) (components.Severity, []components.SuggestedAction) {
+	if item.Error != nil || item.Matched == nil {
+		return components.SeverityWarning, nil
+	}
+
+	switch item.Matched.Name {
+	case EventOOMKill, EventOOMKiller, EventOOMCgroup:
+		return components.SeverityWarning, []components.SuggestedAction{components.SuggestedActionRestartWorkload}
+
+	case EventNvidiaNVRMXid:
+		de, err := nvidia_query_xid.ParseDmesgLogLine(item.Line)
+		if err != nil || de.Detail == nil {
+			return components.SeverityWarning, nil
+		}
+		return de.Detail.Severity(), de.Detail.SuggestedActions()
+
+	case EventNvidiaNVSwitchSXid:
+		de, err := nvidia_query_sxid.ParseDmesgLogLine(item.Line)
+		if err != nil || de.Detail == nil {
+			return components.SeverityWarning, nil
+		}
+		return de.Detail.Severity(), de.Detail.SuggestedActions()
+
+	default:
+		return components.SeverityInfo, nil
+	}
+}
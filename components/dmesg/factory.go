@@ -0,0 +1,57 @@
+package dmesg
+
+import (
+	"context"
+	"database/sql"
+	"runtime"
+
+	"github.com/leptonai/gpud/components"
+)
+
+func init() {
+	components.RegisterFactory(components.Factory{
+		Name: Name,
+		DefaultConfig: func() any {
+			return DefaultConfig()
+		},
+		AutoDetect: func(ctx context.Context) bool {
+			return runtime.GOOS == "linux" && DmesgExists()
+		},
+		ParseConfig: func(raw any, db *sql.DB) (any, error) {
+			return parseConfigOrDefault(raw, db)
+		},
+		New: func(ctx context.Context, cfg any, _ components.FactoryOptions) (components.Component, error) {
+			return New(ctx, *cfg.(*Config))
+		},
+	})
+}
+
+// parseConfigOrDefault parses the raw config value,
+// or returns the default config if the value is nil.
+func parseConfigOrDefault(raw any, db *sql.DB) (*Config, error) {
+	if raw != nil {
+		return ParseConfig(raw, db)
+	}
+
+	cfg := DefaultConfig()
+	if cfg.Log.Query.State != nil {
+		cfg.Log.Query.State.DB = db
+	}
+	cfg.Log.DB = db
+	return &cfg, nil
+}
+
+// HasSelectFilter returns true if the raw dmesg config value selects the filter of the name.
+// Used for the components that parse the dmesg events (e.g., nvidia xid).
+func HasSelectFilter(raw any, filterName string) (bool, error) {
+	cfg, err := parseConfigOrDefault(raw, nil)
+	if err != nil {
+		return false, err
+	}
+	for _, f := range cfg.Log.SelectFilters {
+		if f.Name == filterName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,427 @@
+// Package state persists the component events in the state database,
+// so that the events survive the gpud restarts.
+package state
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+
+	_ "github.com/mattn/go-sqlite3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const DefaultTableName = "components_events"
+
+const (
+	// ColumnID is monotonically increasing with the insertion order,
+	// and never reused even after the old rows are purged.
+	ColumnID = "id"
+
+	ColumnComponent   = "component"
+	ColumnUnixSeconds = "unix_seconds"
+	ColumnName        = "name"
+	ColumnType        = "type"
+	ColumnMessage     = "message"
+	ColumnExtraInfo   = "extra_info"
+
+	// ColumnSeq is the occurrence of the same event (component, time, name, type, and message)
+	// within the same second, so that the repeated events are not merged.
+	ColumnSeq = "seq"
+)
+
+func CreateTable(ctx context.Context, db *sql.DB, tableName string) error {
+	if err := migrateTable(ctx, db, tableName); err != nil {
+		return fmt.Errorf("failed to migrate table %s: %w", tableName, err)
+	}
+	if _, err := db.ExecContext(ctx, createTableQuery(tableName)); err != nil {
+		return err
+	}
+
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE INDEX IF NOT EXISTS idx_%s_%s_%s ON %s(%s, %s);`,
+		tableName, ColumnComponent, ColumnUnixSeconds,
+		tableName, ColumnComponent, ColumnUnixSeconds,
+	))
+	return err
+}
+
+func createTableQuery(tableName string) string {
+	// the unique constraint deduplicates the same event
+	// returned by the multiple "Events" calls
+	return fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER PRIMARY KEY AUTOINCREMENT,
+	%s TEXT NOT NULL,
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT,
+	%s INTEGER NOT NULL DEFAULT 0,
+	UNIQUE (%s, %s, %s, %s, %s, %s)
+);`,
+		tableName,
+		ColumnID, ColumnComponent, ColumnUnixSeconds, ColumnName, ColumnType, ColumnMessage, ColumnExtraInfo, ColumnSeq, // columns
+		ColumnComponent, ColumnUnixSeconds, ColumnName, ColumnType, ColumnMessage, ColumnSeq, // unique keys
+	)
+}
+
+// migrateTable recreates the table created by the older versions
+// (e.g., without the id or seq column), copying the existing events.
+// The unique constraint cannot be altered in place.
+func migrateTable(ctx context.Context, db *sql.DB, tableName string) error {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s);", tableName))
+	if err != nil {
+		return err
+	}
+	columns := make(map[string]struct{})
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			typ       string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dfltValue, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		columns[name] = struct{}{}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// not created yet
+	if len(columns) == 0 {
+		return nil
+	}
+	_, hasID := columns[ColumnID]
+	_, hasSeq := columns[ColumnSeq]
+	if hasID && hasSeq {
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	oldTableName := tableName + "_old"
+	copied := strings.Join([]string{ColumnComponent, ColumnUnixSeconds, ColumnName, ColumnType, ColumnMessage, ColumnExtraInfo}, ", ")
+	for _, query := range []string{
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", tableName, oldTableName),
+		createTableQuery(tableName),
+		fmt.Sprintf("INSERT OR IGNORE INTO %s (%s) SELECT %s FROM %s ORDER BY %s ASC;", tableName, copied, copied, oldTableName, ColumnUnixSeconds),
+		// also drops the index of the old table, to recreate on the new table
+		fmt.Sprintf("DROP TABLE %s;", oldTableName),
+	} {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Insert persists the event of the component.
+// It is a no-op if the same event already exists.
+func Insert(ctx context.Context, db *sql.DB, tableName string, componentName string, ev components.Event) error {
+	return insert(ctx, db, tableName, componentName, ev, 0)
+}
+
+func insert(ctx context.Context, db *sql.DB, tableName string, componentName string, ev components.Event, seq int) error {
+	var extraInfo []byte
+	if len(ev.ExtraInfo) > 0 {
+		var err error
+		extraInfo, err = json.Marshal(ev.ExtraInfo)
+		if err != nil {
+			return err
+		}
+	}
+
+	query := fmt.Sprintf(`
+INSERT OR IGNORE INTO %s (%s, %s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, ?, ?);
+`,
+		tableName,
+		ColumnComponent,
+		ColumnUnixSeconds,
+		ColumnName,
+		ColumnType,
+		ColumnMessage,
+		ColumnExtraInfo,
+		ColumnSeq,
+	)
+	_, err := db.ExecContext(ctx, query, componentName, ev.Time.Unix(), ev.Name, ev.Type, ev.Message, string(extraInfo), seq)
+	return err
+}
+
+type Op struct {
+	until      time.Time
+	eventType  string
+	name       string
+	components []string
+	limit      int
+}
+
+type OpOption func(*Op)
+
+func (op *Op) applyOpts(opts []OpOption) {
+	for _, opt := range opts {
+		opt(op)
+	}
+}
+
+// WithUntil only reads the events before or at the given time.
+func WithUntil(t time.Time) OpOption {
+	return func(op *Op) {
+		op.until = t
+	}
+}
+
+// WithEventType only reads the events of the given type (e.g., "warn").
+func WithEventType(eventType string) OpOption {
+	return func(op *Op) {
+		op.eventType = eventType
+	}
+}
+
+// WithEventName only reads the events of the given name.
+func WithEventName(name string) OpOption {
+	return func(op *Op) {
+		op.name = name
+	}
+}
+
+// WithComponents only reads the events of the given components.
+// Only used for "ReadAfter".
+func WithComponents(names ...string) OpOption {
+	return func(op *Op) {
+		op.components = append(op.components, names...)
+	}
+}
+
+// WithLimit limits the number of events to read.
+// Only used for "ReadAfter".
+func WithLimit(limit int) OpOption {
+	return func(op *Op) {
+		op.limit = limit
+	}
+}
+
+// Read returns the events of the component since the given time,
+// sorted by time in ascending order.
+// Returns nil if no record is found.
+func Read(ctx context.Context, db *sql.DB, tableName string, componentName string, since time.Time, opts ...OpOption) ([]components.Event, error) {
+	op := &Op{}
+	op.applyOpts(opts)
+
+	conds := []string{fmt.Sprintf("%s = ?", ColumnComponent)}
+	args := []any{componentName}
+	if !since.IsZero() {
+		conds = append(conds, fmt.Sprintf("%s >= ?", ColumnUnixSeconds))
+		args = append(args, since.Unix())
+	}
+	if !op.until.IsZero() {
+		conds = append(conds, fmt.Sprintf("%s <= ?", ColumnUnixSeconds))
+		args = append(args, op.until.Unix())
+	}
+	if op.eventType != "" {
+		conds = append(conds, fmt.Sprintf("%s = ?", ColumnType))
+		args = append(args, op.eventType)
+	}
+	if op.name != "" {
+		conds = append(conds, fmt.Sprintf("%s = ?", ColumnName))
+		args = append(args, op.name)
+	}
+
+	query := fmt.Sprintf(`
+SELECT %s, %s, %s, %s, %s
+FROM %s
+WHERE %s
+ORDER BY %s ASC;
+`,
+		ColumnUnixSeconds,
+		ColumnName,
+		ColumnType,
+		ColumnMessage,
+		ColumnExtraInfo,
+		tableName,
+		strings.Join(conds, " AND "),
+		ColumnUnixSeconds,
+	)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []components.Event
+	for rows.Next() {
+		var (
+			unixSeconds int64
+			ev          components.Event
+			extraInfo   sql.NullString
+		)
+		if err := rows.Scan(&unixSeconds, &ev.Name, &ev.Type, &ev.Message, &extraInfo); err != nil {
+			return nil, err
+		}
+		if err := setEventFields(&ev, unixSeconds, extraInfo); err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Record is the persisted event with its id.
+type Record struct {
+	ID        int64            `json:"id"`
+	Component string           `json:"component"`
+	Event     components.Event `json:"event"`
+}
+
+// ReadAfter returns the events inserted after the given id,
+// sorted by the id (insertion order) in ascending order.
+// Useful to resume reading the events from the last read.
+// Returns nil if no record is found.
+func ReadAfter(ctx context.Context, db *sql.DB, tableName string, afterID int64, opts ...OpOption) ([]Record, error) {
+	op := &Op{}
+	op.applyOpts(opts)
+
+	conds := []string{fmt.Sprintf("%s > ?", ColumnID)}
+	args := []any{afterID}
+	if len(op.components) > 0 {
+		conds = append(conds, fmt.Sprintf("%s IN (?%s)", ColumnComponent, strings.Repeat(", ?", len(op.components)-1)))
+		for _, name := range op.components {
+			args = append(args, name)
+		}
+	}
+	if op.eventType != "" {
+		conds = append(conds, fmt.Sprintf("%s = ?", ColumnType))
+		args = append(args, op.eventType)
+	}
+	if op.name != "" {
+		conds = append(conds, fmt.Sprintf("%s = ?", ColumnName))
+		args = append(args, op.name)
+	}
+
+	query := fmt.Sprintf(`
+SELECT %s, %s, %s, %s, %s, %s, %s
+FROM %s
+WHERE %s
+ORDER BY %s ASC`,
+		ColumnID,
+		ColumnComponent,
+		ColumnUnixSeconds,
+		ColumnName,
+		ColumnType,
+		ColumnMessage,
+		ColumnExtraInfo,
+		tableName,
+		strings.Join(conds, " AND "),
+		ColumnID,
+	)
+	if op.limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", op.limit)
+	}
+	query += ";"
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []Record
+	for rows.Next() {
+		var (
+			r           Record
+			unixSeconds int64
+			extraInfo   sql.NullString
+		)
+		if err := rows.Scan(&r.ID, &r.Component, &unixSeconds, &r.Event.Name, &r.Event.Type, &r.Event.Message, &extraInfo); err != nil {
+			return nil, err
+		}
+		if err := setEventFields(&r.Event, unixSeconds, extraInfo); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// LastID returns the id of the last inserted event.
+// Returns 0 if no record is found.
+func LastID(ctx context.Context, db *sql.DB, tableName string) (int64, error) {
+	query := fmt.Sprintf(`SELECT COALESCE(MAX(%s), 0) FROM %s;`, ColumnID, tableName)
+	var id int64
+	if err := db.QueryRowContext(ctx, query).Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func setEventFields(ev *components.Event, unixSeconds int64, extraInfo sql.NullString) error {
+	ev.Time = metav1.Time{Time: time.Unix(unixSeconds, 0).UTC()}
+	if extraInfo.Valid && extraInfo.String != "" {
+		return json.Unmarshal([]byte(extraInfo.String), &ev.ExtraInfo)
+	}
+	return nil
+}
+
+// Sync persists the events returned by the component since the given time.
+// The already persisted events are ignored,
+// while the same events repeated within the same second are persisted as many.
+func Sync(ctx context.Context, db *sql.DB, tableName string, c components.Component, since time.Time) error {
+	// from the start of the second, so that the repeated events within the second
+	// are numbered the same in every sync
+	events, err := c.Events(ctx, since.Truncate(time.Second))
+	if err != nil {
+		return err
+	}
+
+	seqs := make(map[string]int)
+	for _, ev := range events {
+		key := fmt.Sprintf("%d/%s/%s/%s", ev.Time.Unix(), ev.Name, ev.Type, ev.Message)
+		seq := seqs[key]
+		seqs[key] = seq + 1
+
+		if err := insert(ctx, db, tableName, c.Name(), ev, seq); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Purge(ctx context.Context, db *sql.DB, tableName string, before time.Time) (int, error) {
+	query := fmt.Sprintf(`
+DELETE FROM %s WHERE %s < ?;`, tableName, ColumnUnixSeconds)
+	rs, err := db.ExecContext(ctx, query, before.Unix())
+	if err != nil {
+		return 0, err
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
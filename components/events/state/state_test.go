@@ -0,0 +1,282 @@
+package state
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/state"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestState(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := state.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	tableName := "test_events"
+	if err := CreateTable(ctx, db, tableName); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	if events, err := Read(ctx, db, tableName, "dmesg", time.Time{}); events != nil || err != nil {
+		t.Fatalf("expected no event + no error, got %v, %v", events, err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	events := []components.Event{
+		{Time: metav1.Time{Time: now.Add(-3 * time.Minute)}, Name: "oom", Type: components.EventTypeWarn, Message: "oom killed"},
+		{Time: metav1.Time{Time: now.Add(-2 * time.Minute)}, Name: "xid", Type: components.EventTypeError, Message: "xid 79", ExtraInfo: map[string]string{"xid": "79"}},
+		{Time: metav1.Time{Time: now.Add(-time.Minute)}, Name: "oom", Type: components.EventTypeWarn, Message: "oom killed"},
+	}
+	for _, ev := range events {
+		if err := Insert(ctx, db, tableName, "dmesg", ev); err != nil {
+			t.Fatalf("failed to insert event: %v", err)
+		}
+	}
+	// duplicate is ignored
+	if err := Insert(ctx, db, tableName, "dmesg", events[0]); err != nil {
+		t.Fatalf("failed to insert event: %v", err)
+	}
+	if err := Insert(ctx, db, tableName, "other", events[0]); err != nil {
+		t.Fatalf("failed to insert event: %v", err)
+	}
+
+	read, err := Read(ctx, db, tableName, "dmesg", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(read))
+	}
+	if !read[1].Time.Time.Equal(events[1].Time.Time) || read[1].ExtraInfo["xid"] != "79" {
+		t.Fatalf("unexpected event: %+v", read[1])
+	}
+
+	read, err = Read(ctx, db, tableName, "dmesg", now.Add(-150*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != 2 {
+		t.Fatalf("expected 2 events since, got %d", len(read))
+	}
+
+	read, err = Read(ctx, db, tableName, "dmesg", time.Time{}, WithEventType(components.EventTypeWarn), WithUntil(now.Add(-2*time.Minute)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != 1 || read[0].Name != "oom" {
+		t.Fatalf("unexpected events with type/until filters: %+v", read)
+	}
+
+	read, err = Read(ctx, db, tableName, "dmesg", time.Time{}, WithEventName("xid"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != 1 || read[0].Type != components.EventTypeError {
+		t.Fatalf("unexpected events with name filter: %+v", read)
+	}
+
+	purged, err := Purge(ctx, db, tableName, now.Add(-90*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 3 {
+		t.Fatalf("expected 3 purged, got %d", purged)
+	}
+}
+
+func TestReadAfter(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := state.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	tableName := "test_events"
+	if err := CreateTable(ctx, db, tableName); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	if id, err := LastID(ctx, db, tableName); id != 0 || err != nil {
+		t.Fatalf("expected 0 id + no error, got %d, %v", id, err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, name := range []string{"a", "b", "a", "c"} {
+		ev := components.Event{Time: metav1.Time{Time: now.Add(time.Duration(i) * time.Second)}, Name: "ev", Type: components.EventTypeInfo}
+		if err := Insert(ctx, db, tableName, name, ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, err := ReadAfter(ctx, db, tableName, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 || records[0].Component != "a" || records[3].Component != "c" {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+
+	records, err = ReadAfter(ctx, db, tableName, records[1].ID, WithComponents("a", "c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Component != "a" || records[1].Component != "c" {
+		t.Fatalf("unexpected records with components filter: %+v", records)
+	}
+
+	records, err = ReadAfter(ctx, db, tableName, 0, WithComponents("a"), WithLimit(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Component != "a" {
+		t.Fatalf("unexpected records with limit: %+v", records)
+	}
+
+	lastID, err := LastID(ctx, db, tableName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ids are not reused after purge
+	if _, err := Purge(ctx, db, tableName, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Insert(ctx, db, tableName, "d", components.Event{Time: metav1.Time{Time: now}, Name: "ev"}); err != nil {
+		t.Fatal(err)
+	}
+	records, err = ReadAfter(ctx, db, tableName, lastID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Component != "d" {
+		t.Fatalf("unexpected records after purge: %+v", records)
+	}
+}
+
+type testComponent struct {
+	events []components.Event
+}
+
+func (c *testComponent) Name() string { return "test" }
+func (c *testComponent) States(ctx context.Context) ([]components.State, error) {
+	return nil, nil
+}
+func (c *testComponent) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
+	return c.events, nil
+}
+func (c *testComponent) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
+	return nil, nil
+}
+func (c *testComponent) Close() error { return nil }
+
+func TestSync(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := state.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := CreateTable(ctx, db, DefaultTableName); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	now := time.Now()
+	c := &testComponent{
+		events: []components.Event{
+			{Time: metav1.Time{Time: now}, Name: "a", Type: components.EventTypeInfo, Message: "a"},
+			// repeated within the same second
+			{Time: metav1.Time{Time: now}, Name: "b", Type: components.EventTypeError, Message: "b"},
+			{Time: metav1.Time{Time: now}, Name: "b", Type: components.EventTypeError, Message: "b"},
+		},
+	}
+	for i := 0; i < 3; i++ {
+		if err := Sync(ctx, db, DefaultTableName, c, time.Time{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	read, err := Read(ctx, db, DefaultTableName, c.Name(), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != 3 {
+		t.Fatalf("expected 3 events after repeated syncs, got %d", len(read))
+	}
+}
+
+func TestMigrateTable(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := state.Open(filepath.Join(t.TempDir(), "gpud.state"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// the table created by the older version, without the id and seq columns
+	tableName := "test_events_migrate"
+	if _, err := db.ExecContext(ctx, `
+CREATE TABLE test_events_migrate (
+	component TEXT NOT NULL,
+	unix_seconds INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	type TEXT NOT NULL,
+	message TEXT NOT NULL,
+	extra_info TEXT,
+	UNIQUE (component, unix_seconds, name, type, message)
+);
+CREATE INDEX idx_test_events_migrate_component_unix_seconds ON test_events_migrate(component, unix_seconds);
+INSERT INTO test_events_migrate VALUES ('dmesg', 100, 'xid', 'error', 'xid 79', '{"xid":"79"}');
+INSERT INTO test_events_migrate VALUES ('dmesg', 200, 'oom', 'warn', 'oom killed', NULL);
+`); err != nil {
+		t.Fatal(err)
+	}
+
+	// idempotent
+	for i := 0; i < 2; i++ {
+		if err := CreateTable(ctx, db, tableName); err != nil {
+			t.Fatalf("failed to create table: %v", err)
+		}
+	}
+
+	records, err := ReadAfter(ctx, db, tableName, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].ID != 1 || records[0].Event.ExtraInfo["xid"] != "79" || records[1].Event.Name != "oom" {
+		t.Fatalf("unexpected migrated records %+v", records)
+	}
+
+	ev := components.Event{Time: metav1.Time{Time: time.Unix(100, 0)}, Name: "xid", Type: components.EventTypeError, Message: "xid 79"}
+	if err := insert(ctx, db, tableName, "dmesg", ev, 1); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := LastID(ctx, db, tableName); err != nil || id != 3 {
+		t.Fatalf("expected last id 3, got %d, %v", id, err)
+	}
+}
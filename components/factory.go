@@ -0,0 +1,105 @@
+package components
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/leptonai/gpud/errdefs"
+)
+
+// Factory creates a component from its configuration.
+// Each component package registers its factory in "init",
+// thus an out-of-tree component only needs a blank import
+// to be configured and created by the server.
+type Factory struct {
+	// Name is the unique name of the component,
+	// which is also the key in the configuration components map.
+	Name string
+
+	// DefaultConfig returns the config to set when the component is auto-detected.
+	// If nil, the component is configured with the nil config value
+	// (i.e., the default configuration returned by ParseConfig).
+	DefaultConfig func() any
+
+	// AutoDetect returns true if the component should be enabled
+	// in the default configuration for this host.
+	// If nil, the component is never enabled by default,
+	// only when explicitly configured (e.g., custom checks that require the config).
+	AutoDetect func(ctx context.Context) bool
+
+	// ParseConfig parses the raw config value (e.g., decoded from the YAML file)
+	// into the component config that is passed to "New".
+	// The raw value is nil if the component is enabled without any config,
+	// in which case it must return the default config.
+	// If the returned config implements "Validate() error", the caller validates it.
+	ParseConfig func(raw any, db *sql.DB) (any, error)
+
+	// New creates the component with the config returned by ParseConfig.
+	New func(ctx context.Context, cfg any, opts FactoryOptions) (Component, error)
+}
+
+// FactoryOptions is the shared state passed to the component factories.
+type FactoryOptions struct {
+	// DB is the state database.
+	DB *sql.DB
+	// Annotations are the annotations of the gpud instance.
+	Annotations map[string]string
+	// Components are all the component config values being enabled,
+	// keyed by the component name.
+	// Useful to check the configuration of the dependent components.
+	Components map[string]any
+}
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// RegisterFactory registers the component factory.
+// It panics if the factory is invalid or the name is already registered,
+// since it is meant to be called in "init".
+func RegisterFactory(f Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if f.Name == "" {
+		panic("component factory name is empty")
+	}
+	if f.ParseConfig == nil || f.New == nil {
+		panic(fmt.Sprintf("component factory %q missing ParseConfig or New", f.Name))
+	}
+	if _, ok := factories[f.Name]; ok {
+		panic(fmt.Sprintf("component factory %q already registered", f.Name))
+	}
+	factories[f.Name] = f
+}
+
+// GetFactory returns the component factory of the name.
+func GetFactory(name string) (Factory, error) {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	f, ok := factories[name]
+	if !ok {
+		return Factory{}, fmt.Errorf("component factory %s not found: %w", name, errdefs.ErrNotFound)
+	}
+	return f, nil
+}
+
+// GetAllFactories returns all the registered component factories sorted by name.
+func GetAllFactories() []Factory {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	fs := make([]Factory, 0, len(factories))
+	for _, f := range factories {
+		fs = append(fs, f)
+	}
+	sort.Slice(fs, func(i, j int) bool {
+		return fs[i].Name < fs[j].Name
+	})
+	return fs
+}
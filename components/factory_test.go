@@ -0,0 +1,57 @@
+package components
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/leptonai/gpud/errdefs"
+)
+
+func TestRegisterFactory(t *testing.T) {
+	f := Factory{
+		Name: "test-factory",
+		ParseConfig: func(raw any, db *sql.DB) (any, error) {
+			return nil, nil
+		},
+		New: func(ctx context.Context, cfg any, opts FactoryOptions) (Component, error) {
+			return nil, nil
+		},
+	}
+	RegisterFactory(f)
+	defer func() {
+		factoriesMu.Lock()
+		delete(factories, f.Name)
+		factoriesMu.Unlock()
+	}()
+
+	got, err := GetFactory(f.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != f.Name {
+		t.Fatalf("expected %q, got %q", f.Name, got.Name)
+	}
+
+	found := false
+	for _, f := range GetAllFactories() {
+		if f.Name == "test-factory" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("expected the factory in all factories")
+	}
+
+	if _, err := GetFactory("unknown"); !errors.Is(err, errdefs.ErrNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic on duplicate registration")
+		}
+	}()
+	RegisterFactory(f)
+}
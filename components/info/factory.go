@@ -0,0 +1,24 @@
+package info
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/leptonai/gpud/components"
+)
+
+func init() {
+	components.RegisterFactory(components.Factory{
+		Name: Name,
+		AutoDetect: func(ctx context.Context) bool {
+			return true
+		},
+		ParseConfig: func(raw any, db *sql.DB) (any, error) {
+			// no config
+			return nil, nil
+		},
+		New: func(ctx context.Context, cfg any, opts components.FactoryOptions) (components.Component, error) {
+			return New(opts.Annotations), nil
+		},
+	})
+}
@@ -1,4 +1,5 @@
-// Package pod tracks the current pods from the kubelet read-only port.
+// Package pod tracks the current pods from the kubelet read-only or authenticated port,
+// and the GPUs allocatable on the node.
 package pod
 
 import (
@@ -2,25 +2,40 @@ package pod
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
 	components_metrics "github.com/leptonai/gpud/components/metrics"
 	"github.com/leptonai/gpud/components/query"
 	"github.com/leptonai/gpud/log"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 )
 
 type Output struct {
 	NodeName string      `json:"node_name,omitempty"`
 	Pods     []PodStatus `json:"pods,omitempty"`
+
+	// Node is the node status from the API server,
+	// set if the API server is accessible.
+	Node *NodeStatus `json:"node,omitempty"`
+	// NVMLGPUCount is the number of GPUs found by NVML,
+	// set if NVML is running on the host.
+	NVMLGPUCount int `json:"nvml_gpu_count,omitempty"`
 }
 
 func (o *Output) JSON() ([]byte, error) {
@@ -65,15 +80,44 @@ func ParseStatesToOutput(states ...components.State) (*Output, error) {
 	return nil, fmt.Errorf("no pod state found")
 }
 
+// missingGPUs returns the number of GPUs found by NVML
+// but not advertised by the device plugin as allocatable.
+// The MIG-enabled GPUs are advertised as the MIG devices instead (the "mixed" strategy),
+// thus not compared with the GPU count.
+func (o *Output) missingGPUs() int64 {
+	if o.Node == nil || o.NVMLGPUCount == 0 || len(o.Node.MIGAllocatable) > 0 {
+		return 0
+	}
+	if d := int64(o.NVMLGPUCount) - o.Node.GPUAllocatable; d > 0 {
+		return d
+	}
+	return 0
+}
+
 func (o *Output) describeReason() string {
-	return fmt.Sprintf("total %d pods (node %s)", len(o.Pods), o.NodeName)
+	reason := fmt.Sprintf("total %d pods (node %s)", len(o.Pods), o.NodeName)
+	if o.Node == nil {
+		return reason
+	}
+
+	reason += fmt.Sprintf(", %s capacity %d, allocatable %d", o.Node.GPUResourceName, o.Node.GPUCapacity, o.Node.GPUAllocatable)
+	if len(o.Node.MIGAllocatable) > 0 {
+		reason += fmt.Sprintf(", mig allocatable %v", o.Node.MIGAllocatable)
+	}
+	if o.NVMLGPUCount > 0 {
+		reason += fmt.Sprintf(", nvml found %d GPU(s)", o.NVMLGPUCount)
+	}
+	if missing := o.missingGPUs(); missing > 0 {
+		reason += fmt.Sprintf(" -- %d GPU(s) not allocatable (device plugin or GPU failure)", missing)
+	}
+	return reason
 }
 
 func (o *Output) States() ([]components.State, error) {
 	b, _ := o.JSON()
 	return []components.State{{
 		Name:    StateNamePod,
-		Healthy: true,
+		Healthy: o.missingGPUs() == 0,
 		Reason:  o.describeReason(),
 		ExtraInfo: map[string]string{
 			StateKeyPodData:     string(b),
@@ -90,11 +134,17 @@ var (
 	defaultPollerc         = make(chan any)
 )
 
-// only set once since it relies on the kube client and specific port
+// only created once, and the get func is replaced with the reloaded config
+// since it relies on the config
 func setDefaultPoller(cfg Config) {
+	created := false
 	defaultPollerOnce.Do(func() {
-		defaultPoller = query.New(Name, cfg.Query, CreateGet(cfg))
+		defaultPoller = query.New(Name, cfg.Query, CreateGet(cfg), query.WithDecodeFunc(query.DecodeJSON[Output]))
+		created = true
 	})
+	if !created {
+		defaultPoller.SetGetFunc(CreateGet(cfg))
+	}
 }
 
 func GetDefaultPoller() query.Poller {
@@ -106,6 +156,12 @@ func DefaultPollerReady() <-chan any {
 }
 
 func CreateGet(cfg Config) query.GetFunc {
+	cfg.SetDefaultsIfNotSet()
+
+	var (
+		nodeClientOnce sync.Once
+		nodeClient     kubernetes.Interface
+	)
 	return func(ctx context.Context) (_ any, e error) {
 		defer func() {
 			if e != nil {
@@ -115,7 +171,7 @@ func CreateGet(cfg Config) query.GetFunc {
 			}
 		}()
 
-		pods, err := ListFromKubeletReadOnlyPort(ctx, cfg.Port)
+		pods, err := ListFromKubelet(ctx, cfg)
 		if err != nil {
 			return nil, err
 		}
@@ -130,13 +186,45 @@ func CreateGet(cfg Config) query.GetFunc {
 			pss = append(pss, ConvertToPodsStatus(pod)...)
 		}
 
-		return &Output{
+		o := &Output{
 			NodeName: nodeName,
 			Pods:     pss,
-		}, nil
+		}
+
+		nodeClientOnce.Do(func() {
+			var err error
+			nodeClient, err = NewNodeClient(cfg.Kubeconfig)
+			if errors.Is(err, rest.ErrNotInCluster) {
+				log.Logger.Debugw("not running in a pod, skipping node status")
+			} else if err != nil {
+				log.Logger.Warnw("failed to create node client, skipping node status", "error", err)
+			}
+		})
+		if nodeClient != nil && nodeName != "" {
+			// node status is best-effort, not to fail the pod listing
+			o.Node, err = GetNodeStatus(ctx, nodeClient, nodeName, cfg.GPUResourceName)
+			if err != nil {
+				log.Logger.Warnw("failed to get node status", "node", nodeName, "error", err)
+			}
+		}
+
+		if inst := nvidia_query_nvml.DefaultInstance(); inst != nil && inst.Exists() {
+			o.NVMLGPUCount = inst.DeviceCount()
+		}
+
+		return o, nil
 	}
 }
 
+// ListFromKubelet lists the pods from the kubelet authenticated port
+// if the token file is configured, otherwise, from the read-only port.
+func ListFromKubelet(ctx context.Context, cfg Config) (*corev1.PodList, error) {
+	if cfg.TokenFile == "" {
+		return ListFromKubeletReadOnlyPort(ctx, cfg.Port)
+	}
+	return ListFromKubeletAuthenticatedPort(ctx, cfg.Port, cfg.TokenFile, cfg.CAFile, cfg.InsecureSkipVerify)
+}
+
 const DefaultKubeletReadOnlyPort = 10255
 
 func CheckKubeletReadOnlyPort(ctx context.Context, port int) error {
@@ -187,6 +275,92 @@ func ListFromKubeletReadOnlyPort(ctx context.Context, port int) (*corev1.PodList
 	return parsePodsFromKubeletReadOnlyPort(resp.Body)
 }
 
+const DefaultKubeletPort = 10250
+
+// CheckKubeletAuthenticatedPort checks the kubelet authenticated port "/healthz"
+// with the bearer token.
+func CheckKubeletAuthenticatedPort(ctx context.Context, port int, tokenFile string, caFile string, insecureSkipVerify bool) error {
+	resp, err := getFromKubeletAuthenticatedPort(ctx, port, "/healthz", tokenFile, caFile, insecureSkipVerify)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("checking kubelet authenticated port failed %d", resp.StatusCode)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if string(b) != "ok" {
+		return fmt.Errorf("kubelet authenticated port /healthz expected 'ok', got %q", string(b))
+	}
+	return nil
+}
+
+// ListFromKubeletAuthenticatedPort lists the pods from the kubelet authenticated port
+// (e.g., 10250) with the bearer token, which requires the "nodes/proxy" permission.
+// The token file is read for every request, since the projected tokens are rotated.
+func ListFromKubeletAuthenticatedPort(ctx context.Context, port int, tokenFile string, caFile string, insecureSkipVerify bool) (*corev1.PodList, error) {
+	resp, err := getFromKubeletAuthenticatedPort(ctx, port, "/pods", tokenFile, caFile, insecureSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("listing pods from kubelet authenticated port failed %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	return parsePodsFromKubeletReadOnlyPort(resp.Body)
+}
+
+func getFromKubeletAuthenticatedPort(ctx context.Context, port int, path string, tokenFile string, caFile string, insecureSkipVerify bool) (*http.Response, error) {
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubelet token file: %w", err)
+	}
+	cli, err := authenticatedHTTPClient(caFile, insecureSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	u := fmt.Sprintf("https://localhost:%d%s", port, path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+
+	return cli.Do(req)
+}
+
+func authenticatedHTTPClient(caFile string, insecureSkipVerify bool) (*http.Client, error) {
+	tlsCfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+
+		// the kubelet serving certificate is often self-signed
+		// or does not include "localhost" in its names
+		InsecureSkipVerify: insecureSkipVerify, //nolint:gosec
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kubelet ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in kubelet ca file %q", caFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	cli := defaultHTTPClient()
+	cli.Transport.(*http.Transport).TLSClientConfig = tlsCfg
+	return cli, nil
+}
+
 func parsePodsFromKubeletReadOnlyPort(r io.Reader) (*corev1.PodList, error) {
 	// ref. "pkg/kubelet/server/server.go#encodePods"
 	podList := new(corev1.PodList)
@@ -5,10 +5,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestListFromKubeletReadOnlyPort(t *testing.T) {
@@ -111,3 +115,138 @@ func Test_parsePodsFromKubeletReadOnlyPort(t *testing.T) {
 		t.Errorf("expected pod phase 'Running', got: %s", pods.Items[1].Status.Phase)
 	}
 }
+
+func TestListFromKubeletAuthenticatedPort(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeFile(w, r, "kubelet-readonly-pods.json")
+	}))
+	defer srv.Close()
+
+	portRaw := srv.URL[len("https://127.0.0.1:"):]
+	port, _ := strconv.ParseInt(portRaw, 10, 32)
+
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenFile, []byte("test-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	invalidTokenFile := filepath.Join(dir, "invalid-token")
+	if err := os.WriteFile(invalidTokenFile, []byte("invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pods, err := ListFromKubelet(ctx, Config{Port: int(port), TokenFile: tokenFile, InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(pods.Items) != 2 {
+		t.Fatalf("expected 2 pod, got %d", len(pods.Items))
+	}
+
+	if _, err := ListFromKubeletAuthenticatedPort(ctx, int(port), invalidTokenFile, "", true); err == nil {
+		t.Fatal("expected an unauthorized error, got nil")
+	}
+	// self-signed serving certificate is not trusted by default
+	if _, err := ListFromKubeletAuthenticatedPort(ctx, int(port), tokenFile, "", false); err == nil {
+		t.Fatal("expected a certificate error, got nil")
+	}
+}
+
+func TestOutputStatesGPUAllocatable(t *testing.T) {
+	t.Parallel()
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{
+			Capacity: corev1.ResourceList{
+				corev1.ResourceName(DefaultGPUResourceName): resource.MustParse("8"),
+			},
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceName(DefaultGPUResourceName): resource.MustParse("7"),
+			},
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue, Reason: "KubeletReady"},
+			},
+		},
+	}
+	cli := fake.NewSimpleClientset(node)
+
+	st, err := GetNodeStatus(context.Background(), cli, "node-1", DefaultGPUResourceName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.GPUCapacity != 8 || st.GPUAllocatable != 7 {
+		t.Fatalf("unexpected gpu capacity %d, allocatable %d", st.GPUCapacity, st.GPUAllocatable)
+	}
+	if len(st.Conditions) != 1 || st.Conditions[0].Type != "Ready" {
+		t.Fatalf("unexpected conditions %+v", st.Conditions)
+	}
+	if st.MIGAllocatable != nil {
+		t.Fatalf("unexpected mig allocatable %v", st.MIGAllocatable)
+	}
+
+	migNode := convertToNodeStatus(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-mig"},
+		Status: corev1.NodeStatus{
+			Capacity: corev1.ResourceList{
+				corev1.ResourceName("nvidia.com/mig-1g.5gb"):  resource.MustParse("7"),
+				corev1.ResourceName("nvidia.com/mig-3g.20gb"): resource.MustParse("2"),
+			},
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceName("nvidia.com/mig-1g.5gb"): resource.MustParse("7"),
+			},
+		},
+	}, DefaultGPUResourceName)
+	if migNode.MIGAllocatable["nvidia.com/mig-1g.5gb"] != 7 || migNode.MIGAllocatable["nvidia.com/mig-3g.20gb"] != 0 {
+		t.Fatalf("unexpected mig allocatable %v", migNode.MIGAllocatable)
+	}
+	if _, err := GetNodeStatus(context.Background(), cli, "node-2", DefaultGPUResourceName); err == nil {
+		t.Fatal("expected not found error, got nil")
+	}
+
+	tests := []struct {
+		name         string
+		node         *NodeStatus
+		nvmlGPUCount int
+		wantHealthy  bool
+	}{
+		{name: "no node status", nvmlGPUCount: 8, wantHealthy: true},
+		{name: "no nvml", node: st, wantHealthy: true},
+		{name: "all allocatable", node: &NodeStatus{Name: "node-1", GPUCapacity: 8, GPUAllocatable: 8}, nvmlGPUCount: 8, wantHealthy: true},
+		{name: "fewer allocatable", node: st, nvmlGPUCount: 8, wantHealthy: false},
+		{name: "mig mixed", node: &NodeStatus{Name: "node-1", MIGAllocatable: map[string]int64{"nvidia.com/mig-1g.5gb": 14}}, nvmlGPUCount: 2, wantHealthy: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &Output{NodeName: "node-1", Node: tt.node, NVMLGPUCount: tt.nvmlGPUCount}
+			states, err := o.States()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(states) != 1 {
+				t.Fatalf("expected 1 state, got %d", len(states))
+			}
+			if states[0].Healthy != tt.wantHealthy {
+				t.Fatalf("expected healthy %v, got %v (%s)", tt.wantHealthy, states[0].Healthy, states[0].Reason)
+			}
+
+			parsed, err := ParseStatesToOutput(states...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed.NVMLGPUCount != tt.nvmlGPUCount {
+				t.Fatalf("expected nvml gpu count %d, got %d", tt.nvmlGPUCount, parsed.NVMLGPUCount)
+			}
+		})
+	}
+}
@@ -8,9 +8,39 @@ import (
 	query_config "github.com/leptonai/gpud/components/query/config"
 )
 
+const (
+	// DefaultServiceAccountTokenFile is the service account token mounted in the pod.
+	DefaultServiceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	// DefaultServiceAccountCAFile is the cluster CA certificate mounted in the pod.
+	DefaultServiceAccountCAFile = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+
+	// DefaultGPUResourceName is the extended resource advertised by the NVIDIA device plugin.
+	DefaultGPUResourceName = "nvidia.com/gpu"
+)
+
 type Config struct {
 	Query query_config.Config `json:"query"`
 	Port  int                 `json:"port"`
+
+	// TokenFile is the bearer token file to authenticate to the kubelet
+	// (e.g., the service account token).
+	// If set, the kubelet is queried over HTTPS (e.g., the authenticated port 10250),
+	// and the token requires the "nodes/proxy" permission.
+	TokenFile string `json:"token_file,omitempty"`
+	// CAFile is the CA certificate file to verify the kubelet serving certificate.
+	// If empty, the system root CAs are used.
+	CAFile string `json:"ca_file,omitempty"`
+	// InsecureSkipVerify skips verifying the kubelet serving certificate,
+	// which is often self-signed. Never set by the auto-detection.
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
+
+	// Kubeconfig is the kubeconfig file to read the node status from the API server.
+	// If empty, the in-cluster config is used when running in a pod,
+	// otherwise, the node status is not reported.
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+	// GPUResourceName is the node resource to compare with the GPUs found by NVML.
+	// Defaults to "nvidia.com/gpu".
+	GPUResourceName string `json:"gpu_resource_name,omitempty"`
 }
 
 func ParseConfig(b any, db *sql.DB) (*Config, error) {
@@ -33,5 +63,14 @@ func (cfg Config) Validate() error {
 	if cfg.Port == 0 {
 		return errors.New("kubelet port is required")
 	}
+	if cfg.TokenFile == "" && (cfg.CAFile != "" || cfg.InsecureSkipVerify) {
+		return errors.New("kubelet ca_file and insecure_skip_verify require token_file")
+	}
 	return nil
 }
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	if cfg.GPUResourceName == "" {
+		cfg.GPUResourceName = DefaultGPUResourceName
+	}
+}
@@ -0,0 +1,120 @@
+package pod
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	query_config "github.com/leptonai/gpud/components/query/config"
+	"github.com/leptonai/gpud/log"
+)
+
+func init() {
+	components.RegisterFactory(components.Factory{
+		Name:          Name,
+		DefaultConfig: defaultConfig,
+		AutoDetect:    autoDetect,
+		ParseConfig: func(raw any, db *sql.DB) (any, error) {
+			if raw == nil {
+				return &Config{Query: query_config.Config{State: &query_config.State{DB: db}}}, nil
+			}
+			return ParseConfig(raw, db)
+		},
+		New: func(ctx context.Context, cfg any, _ components.FactoryOptions) (components.Component, error) {
+			return New(ctx, *cfg.(*Config)), nil
+		},
+	})
+}
+
+var (
+	detectedMu sync.Mutex
+	// set by the last auto-detection, to configure the detected port
+	// without probing the kubelet again
+	detectedAuthenticatedPort bool
+)
+
+// defaultConfig uses the port found by the auto-detection:
+// the most common read-only port 10255 if open,
+// otherwise, the authenticated port 10250 with the service account token.
+// The kubelet serving certificate is verified with the service account CA
+// (if mounted), and "insecure_skip_verify" must be explicitly configured
+// for the self-signed kubelet serving certificate.
+func defaultConfig() any {
+	cfg := Config{
+		Query: query_config.DefaultConfig(),
+		Port:  DefaultKubeletReadOnlyPort,
+	}
+
+	detectedMu.Lock()
+	authenticated := detectedAuthenticatedPort
+	detectedMu.Unlock()
+	if authenticated {
+		cfg.Port = DefaultKubeletPort
+		cfg.TokenFile = DefaultServiceAccountTokenFile
+		cfg.CAFile = defaultCAFile()
+	}
+	return cfg
+}
+
+func serviceAccountTokenExists() bool {
+	_, err := os.Stat(DefaultServiceAccountTokenFile)
+	return err == nil
+}
+
+// defaultCAFile returns the service account CA file if mounted,
+// otherwise empty to use the system root CAs.
+func defaultCAFile() string {
+	if _, err := os.Stat(DefaultServiceAccountCAFile); err != nil {
+		return ""
+	}
+	return DefaultServiceAccountCAFile
+}
+
+// autoDetect returns true if the kubelet read-only port is open,
+// or the authenticated port is accessible with the service account token
+// and its serving certificate is verified.
+func autoDetect(ctx context.Context) bool {
+	if runtime.GOOS != "linux" {
+		log.Logger.Debugw("ignoring default kubelet checking since it's not linux", "os", runtime.GOOS)
+		return false
+	}
+
+	detectedMu.Lock()
+	defer detectedMu.Unlock()
+	detectedAuthenticatedPort = false
+
+	// check if the TCP port is open/used
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("localhost:%d", DefaultKubeletReadOnlyPort), 3*time.Second)
+	if err != nil {
+		log.Logger.Debugw("tcp port is not open", "port", DefaultKubeletReadOnlyPort, "error", err)
+		detectedAuthenticatedPort = autoDetectAuthenticatedPort(ctx)
+		return detectedAuthenticatedPort
+	}
+	log.Logger.Debugw("tcp port is open", "port", DefaultKubeletReadOnlyPort)
+	conn.Close()
+
+	if err := CheckKubeletReadOnlyPort(ctx, DefaultKubeletReadOnlyPort); err != nil {
+		log.Logger.Debugw("kubelet readonly port is not open", "port", DefaultKubeletReadOnlyPort, "error", err)
+		detectedAuthenticatedPort = autoDetectAuthenticatedPort(ctx)
+		return detectedAuthenticatedPort
+	}
+	return true
+}
+
+func autoDetectAuthenticatedPort(ctx context.Context) bool {
+	if !serviceAccountTokenExists() {
+		log.Logger.Debugw("service account token not found", "file", DefaultServiceAccountTokenFile)
+		return false
+	}
+	if err := CheckKubeletAuthenticatedPort(ctx, DefaultKubeletPort, DefaultServiceAccountTokenFile, defaultCAFile(), false); err != nil {
+		log.Logger.Debugw("kubelet authenticated port is not accessible (set insecure_skip_verify for the self-signed kubelet certificate)", "port", DefaultKubeletPort, "error", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package pod
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// migResourcePrefix is the prefix of the MIG device resources
+// advertised by the NVIDIA device plugin with the "mixed" MIG strategy.
+const migResourcePrefix = "nvidia.com/mig-"
+
+// NodeStatus represents the simpler node status from the API server.
+// ref. https://pkg.go.dev/k8s.io/api/core/v1#NodeStatus
+type NodeStatus struct {
+	Name       string          `json:"name,omitempty"`
+	Conditions []NodeCondition `json:"conditions,omitempty"`
+
+	// GPUResourceName is the node resource name of the GPUs (e.g., "nvidia.com/gpu").
+	GPUResourceName string `json:"gpu_resource_name,omitempty"`
+	// GPUCapacity is the total number of GPUs advertised by the device plugin.
+	GPUCapacity int64 `json:"gpu_capacity"`
+	// GPUAllocatable is the number of GPUs available for the pods.
+	GPUAllocatable int64 `json:"gpu_allocatable"`
+	// MIGAllocatable is the number of the allocatable MIG devices by the resource name
+	// (e.g., "nvidia.com/mig-1g.5gb"), advertised with the "mixed" MIG strategy.
+	MIGAllocatable map[string]int64 `json:"mig_allocatable,omitempty"`
+}
+
+// ref. https://pkg.go.dev/k8s.io/api/core/v1#NodeCondition
+type NodeCondition struct {
+	Type               string      `json:"type,omitempty"`
+	Status             string      `json:"status,omitempty"`
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	Reason             string      `json:"reason,omitempty"`
+	Message            string      `json:"message,omitempty"`
+}
+
+// NewNodeClient creates the client to read the node status from the API server.
+// If the kubeconfig is empty, it uses the in-cluster config,
+// and returns "rest.ErrNotInCluster" if not running in a pod.
+func NewNodeClient(kubeconfig string) (kubernetes.Interface, error) {
+	var (
+		restCfg *rest.Config
+		err     error
+	)
+	if kubeconfig == "" {
+		restCfg, err = rest.InClusterConfig()
+	} else {
+		restCfg, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(restCfg)
+}
+
+// GetNodeStatus returns the node conditions and the GPU capacity and allocatable of the node.
+func GetNodeStatus(ctx context.Context, client kubernetes.Interface, nodeName string, gpuResourceName string) (*NodeStatus, error) {
+	if nodeName == "" {
+		return nil, errors.New("node name is empty")
+	}
+	node, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node %q: %w", nodeName, err)
+	}
+	return convertToNodeStatus(node, gpuResourceName), nil
+}
+
+func convertToNodeStatus(node *corev1.Node, gpuResourceName string) *NodeStatus {
+	conds := make([]NodeCondition, 0, len(node.Status.Conditions))
+	for _, c := range node.Status.Conditions {
+		conds = append(conds, NodeCondition{
+			Type:               string(c.Type),
+			Status:             string(c.Status),
+			LastTransitionTime: c.LastTransitionTime,
+			Reason:             c.Reason,
+			Message:            c.Message,
+		})
+	}
+
+	st := &NodeStatus{
+		Name:            node.Name,
+		Conditions:      conds,
+		GPUResourceName: gpuResourceName,
+	}
+	if q, ok := node.Status.Capacity[corev1.ResourceName(gpuResourceName)]; ok {
+		st.GPUCapacity = q.Value()
+	}
+	if q, ok := node.Status.Allocatable[corev1.ResourceName(gpuResourceName)]; ok {
+		st.GPUAllocatable = q.Value()
+	}
+	for name := range node.Status.Capacity {
+		if !strings.HasPrefix(string(name), migResourcePrefix) {
+			continue
+		}
+		if st.MIGAllocatable == nil {
+			st.MIGAllocatable = make(map[string]int64)
+		}
+		allocatable := node.Status.Allocatable[name]
+		st.MIGAllocatable[string(name)] = allocatable.Value()
+	}
+	return st
+}
@@ -0,0 +1,210 @@
+package podresources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/leptonai/gpud/components"
+	components_metrics "github.com/leptonai/gpud/components/metrics"
+	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/log"
+
+	podresourcesapi "k8s.io/kubelet/pkg/apis/podresources/v1"
+)
+
+// DefaultResourceNamePrefix is the prefix of the NVIDIA device plugin resources
+// (e.g., "nvidia.com/gpu", "nvidia.com/mig-1g.10gb").
+const DefaultResourceNamePrefix = "nvidia.com/"
+
+// DeviceOwner is the pod container that the device is allocated to.
+type DeviceOwner struct {
+	// DeviceID is the device ID reported by the device plugin
+	// (e.g., the GPU UUID for the NVIDIA device plugin).
+	DeviceID     string `json:"device_id"`
+	ResourceName string `json:"resource_name"`
+
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+}
+
+func (o DeviceOwner) String() string {
+	return fmt.Sprintf("%s/%s (container %s)", o.Namespace, o.Pod, o.Container)
+}
+
+type Output struct {
+	Devices []DeviceOwner `json:"devices,omitempty"`
+}
+
+func (o *Output) JSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func ParseOutputJSON(data []byte) (*Output, error) {
+	o := new(Output)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+const (
+	StateNamePodResources = "pod_resources"
+
+	StateKeyPodResourcesData           = "data"
+	StateKeyPodResourcesEncoding       = "encoding"
+	StateValuePodResourcesEncodingJSON = "json"
+)
+
+func ParseStatePodResources(m map[string]string) (*Output, error) {
+	data := m[StateKeyPodResourcesData]
+	return ParseOutputJSON([]byte(data))
+}
+
+func ParseStatesToOutput(states ...components.State) (*Output, error) {
+	for _, state := range states {
+		switch state.Name {
+		case StateNamePodResources:
+			return ParseStatePodResources(state.ExtraInfo)
+
+		default:
+			return nil, fmt.Errorf("unknown state name: %s", state.Name)
+		}
+	}
+	return nil, fmt.Errorf("no pod resources state found")
+}
+
+func (o *Output) describeReason() string {
+	pods := make(map[string]struct{})
+	for _, d := range o.Devices {
+		pods[d.Namespace+"/"+d.Pod] = struct{}{}
+	}
+	return fmt.Sprintf("total %d devices allocated to %d pods", len(o.Devices), len(pods))
+}
+
+func (o *Output) States() ([]components.State, error) {
+	b, _ := o.JSON()
+	return []components.State{{
+		Name:    StateNamePodResources,
+		Healthy: true,
+		Reason:  o.describeReason(),
+		ExtraInfo: map[string]string{
+			StateKeyPodResourcesData:     string(b),
+			StateKeyPodResourcesEncoding: StateValuePodResourcesEncodingJSON,
+		},
+	}}, nil
+}
+
+// Owner returns the pod container that the device is allocated to.
+func (o *Output) Owner(deviceID string) (*DeviceOwner, bool) {
+	for i := range o.Devices {
+		if o.Devices[i].DeviceID == deviceID {
+			return &o.Devices[i], true
+		}
+	}
+	return nil, false
+}
+
+// ConvertToDeviceOwners returns the device owners of the resources matching the name prefixes,
+// sorted by the device ID.
+func ConvertToDeviceOwners(prs []*podresourcesapi.PodResources, resourceNamePrefixes []string) []DeviceOwner {
+	owners := make([]DeviceOwner, 0)
+	for _, pr := range prs {
+		for _, c := range pr.GetContainers() {
+			for _, d := range c.GetDevices() {
+				if !hasAnyPrefix(d.GetResourceName(), resourceNamePrefixes) {
+					continue
+				}
+				for _, id := range d.GetDeviceIds() {
+					owners = append(owners, DeviceOwner{
+						DeviceID:     id,
+						ResourceName: d.GetResourceName(),
+						Namespace:    pr.GetNamespace(),
+						Pod:          pr.GetName(),
+						Container:    c.GetName(),
+					})
+				}
+			}
+		}
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		return owners[i].DeviceID < owners[j].DeviceID
+	})
+	return owners
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	defaultPollerOnce sync.Once
+	defaultPoller     query.Poller
+)
+
+// only created once, and the get func is replaced with the reloaded config
+// since it relies on the config
+func setDefaultPoller(cfg Config) {
+	created := false
+	defaultPollerOnce.Do(func() {
+		defaultPoller = query.New(Name, cfg.Query, CreateGet(cfg), query.WithDecodeFunc(query.DecodeJSON[Output]))
+		created = true
+	})
+	if !created {
+		defaultPoller.SetGetFunc(CreateGet(cfg))
+	}
+}
+
+func getDefaultPoller() query.Poller {
+	return defaultPoller
+}
+
+// GetDeviceOwner returns the pod container that the device (e.g., GPU UUID) is allocated to,
+// from the last poll of the pod resources.
+// Returns false if the component is not enabled, or the device is not allocated.
+func GetDeviceOwner(deviceID string) (*DeviceOwner, bool) {
+	poller := getDefaultPoller()
+	if poller == nil {
+		return nil, false
+	}
+	last, err := poller.Last()
+	if err != nil || last == nil || last.Output == nil {
+		return nil, false
+	}
+	o, ok := last.Output.(*Output)
+	if !ok {
+		return nil, false
+	}
+	return o.Owner(deviceID)
+}
+
+func CreateGet(cfg Config) query.GetFunc {
+	return func(ctx context.Context) (_ any, e error) {
+		defer func() {
+			if e != nil {
+				components_metrics.SetGetFailed(Name)
+			} else {
+				components_metrics.SetGetSuccess(Name)
+			}
+		}()
+
+		prs, err := ListPodResources(ctx, cfg.Socket)
+		if err != nil {
+			return nil, err
+		}
+		log.Logger.Debugw("listed pod resources", "pods", len(prs))
+
+		return &Output{
+			Devices: ConvertToDeviceOwners(prs, cfg.ResourceNames),
+		}, nil
+	}
+}
@@ -0,0 +1,116 @@
+package podresources
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+	podresourcesapi "k8s.io/kubelet/pkg/apis/podresources/v1"
+)
+
+type testServer struct {
+	podresourcesapi.UnimplementedPodResourcesListerServer
+	resp *podresourcesapi.ListPodResourcesResponse
+}
+
+func (s *testServer) List(context.Context, *podresourcesapi.ListPodResourcesRequest) (*podresourcesapi.ListPodResourcesResponse, error) {
+	return s.resp, nil
+}
+
+func startTestServer(t *testing.T, resp *podresourcesapi.ListPodResourcesResponse) string {
+	socket := filepath.Join(t.TempDir(), "kubelet.sock")
+	lis, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	podresourcesapi.RegisterPodResourcesListerServer(srv, &testServer{resp: resp})
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+	return socket
+}
+
+func TestListPodResources(t *testing.T) {
+	socket := startTestServer(t, &podresourcesapi.ListPodResourcesResponse{
+		PodResources: []*podresourcesapi.PodResources{
+			{
+				Name:      "train-0",
+				Namespace: "ml",
+				Containers: []*podresourcesapi.ContainerResources{
+					{
+						Name: "trainer",
+						Devices: []*podresourcesapi.ContainerDevices{
+							{ResourceName: "nvidia.com/gpu", DeviceIds: []string{"GPU-b", "GPU-a"}},
+							{ResourceName: "rdma/hca", DeviceIds: []string{"mlx5_0"}},
+						},
+					},
+				},
+			},
+			{
+				Name:      "infer-0",
+				Namespace: "serving",
+				Containers: []*podresourcesapi.ContainerResources{
+					{
+						Name: "server",
+						Devices: []*podresourcesapi.ContainerDevices{
+							{ResourceName: "nvidia.com/mig-1g.10gb", DeviceIds: []string{"MIG-c"}},
+						},
+					},
+				},
+			},
+			{Name: "no-gpu", Namespace: "default"},
+		},
+	})
+
+	get := CreateGet(Config{Socket: socket, ResourceNames: []string{DefaultResourceNamePrefix}})
+	out, err := get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := out.(*Output)
+	if len(o.Devices) != 3 {
+		t.Fatalf("expected 3 devices, got %+v", o.Devices)
+	}
+	if o.Devices[0].DeviceID != "GPU-a" {
+		t.Fatalf("expected devices sorted by id, got %+v", o.Devices)
+	}
+
+	owner, ok := o.Owner("MIG-c")
+	if !ok {
+		t.Fatal("expected owner of MIG-c")
+	}
+	if owner.Namespace != "serving" || owner.Pod != "infer-0" || owner.Container != "server" || owner.ResourceName != "nvidia.com/mig-1g.10gb" {
+		t.Fatalf("unexpected owner %+v", owner)
+	}
+	if _, ok := o.Owner("mlx5_0"); ok {
+		t.Fatal("expected non-gpu device to be ignored")
+	}
+
+	states, err := o.States()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseStatesToOutput(states...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Devices) != 3 || states[0].Reason != "total 3 devices allocated to 2 pods" {
+		t.Fatalf("unexpected states %+v", states)
+	}
+}
+
+func TestListPodResourcesNoSocket(t *testing.T) {
+	if _, err := ListPodResources(context.Background(), filepath.Join(t.TempDir(), "missing.sock")); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestGetDeviceOwnerNotEnabled(t *testing.T) {
+	if _, ok := GetDeviceOwner("GPU-a"); ok {
+		t.Fatal("expected no owner when the component is not enabled")
+	}
+}
@@ -0,0 +1,50 @@
+package podresources
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	query_config "github.com/leptonai/gpud/components/query/config"
+)
+
+type Config struct {
+	Query query_config.Config `json:"query"`
+	// Socket is the path to the kubelet pod-resources socket.
+	Socket string `json:"socket"`
+	// ResourceNames to track (e.g., "nvidia.com/gpu"), as the prefixes.
+	// If empty, "nvidia.com/" resources are tracked.
+	ResourceNames []string `json:"resource_names,omitempty"`
+}
+
+func ParseConfig(b any, db *sql.DB) (*Config, error) {
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	err = json.Unmarshal(raw, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Query.State != nil {
+		cfg.Query.State.DB = db
+	}
+	return cfg, nil
+}
+
+func (cfg Config) Validate() error {
+	if cfg.Socket == "" {
+		return errors.New("kubelet pod-resources socket is required")
+	}
+	return nil
+}
+
+func (cfg *Config) SetDefaultsIfNotSet() {
+	if cfg.Socket == "" {
+		cfg.Socket = DefaultSocket
+	}
+	if len(cfg.ResourceNames) == 0 {
+		cfg.ResourceNames = []string{DefaultResourceNamePrefix}
+	}
+}
@@ -0,0 +1,47 @@
+package podresources
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"runtime"
+
+	"github.com/leptonai/gpud/components"
+	query_config "github.com/leptonai/gpud/components/query/config"
+	"github.com/leptonai/gpud/log"
+)
+
+func init() {
+	components.RegisterFactory(components.Factory{
+		Name: Name,
+		DefaultConfig: func() any {
+			return Config{
+				Query:  query_config.DefaultConfig(),
+				Socket: DefaultSocket,
+			}
+		},
+		AutoDetect: autoDetect,
+		ParseConfig: func(raw any, db *sql.DB) (any, error) {
+			if raw == nil {
+				return &Config{Query: query_config.Config{State: &query_config.State{DB: db}}, Socket: DefaultSocket}, nil
+			}
+			return ParseConfig(raw, db)
+		},
+		New: func(ctx context.Context, cfg any, _ components.FactoryOptions) (components.Component, error) {
+			return New(ctx, *cfg.(*Config)), nil
+		},
+	})
+}
+
+// autoDetect returns true if the kubelet pod-resources socket exists.
+func autoDetect(ctx context.Context) bool {
+	if runtime.GOOS != "linux" {
+		log.Logger.Debugw("ignoring default kubelet pod-resources checking since it's not linux", "os", runtime.GOOS)
+		return false
+	}
+	if _, err := os.Stat(DefaultSocket); err != nil {
+		log.Logger.Debugw("kubelet pod-resources socket not found", "socket", DefaultSocket, "error", err)
+		return false
+	}
+	return true
+}
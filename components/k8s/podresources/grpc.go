@@ -0,0 +1,52 @@
+package podresources
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	podresourcesapi "k8s.io/kubelet/pkg/apis/podresources/v1"
+)
+
+const (
+	// DefaultSocket is the default kubelet pod-resources socket.
+	// ref. https://kubernetes.io/docs/concepts/extend-kubernetes/compute-storage-net/device-plugins/#monitoring-device-plugin-resources
+	DefaultSocket = "/var/lib/kubelet/pod-resources/kubelet.sock"
+
+	// maxMsgSize use 16MB as the default message size limit,
+	// same as the kubelet pod-resources client.
+	maxMsgSize = 1024 * 1024 * 16
+
+	defaultTimeout = 10 * time.Second
+)
+
+func dialUnix(ctx context.Context, addr string) (net.Conn, error) {
+	return (&net.Dialer{}).DialContext(ctx, "unix", addr)
+}
+
+// ListPodResources lists the devices allocated to the pod containers
+// from the kubelet pod-resources API.
+// ref. https://github.com/kubernetes/kubernetes/blob/v1.32.0-alpha.0/pkg/kubelet/apis/podresources/client.go
+func ListPodResources(ctx context.Context, socket string) ([]*podresourcesapi.PodResources, error) {
+	conn, err := grpc.NewClient(
+		"passthrough:///"+socket,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxMsgSize)),
+		grpc.WithContextDialer(dialUnix),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect %s: %w", socket, err)
+	}
+	defer conn.Close()
+
+	cctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+	resp, err := podresourcesapi.NewPodResourcesListerClient(conn).List(cctx, &podresourcesapi.ListPodResourcesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pod resources: %w", err)
+	}
+	return resp.GetPodResources(), nil
+}
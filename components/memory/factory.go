@@ -0,0 +1,30 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/leptonai/gpud/components"
+	memory_metrics "github.com/leptonai/gpud/components/memory/metrics"
+	components_metrics_state "github.com/leptonai/gpud/components/metrics/state"
+	query_config "github.com/leptonai/gpud/components/query/config"
+)
+
+func init() {
+	components.RegisterFactory(components.Factory{
+		Name: Name,
+		AutoDetect: func(ctx context.Context) bool {
+			return true
+		},
+		ParseConfig: func(raw any, db *sql.DB) (any, error) {
+			if raw == nil {
+				return &Config{Query: query_config.Config{State: &query_config.State{DB: db}}}, nil
+			}
+			return ParseConfig(raw, db)
+		},
+		New: func(ctx context.Context, cfg any, opts components.FactoryOptions) (components.Component, error) {
+			memory_metrics.InitAveragers(opts.DB, components_metrics_state.DefaultTableName)
+			return New(ctx, *cfg.(*Config)), nil
+		},
+	})
+}
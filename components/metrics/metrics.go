@@ -3,9 +3,12 @@ package metrics
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/leptonai/gpud/components"
 	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var (
@@ -79,6 +82,16 @@ func SetRegistered(componentName string) {
 	componentsRegistered.With(prometheus.Labels{"component": componentName}).Set(1.0)
 }
 
+// SetUnregistered removes all the metrics of the component,
+// so that the removed component no longer reports stale values.
+func SetUnregistered(componentName string) {
+	componentsRegistered.DeleteLabelValues(componentName)
+	componentsHealthy.DeleteLabelValues(componentName)
+	componentsUnhealthy.DeleteLabelValues(componentName)
+	componentsGetSuccess.DeleteLabelValues(componentName)
+	componentsGetFailed.DeleteLabelValues(componentName)
+}
+
 func SetHealthy(componentName string) {
 	componentsHealthy.With(prometheus.Labels{"component": componentName}).Set(1.0)
 	componentsUnhealthy.With(prometheus.Labels{"component": componentName}).Set(0.0)
@@ -196,26 +209,44 @@ func (w *watchableComponent) Unwrap() interface{} {
 
 type watchableComponent struct {
 	components.Component
+
+	mu         sync.RWMutex
+	lastHealth *components.HealthStatus
 }
 
 func (w *watchableComponent) States(ctx context.Context) ([]components.State, error) {
 	states, err := w.Component.States(ctx)
 	if err != nil {
 		SetUnhealthy(w.Component.Name())
+		w.setLastHealth(components.HealthStatus{
+			Healthy: false,
+			Error:   err.Error(),
+			Time:    metav1.Time{Time: time.Now().UTC()},
+		})
 		return nil, err
 	}
 
-	healthy := true
-	for _, state := range states {
-		if !state.Healthy {
-			healthy = false
-			break
-		}
-	}
-	if healthy {
+	hs := components.EvaluateHealth(states)
+	if hs.Healthy {
 		SetHealthy(w.Component.Name())
 	} else {
 		SetUnhealthy(w.Component.Name())
 	}
+	w.setLastHealth(hs)
 	return states, nil
 }
+
+func (w *watchableComponent) LastHealth() (components.HealthStatus, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.lastHealth == nil {
+		return components.HealthStatus{}, false
+	}
+	return *w.lastHealth, true
+}
+
+func (w *watchableComponent) setLastHealth(hs components.HealthStatus) {
+	w.mu.Lock()
+	w.lastHealth = &hs
+	w.mu.Unlock()
+}
@@ -0,0 +1,22 @@
+package query
+
+type Op struct {
+	decodeFunc DecodeFunc
+}
+
+type OpOption func(*Op)
+
+func (op *Op) applyOpts(opts []OpOption) {
+	for _, opt := range opts {
+		opt(op)
+	}
+}
+
+// WithDecodeFunc sets the function to decode the persisted output,
+// in order to warm-start the last results from the storage after restart.
+// If not set, the results are persisted but not loaded.
+func WithDecodeFunc(f DecodeFunc) OpOption {
+	return func(op *Op) {
+		op.decodeFunc = f
+	}
+}
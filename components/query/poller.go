@@ -2,6 +2,7 @@ package query
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"time"
 
@@ -39,15 +40,24 @@ type Poller interface {
 	// All returns all results.
 	// Useful for constructing the events.
 	All(since time.Time) ([]Item, error)
+
+	// SetGetFunc replaces the function to query the data source,
+	// effective from the next poll (e.g., the component config is reloaded).
+	SetGetFunc(getFunc GetFunc)
+
+	// Refresh triggers an out-of-band poll and waits for its result,
+	// which is also processed as the last result.
+	// It shares the in-flight poll if any, rather than calling "GetFunc" twice.
+	Refresh(ctx context.Context) (*Item, error)
 }
 
 // Item is the basic unit of data that poller returns.
-// If enabled, each result is persisted in the storage.
+// If enabled with the decode func, each result is persisted in the storage.
 type Item struct {
 	Time metav1.Time `json:"time"`
 
 	// Generic component output.
-	// Either Output or OutputEncoded should be set.
+	// Persisted in JSON, and decoded with the "DecodeFunc" on restart.
 	Output any `json:"output,omitempty"`
 
 	Error error `json:"error,omitempty"`
@@ -57,12 +67,16 @@ type Item struct {
 // Each get output is persisted to the storage if enabled.
 type GetFunc func(context.Context) (any, error)
 
-func New(id string, cfg query_config.Config, getFunc GetFunc) Poller {
+func New(id string, cfg query_config.Config, getFunc GetFunc, opts ...OpOption) Poller {
+	op := &Op{}
+	op.applyOpts(opts)
+
 	return &poller{
 		id:                 id,
 		tableName:          GetTableName(id),
 		startPollFunc:      startPoll,
 		getFunc:            getFunc,
+		decodeFunc:         op.decodeFunc,
 		cfg:                cfg,
 		inflightComponents: make(map[string]any),
 	}
@@ -75,7 +89,7 @@ type poller struct {
 	tableName string
 
 	startPollFunc startPollFunc
-	getFunc       GetFunc
+	decodeFunc    DecodeFunc
 
 	ctxMu  sync.RWMutex
 	ctx    context.Context
@@ -87,6 +101,11 @@ type poller struct {
 	lastItemsMu sync.RWMutex
 	lastItems   []Item
 
+	// the in-flight "GetFunc" call shared by the poll loop and "Refresh"
+	callMu  sync.Mutex
+	call    *getCall
+	getFunc GetFunc
+
 	inflightComponents map[string]any
 }
 
@@ -179,13 +198,16 @@ func (pl *poller) Start(ctx context.Context, cfg query_config.Config, componentN
 	pl.cfg = cfg
 
 	pl.inflightComponents[componentName] = struct{}{}
+	registerPoller(componentName, pl)
 	started := pl.ctx != nil
 	if started {
 		return
 	}
 
 	pl.ctx, pl.cancel = context.WithCancel(ctx)
-	ch := pl.startPollFunc(pl.ctx, pl.id, cfg.Interval.Duration, pl.getFunc)
+	pl.loadState(pl.ctx)
+
+	ch := pl.startPollFunc(pl.ctx, pl.id, cfg.Interval.Duration, pl.pollGet)
 	go func() {
 		for item := range ch {
 			pl.processItem(item)
@@ -200,6 +222,7 @@ func (pl *poller) Stop(componentName string) bool {
 	defer pl.ctxMu.Unlock()
 
 	log.Logger.Debugw("stopping the underlying poller", "componentName", componentName)
+	deregisterPoller(componentName, pl)
 
 	stopped := pl.ctx == nil
 	if stopped {
@@ -226,8 +249,62 @@ func (pl *poller) Stop(componentName string) bool {
 	return true
 }
 
+// loadState creates the table to persist the results,
+// and warm-starts the last results from the storage (e.g., after restart).
+func (pl *poller) loadState(ctx context.Context) {
+	cfg := pl.Config()
+	if cfg.State == nil || cfg.State.DB == nil {
+		return
+	}
+	// the persisted output cannot be typed without the decode func,
+	// thus not persisted either
+	if pl.decodeFunc == nil {
+		return
+	}
+
+	if err := createTable(ctx, cfg.State.DB, pl.tableName); err != nil {
+		log.Logger.Warnw("failed to create poller table", "id", pl.id, "error", err)
+		return
+	}
+
+	pl.lastItemsMu.Lock()
+	defer pl.lastItemsMu.Unlock()
+	if len(pl.lastItems) > 0 {
+		return
+	}
+
+	since := time.Now().Add(-cfg.State.Retention.Duration)
+	items, err := readItems(ctx, cfg.State.DB, pl.tableName, since, cfg.QueueSize, pl.decodeFunc)
+	if err != nil {
+		log.Logger.Warnw("failed to read poller results", "id", pl.id, "error", err)
+		return
+	}
+	pl.lastItems = items
+	log.Logger.Debugw("loaded poller results", "id", pl.id, "items", len(items))
+}
+
+// persistItem persists the item and purges the results older than the retention.
+// The item is not persisted without the decode func, since it cannot be warm-started.
+func (pl *poller) persistItem(ctx context.Context, item Item) {
+	cfg := pl.Config()
+	if cfg.State == nil || cfg.State.DB == nil || pl.decodeFunc == nil {
+		return
+	}
+
+	if err := insertItem(ctx, cfg.State.DB, pl.tableName, item); err != nil {
+		log.Logger.Warnw("failed to persist poller result", "id", pl.id, "error", err)
+		return
+	}
+	if cfg.State.Retention.Duration > 0 {
+		if _, err := purgeItems(ctx, cfg.State.DB, pl.tableName, time.Now().Add(-cfg.State.Retention.Duration)); err != nil {
+			log.Logger.Warnw("failed to purge poller results", "id", pl.id, "error", err)
+		}
+	}
+}
+
 func (pl *poller) processItem(item Item) {
 	pl.ctxMu.RLock()
+	ctx := pl.ctx
 	canceled := pl.ctx == nil
 	pl.ctxMu.RUnlock()
 
@@ -239,12 +316,21 @@ func (pl *poller) processItem(item Item) {
 	queueN := pl.Config().QueueSize
 
 	pl.lastItemsMu.Lock()
-	defer pl.lastItemsMu.Unlock()
-
 	if queueN > 0 && len(pl.lastItems) >= queueN {
 		pl.lastItems = pl.lastItems[1:]
 	}
 	pl.lastItems = append(pl.lastItems, item)
+	pl.lastItemsMu.Unlock()
+
+	pl.persistItem(ctx, item)
+
+	pl.ctxMu.RLock()
+	names := make([]string, 0, len(pl.inflightComponents))
+	for name := range pl.inflightComponents {
+		names = append(names, name)
+	}
+	pl.ctxMu.RUnlock()
+	notifyResult(names)
 }
 
 func (pl *poller) Last() (*Item, error) {
@@ -262,8 +348,7 @@ func (pl *poller) All(since time.Time) ([]Item, error) {
 	pl.lastItemsMu.RLock()
 	defer pl.lastItemsMu.RUnlock()
 
-	// nothing in memory (e.g., process restart)
-	// we removed db support to simplify the code
+	// nothing in memory (e.g., process restart without the decode func)
 	if len(pl.lastItems) == 0 {
 		return nil, nil
 	}
@@ -277,3 +362,84 @@ func (pl *poller) All(since time.Time) ([]Item, error) {
 	}
 	return items, nil
 }
+
+func (pl *poller) SetGetFunc(getFunc GetFunc) {
+	pl.callMu.Lock()
+	defer pl.callMu.Unlock()
+	pl.getFunc = getFunc
+}
+
+type getCall struct {
+	done   chan struct{}
+	time   time.Time
+	output any
+	err    error
+}
+
+// startCall returns the in-flight "GetFunc" call, or starts a new one with the context.
+// It returns true if the call is newly started.
+// If "process" is true, the newly started call result is processed as the last result.
+func (pl *poller) startCall(ctx context.Context, process bool) (*getCall, bool) {
+	pl.callMu.Lock()
+	defer pl.callMu.Unlock()
+
+	if pl.call != nil {
+		return pl.call, false
+	}
+
+	c := &getCall{done: make(chan struct{})}
+	pl.call = c
+	get := pl.getFunc
+	go func() {
+		c.output, c.err = get(ctx)
+		c.time = time.Now().UTC()
+
+		// process before closing "done", so that the callers waiting on
+		// the call read the refreshed states, not the previous ones
+		if process && (c.output != nil || c.err != nil) {
+			pl.processItem(Item{Time: metav1.Time{Time: c.time}, Output: c.output, Error: c.err})
+		}
+
+		pl.callMu.Lock()
+		pl.call = nil
+		pl.callMu.Unlock()
+		close(c.done)
+	}()
+	return c, true
+}
+
+// pollGet is the "GetFunc" of the poll loop.
+// If the loop joins the in-flight "Refresh" call, it returns nil
+// to skip the result, which is already processed by the refresh.
+func (pl *poller) pollGet(ctx context.Context) (any, error) {
+	c, started := pl.startCall(ctx, false)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.done:
+	}
+	if !started {
+		return nil, nil
+	}
+	return c.output, c.err
+}
+
+func (pl *poller) Refresh(ctx context.Context) (*Item, error) {
+	pl.ctxMu.RLock()
+	pctx := pl.ctx
+	pl.ctxMu.RUnlock()
+
+	if pctx == nil {
+		return nil, errors.New("poller not started")
+	}
+
+	// run with the poller context, so that the caller timeout
+	// does not cancel the call shared with the poll loop
+	c, _ := pl.startCall(pctx, true)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.done:
+	}
+	return &Item{Time: metav1.Time{Time: c.time}, Output: c.output, Error: c.err}, nil
+}
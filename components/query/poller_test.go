@@ -2,11 +2,16 @@ package query
 
 import (
 	"context"
+	"errors"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	query_config "github.com/leptonai/gpud/components/query/config"
+	"github.com/leptonai/gpud/components/state"
+	"github.com/leptonai/gpud/errdefs"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -161,3 +166,286 @@ func TestPollerStartStop(t *testing.T) {
 		t.Errorf("expected startFunc to be called 1 time, got %d", startFuncCalled)
 	}
 }
+
+func TestPollerWarmStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := state.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	type output struct {
+		Value int `json:"value"`
+	}
+
+	ch := make(chan Item)
+	newPoller := func() *poller {
+		pl := New("test-warm-start", query_config.Config{}, nil, WithDecodeFunc(DecodeJSON[output])).(*poller)
+		pl.startPollFunc = func(context.Context, string, time.Duration, GetFunc) <-chan Item {
+			return ch
+		}
+		return pl
+	}
+	cfg := query_config.Config{
+		QueueSize: 2,
+		State: &query_config.State{
+			DB:        db,
+			Retention: metav1.Duration{Duration: time.Hour},
+		},
+	}
+
+	pl := newPoller()
+	pl.Start(ctx, cfg, "test")
+	now := time.Now().UTC()
+	pl.processItem(Item{Time: metav1.NewTime(now.Add(-2 * time.Hour)), Output: &output{Value: 1}})
+	pl.processItem(Item{Time: metav1.NewTime(now.Add(-2 * time.Second)), Output: &output{Value: 2}})
+	pl.processItem(Item{Time: metav1.NewTime(now.Add(-time.Second)), Error: errors.New("failed")})
+	pl.processItem(Item{Time: metav1.NewTime(now), Output: &output{Value: 3}})
+	pl.Stop("test")
+
+	// restart
+	pl = newPoller()
+	if last, err := pl.Last(); last != nil || err != nil {
+		t.Fatalf("expected no item before start, got %v, %v", last, err)
+	}
+	pl.Start(ctx, cfg, "test")
+	defer pl.Stop("test")
+
+	items, err := pl.All(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// up to the queue size, the first one is purged by the retention
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(items))
+	}
+	if items[0].Error == nil || items[0].Error.Error() != "failed" {
+		t.Fatalf("unexpected error %v", items[0].Error)
+	}
+	o, ok := items[1].Output.(*output)
+	if !ok || o.Value != 3 {
+		t.Fatalf("unexpected output %+v", items[1].Output)
+	}
+}
+
+func TestPollerNoPersistWithoutDecodeFunc(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := state.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ch := make(chan Item)
+	pl := New("test-no-decode", query_config.Config{}, nil).(*poller)
+	pl.startPollFunc = func(context.Context, string, time.Duration, GetFunc) <-chan Item {
+		return ch
+	}
+	pl.Start(ctx, query_config.Config{
+		QueueSize: 2,
+		State: &query_config.State{
+			DB:        db,
+			Retention: metav1.Duration{Duration: time.Hour},
+		},
+	}, "test")
+	defer pl.Stop("test")
+	pl.processItem(Item{Time: metav1.Now(), Output: "ok"})
+
+	var cnt int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name=?", pl.tableName).Scan(&cnt); err != nil {
+		t.Fatal(err)
+	}
+	if cnt != 0 {
+		t.Fatalf("expected no table %q without the decode func", pl.tableName)
+	}
+	if last, err := pl.Last(); err != nil || last == nil || last.Output != "ok" {
+		t.Fatalf("unexpected last item %v, %v", last, err)
+	}
+}
+
+func TestPollerRefresh(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	get := func(ctx context.Context) (any, error) {
+		n := calls.Add(1)
+		<-release
+		return int(n), nil
+	}
+
+	pl := New("test-refresh", query_config.Config{}, get).(*poller)
+	pl.startPollFunc = func(context.Context, string, time.Duration, GetFunc) <-chan Item {
+		return make(chan Item)
+	}
+
+	if _, err := pl.Refresh(ctx); err == nil {
+		t.Fatal("expected error before start")
+	}
+	if err := Refresh(ctx, "test"); !errdefs.IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	pl.Start(ctx, query_config.Config{QueueSize: 3}, "test")
+	defer pl.Stop("test")
+
+	var wg sync.WaitGroup
+	results := make([]*Item, 2)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			item, err := pl.Refresh(ctx)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			results[i] = item
+		}()
+	}
+	// wait for both refreshes to join the call
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Fatalf("expected 1 call, got %d", calls.Load())
+	}
+	for _, item := range results {
+		if item == nil || item.Output != 1 {
+			t.Fatalf("unexpected item %+v", item)
+		}
+	}
+
+	// the result is processed once as the last result, before the refresh returns
+	items, err := pl.All(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Output != 1 {
+		t.Fatalf("unexpected items %+v", items)
+	}
+
+	// refreshed by the component name
+	if err := Refresh(ctx, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls.Load())
+	}
+	if last, err := pl.Last(); err != nil || last == nil || last.Output != 2 {
+		t.Fatalf("expected the refreshed item, got %+v, %v", last, err)
+	}
+
+	// the call is not canceled by the caller timeout
+	tctx, tcancel := context.WithTimeout(ctx, time.Millisecond)
+	defer tcancel()
+	release = make(chan struct{})
+	if _, err := pl.Refresh(tctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	close(release)
+}
+
+func TestPollerSetGetFunc(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pl := New("test-set-get-func", query_config.Config{}, func(context.Context) (any, error) {
+		return "old", nil
+	}).(*poller)
+	pl.startPollFunc = func(context.Context, string, time.Duration, GetFunc) <-chan Item {
+		return make(chan Item)
+	}
+	pl.Start(ctx, query_config.Config{QueueSize: 3}, "test")
+	defer pl.Stop("test")
+
+	item, err := pl.Refresh(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Output != "old" {
+		t.Fatalf("expected old output, got %v", item.Output)
+	}
+
+	// e.g., the component config is reloaded
+	pl.SetGetFunc(func(context.Context) (any, error) {
+		return "new", nil
+	})
+	item, err = pl.Refresh(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Output != "new" {
+		t.Fatalf("expected new output, got %v", item.Output)
+	}
+}
+
+func TestSubscribeResults(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pl := New("test-subscribe-results", query_config.Config{}, func(context.Context) (any, error) {
+		return "ok", nil
+	}).(*poller)
+	pl.startPollFunc = func(context.Context, string, time.Duration, GetFunc) <-chan Item {
+		return make(chan Item)
+	}
+	pl.Start(ctx, query_config.Config{QueueSize: 3}, "test-subscriber")
+	defer pl.Stop("test-subscriber")
+
+	notified := make(chan string, 1)
+	unsubscribe := SubscribeResults(func(componentName string) {
+		// not to block the pollers of the other tests
+		if componentName != "test-subscriber" {
+			return
+		}
+		select {
+		case notified <- componentName:
+		default:
+		}
+	})
+	pl.processItem(Item{Output: "ok"})
+	if name := <-notified; name != "test-subscriber" {
+		t.Fatalf("expected test-subscriber, got %q", name)
+	}
+
+	unsubscribe()
+	pl.processItem(Item{Output: "ok"})
+	select {
+	case name := <-notified:
+		t.Fatalf("unexpected notification after unsubscribe %q", name)
+	default:
+	}
+}
+
+func TestSharedWith(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pl := New("test-shared", query_config.Config{}, nil).(*poller)
+	pl.startPollFunc = func(context.Context, string, time.Duration, GetFunc) <-chan Item {
+		return make(chan Item)
+	}
+	pl.Start(ctx, query_config.Config{}, "test-shared-1")
+	if names := SharedWith("test-shared-1"); len(names) != 0 {
+		t.Fatalf("expected no shared component, got %v", names)
+	}
+
+	pl.Start(ctx, query_config.Config{}, "test-shared-2")
+	if names := SharedWith("test-shared-1"); !reflect.DeepEqual(names, []string{"test-shared-2"}) {
+		t.Fatalf("unexpected shared components %v", names)
+	}
+
+	pl.Stop("test-shared-2")
+	if names := SharedWith("test-shared-1"); len(names) != 0 {
+		t.Fatalf("expected no shared component, got %v", names)
+	}
+	pl.Stop("test-shared-1")
+}
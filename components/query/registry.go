@@ -0,0 +1,123 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/leptonai/gpud/errdefs"
+)
+
+var (
+	pollersMu sync.RWMutex
+	// maps the component name to the pollers started by the component
+	pollers = make(map[string]map[string]Poller)
+)
+
+func registerPoller(componentName string, pl Poller) {
+	pollersMu.Lock()
+	defer pollersMu.Unlock()
+
+	if _, ok := pollers[componentName]; !ok {
+		pollers[componentName] = make(map[string]Poller)
+	}
+	pollers[componentName][pl.ID()] = pl
+}
+
+func deregisterPoller(componentName string, pl Poller) {
+	pollersMu.Lock()
+	defer pollersMu.Unlock()
+
+	delete(pollers[componentName], pl.ID())
+	if len(pollers[componentName]) == 0 {
+		delete(pollers, componentName)
+	}
+}
+
+// GetPollers returns the running pollers started by the component.
+func GetPollers(componentName string) []Poller {
+	pollersMu.RLock()
+	defer pollersMu.RUnlock()
+
+	pls := make([]Poller, 0, len(pollers[componentName]))
+	for _, pl := range pollers[componentName] {
+		pls = append(pls, pl)
+	}
+	return pls
+}
+
+// SharedWith returns the sorted names of the other components
+// running any of the pollers started by the component (e.g., the shared NVIDIA poller).
+// The shared poller keeps running with the config it was first started with,
+// until all the components stop it.
+func SharedWith(componentName string) []string {
+	pollersMu.RLock()
+	defer pollersMu.RUnlock()
+
+	names := make([]string, 0)
+	for name, pls := range pollers {
+		if name == componentName {
+			continue
+		}
+		for id := range pollers[componentName] {
+			if _, ok := pls[id]; ok {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Refresh triggers the out-of-band poll of all the pollers started by the component,
+// and waits for the results until the context is done.
+// It returns "errdefs.ErrNotFound" if the component has no running poller.
+func Refresh(ctx context.Context, componentName string) error {
+	pls := GetPollers(componentName)
+	if len(pls) == 0 {
+		return fmt.Errorf("no poller for component %s: %w", componentName, errdefs.ErrNotFound)
+	}
+	for _, pl := range pls {
+		if _, err := pl.Refresh(ctx); err != nil {
+			return fmt.Errorf("failed to refresh poller %s: %w", pl.ID(), err)
+		}
+	}
+	return nil
+}
+
+var (
+	resultSubsMu  sync.RWMutex
+	resultSubs    = make(map[int]func(componentName string))
+	nextResultSub int
+)
+
+// SubscribeResults calls the function with the component name
+// whenever a poller started by the component processes a new result
+// (e.g., to persist the component events as soon as polled),
+// until the returned function is called.
+// The function must not block, since it is called in the poll loop.
+func SubscribeResults(fn func(componentName string)) (unsubscribe func()) {
+	resultSubsMu.Lock()
+	id := nextResultSub
+	nextResultSub++
+	resultSubs[id] = fn
+	resultSubsMu.Unlock()
+
+	return func() {
+		resultSubsMu.Lock()
+		delete(resultSubs, id)
+		resultSubsMu.Unlock()
+	}
+}
+
+func notifyResult(componentNames []string) {
+	resultSubsMu.RLock()
+	defer resultSubsMu.RUnlock()
+	for _, fn := range resultSubs {
+		for _, name := range componentNames {
+			fn(name)
+		}
+	}
+}
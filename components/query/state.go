@@ -0,0 +1,134 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ColumnID          = "id"
+	ColumnUnixSeconds = "unix_seconds"
+	ColumnOutput      = "output"
+	ColumnError       = "error"
+)
+
+// DecodeFunc decodes the JSON-encoded output persisted in the storage
+// into the same type as the "GetFunc" output (e.g., "*Output").
+type DecodeFunc func([]byte) (any, error)
+
+// DecodeJSON is the "DecodeFunc" that decodes the output into "*T"
+// (e.g., "query.WithDecodeFunc(query.DecodeJSON[Output])").
+func DecodeJSON[T any](b []byte) (any, error) {
+	v := new(T)
+	if err := json.Unmarshal(b, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func createTable(ctx context.Context, db *sql.DB, tableName string) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER PRIMARY KEY AUTOINCREMENT,
+	%s INTEGER NOT NULL,
+	%s TEXT,
+	%s TEXT
+);`, tableName, ColumnID, ColumnUnixSeconds, ColumnOutput, ColumnError))
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, fmt.Sprintf(`
+CREATE INDEX IF NOT EXISTS idx_%s_%s ON %s(%s);`,
+		tableName, ColumnUnixSeconds, tableName, ColumnUnixSeconds,
+	))
+	return err
+}
+
+// insertItem persists the item with the output encoded in JSON.
+func insertItem(ctx context.Context, db *sql.DB, tableName string, item Item) error {
+	var output, errMsg sql.NullString
+	if item.Output != nil {
+		b, err := json.Marshal(item.Output)
+		if err != nil {
+			return err
+		}
+		output = sql.NullString{String: string(b), Valid: true}
+	}
+	if item.Error != nil {
+		errMsg = sql.NullString{String: item.Error.Error(), Valid: true}
+	}
+
+	query := fmt.Sprintf(`
+INSERT INTO %s (%s, %s, %s) VALUES (?, ?, ?);
+`, tableName, ColumnUnixSeconds, ColumnOutput, ColumnError)
+	_, err := db.ExecContext(ctx, query, item.Time.Unix(), output, errMsg)
+	return err
+}
+
+// readItems returns the latest items persisted since the given time,
+// in the insertion order, up to the limit (no limit if zero).
+func readItems(ctx context.Context, db *sql.DB, tableName string, since time.Time, limit int, decode DecodeFunc) ([]Item, error) {
+	query := fmt.Sprintf(`
+SELECT %s, %s, %s FROM %s WHERE %s >= ? ORDER BY %s DESC`,
+		ColumnUnixSeconds, ColumnOutput, ColumnError, tableName, ColumnUnixSeconds, ColumnID)
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	query += ";"
+
+	rows, err := db.QueryContext(ctx, query, since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]Item, 0)
+	for rows.Next() {
+		var unixSeconds int64
+		var output, errMsg sql.NullString
+		if err := rows.Scan(&unixSeconds, &output, &errMsg); err != nil {
+			return nil, err
+		}
+
+		item := Item{Time: metav1.Time{Time: time.Unix(unixSeconds, 0).UTC()}}
+		if output.Valid {
+			item.Output, err = decode([]byte(output.String))
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode output: %w", err)
+			}
+		}
+		if errMsg.Valid {
+			item.Error = errors.New(errMsg.String)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// in the insertion order
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+	return items, nil
+}
+
+// purgeItems deletes the items older than the given time.
+func purgeItems(ctx context.Context, db *sql.DB, tableName string, before time.Time) (int, error) {
+	rs, err := db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s < ?;`, tableName, ColumnUnixSeconds), before.Unix())
+	if err != nil {
+		return 0, err
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
@@ -16,9 +16,15 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// ConvertToTableName converts the name (e.g., the component name) into the table name,
+// replacing the characters other than [A-Za-z0-9_] (e.g., "-", ".", spaces, or quotes) with "_".
 func ConvertToTableName(name string) string {
-	// sqlite doesn't allow "-" in the table name
-	return strings.ReplaceAll(name, "-", "_")
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
 }
 
 func Open(file string) (*sql.DB, error) {
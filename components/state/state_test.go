@@ -32,3 +32,22 @@ func TestOpenMemory(t *testing.T) {
 	}
 	t.Log(id)
 }
+
+func TestConvertToTableName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"accelerator-nvidia-xid", "accelerator_nvidia_xid"},
+		{"custom.check name", "custom_check_name"},
+		{`a"; DROP TABLE x; --`, "a___DROP_TABLE_x____"},
+		{"Valid_Name_01", "Valid_Name_01"},
+	}
+	for _, tt := range tests {
+		if got := ConvertToTableName(tt.name); got != tt.want {
+			t.Errorf("ConvertToTableName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
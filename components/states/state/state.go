@@ -0,0 +1,230 @@
+// Package state persists the component health transitions in the state database,
+// so that the state changes can be replayed after the gpud restarts.
+package state
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const DefaultTableName = "components_states"
+
+const (
+	// ColumnID is monotonically increasing with the insertion order,
+	// and never reused even after the old rows are purged.
+	ColumnID = "id"
+
+	ColumnComponent   = "component"
+	ColumnUnixSeconds = "unix_seconds"
+	ColumnHealth      = "health"
+	ColumnStates      = "states"
+)
+
+func CreateTable(ctx context.Context, db *sql.DB, tableName string) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER PRIMARY KEY AUTOINCREMENT,
+	%s TEXT NOT NULL,
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT
+);`,
+		tableName,
+		ColumnID, ColumnComponent, ColumnUnixSeconds, ColumnHealth, ColumnStates,
+	))
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, fmt.Sprintf(`
+CREATE INDEX IF NOT EXISTS idx_%s_%s_%s ON %s(%s, %s);`,
+		tableName, ColumnComponent, ColumnUnixSeconds,
+		tableName, ColumnComponent, ColumnUnixSeconds,
+	))
+	return err
+}
+
+// Record is the persisted state change of a component.
+type Record struct {
+	ID        int64                   `json:"id"`
+	Component string                  `json:"component"`
+	Health    components.HealthStatus `json:"health"`
+	States    []components.State      `json:"states,omitempty"`
+}
+
+// Insert persists the health and the states of the component.
+func Insert(ctx context.Context, db *sql.DB, tableName string, componentName string, health components.HealthStatus, states []components.State) error {
+	hb, err := json.Marshal(health)
+	if err != nil {
+		return err
+	}
+	sb, err := json.Marshal(states)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`
+INSERT INTO %s (%s, %s, %s, %s) VALUES (?, ?, ?, ?);
+`,
+		tableName,
+		ColumnComponent,
+		ColumnUnixSeconds,
+		ColumnHealth,
+		ColumnStates,
+	)
+	_, err = db.ExecContext(ctx, query, componentName, health.Time.Unix(), string(hb), string(sb))
+	return err
+}
+
+type Op struct {
+	components []string
+	limit      int
+}
+
+type OpOption func(*Op)
+
+func (op *Op) applyOpts(opts []OpOption) {
+	for _, opt := range opts {
+		opt(op)
+	}
+}
+
+// WithComponents only reads the records of the given components.
+func WithComponents(names ...string) OpOption {
+	return func(op *Op) {
+		op.components = append(op.components, names...)
+	}
+}
+
+// WithLimit limits the number of records to read.
+func WithLimit(limit int) OpOption {
+	return func(op *Op) {
+		op.limit = limit
+	}
+}
+
+// ReadLast returns the last persisted record of the component.
+// Returns nil if no record is found.
+func ReadLast(ctx context.Context, db *sql.DB, tableName string, componentName string) (*Record, error) {
+	query := fmt.Sprintf(`
+SELECT %s, %s, %s, %s
+FROM %s
+WHERE %s = ?
+ORDER BY %s DESC
+LIMIT 1;
+`,
+		ColumnID, ColumnComponent, ColumnHealth, ColumnStates,
+		tableName,
+		ColumnComponent,
+		ColumnID,
+	)
+	records, err := readRecords(ctx, db, query, componentName)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return &records[0], nil
+}
+
+// ReadAfter returns the records inserted after the given id,
+// sorted by the id (insertion order) in ascending order.
+// Useful to resume reading the state changes from the last read.
+// Returns nil if no record is found.
+func ReadAfter(ctx context.Context, db *sql.DB, tableName string, afterID int64, opts ...OpOption) ([]Record, error) {
+	op := &Op{}
+	op.applyOpts(opts)
+
+	conds := []string{fmt.Sprintf("%s > ?", ColumnID)}
+	args := []any{afterID}
+	if len(op.components) > 0 {
+		conds = append(conds, fmt.Sprintf("%s IN (?%s)", ColumnComponent, strings.Repeat(", ?", len(op.components)-1)))
+		for _, name := range op.components {
+			args = append(args, name)
+		}
+	}
+
+	query := fmt.Sprintf(`
+SELECT %s, %s, %s, %s
+FROM %s
+WHERE %s
+ORDER BY %s ASC`,
+		ColumnID, ColumnComponent, ColumnHealth, ColumnStates,
+		tableName,
+		strings.Join(conds, " AND "),
+		ColumnID,
+	)
+	if op.limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", op.limit)
+	}
+	query += ";"
+
+	return readRecords(ctx, db, query, args...)
+}
+
+// LastID returns the id of the last inserted record.
+// Returns 0 if no record is found.
+func LastID(ctx context.Context, db *sql.DB, tableName string) (int64, error) {
+	query := fmt.Sprintf(`SELECT COALESCE(MAX(%s), 0) FROM %s;`, ColumnID, tableName)
+	var id int64
+	if err := db.QueryRowContext(ctx, query).Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func Purge(ctx context.Context, db *sql.DB, tableName string, before time.Time) (int, error) {
+	query := fmt.Sprintf(`
+DELETE FROM %s WHERE %s < ?;`, tableName, ColumnUnixSeconds)
+	rs, err := db.ExecContext(ctx, query, before.Unix())
+	if err != nil {
+		return 0, err
+	}
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
+
+func readRecords(ctx context.Context, db *sql.DB, query string, args ...any) ([]Record, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []Record
+	for rows.Next() {
+		var (
+			r      Record
+			health string
+			states sql.NullString
+		)
+		if err := rows.Scan(&r.ID, &r.Component, &health, &states); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(health), &r.Health); err != nil {
+			return nil, err
+		}
+		if states.Valid && states.String != "" {
+			if err := json.Unmarshal([]byte(states.String), &r.States); err != nil {
+				return nil, err
+			}
+		}
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,88 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/state"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestState(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := state.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	tableName := "test_states"
+	if err := CreateTable(ctx, db, tableName); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	if r, err := ReadLast(ctx, db, tableName, "xid"); r != nil || err != nil {
+		t.Fatalf("expected no record + no error, got %v, %v", r, err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	healths := []components.HealthStatus{
+		{Healthy: true, Severity: components.SeverityInfo, Time: metav1.Time{Time: now.Add(-2 * time.Minute)}},
+		{Healthy: false, Severity: components.SeverityFatal, Reason: "xid 79", Time: metav1.Time{Time: now.Add(-time.Minute)}},
+	}
+	for _, h := range healths {
+		if err := Insert(ctx, db, tableName, "xid", h, []components.State{{Name: "xid", Healthy: h.Healthy}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Insert(ctx, db, tableName, "ecc", healths[0], nil); err != nil {
+		t.Fatal(err)
+	}
+
+	last, err := ReadLast(ctx, db, tableName, "xid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last == nil || last.Health.Healthy || last.Health.Reason != "xid 79" || len(last.States) != 1 {
+		t.Fatalf("unexpected last record: %+v", last)
+	}
+
+	records, err := ReadAfter(ctx, db, tableName, 0, WithComponents("ecc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Component != "ecc" {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+
+	records, err = ReadAfter(ctx, db, tableName, 1, WithLimit(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].ID != last.ID {
+		t.Fatalf("unexpected records after id: %+v", records)
+	}
+
+	lastID, err := LastID(ctx, db, tableName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastID != 3 {
+		t.Fatalf("expected last id 3, got %d", lastID)
+	}
+
+	purged, err := Purge(ctx, db, tableName, now.Add(-90*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 2 {
+		t.Fatalf("expected 2 purged, got %d", purged)
+	}
+}
@@ -0,0 +1,52 @@
+package systemd
+
+import (
+	"context"
+	"database/sql"
+	"runtime"
+
+	"github.com/leptonai/gpud/components"
+	query_config "github.com/leptonai/gpud/components/query/config"
+	"github.com/leptonai/gpud/log"
+	pkd_systemd "github.com/leptonai/gpud/pkg/systemd"
+	"github.com/leptonai/gpud/systemd"
+)
+
+func init() {
+	components.RegisterFactory(components.Factory{
+		Name: Name,
+		DefaultConfig: func() any {
+			if err := systemd.CreateDefaultEnvFile(); err != nil {
+				log.Logger.Debugw("failed to create default systemd env file", "error", err)
+			}
+
+			cfg := DefaultConfig()
+
+			if active, err := pkd_systemd.IsActive("kubelet"); err == nil && active {
+				cfg.Units = append(cfg.Units, "kubelet")
+			}
+
+			if active, err := pkd_systemd.IsActive("docker"); err == nil && active {
+				cfg.Units = append(cfg.Units, "docker")
+			}
+
+			if active, err := pkd_systemd.IsActive("tailscaled"); err == nil && active {
+				cfg.Units = append(cfg.Units, "tailscaled")
+			}
+
+			return cfg
+		},
+		AutoDetect: func(ctx context.Context) bool {
+			return runtime.GOOS == "linux" && pkd_systemd.SystemdExists() && pkd_systemd.SystemctlExists()
+		},
+		ParseConfig: func(raw any, db *sql.DB) (any, error) {
+			if raw == nil {
+				return &Config{Query: query_config.Config{State: &query_config.State{DB: db}}}, nil
+			}
+			return ParseConfig(raw, db)
+		},
+		New: func(ctx context.Context, cfg any, _ components.FactoryOptions) (components.Component, error) {
+			return New(ctx, *cfg.(*Config))
+		},
+	})
+}
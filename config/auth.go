@@ -0,0 +1,82 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+const (
+	// RoleReadOnly can only query the states, events, metrics, and info.
+	RoleReadOnly = "read-only"
+	// RoleAdmin can also access the admin endpoints and the mutating endpoints.
+	RoleAdmin = "admin"
+
+	DefaultUnixSocketMode = "0660"
+)
+
+// Configures the authentication and authorization of the local HTTP API.
+// If nil, the API is served to anyone who can reach the address.
+type Auth struct {
+	// TokensFile is the file of the static bearer tokens, one per line
+	// in the format of "<token> [role]", where the role is either
+	// "read-only" (default) or "admin". The lines starting with "#" are ignored.
+	TokensFile string `json:"tokens_file,omitempty"`
+
+	// ClientCAFile is the PEM-encoded CA bundle to verify the client certificates (mTLS).
+	// The clients with the verified certificates are granted the read-only role,
+	// unless the certificate common name is listed in "admin_common_names".
+	ClientCAFile     string   `json:"client_ca_file,omitempty"`
+	AdminCommonNames []string `json:"admin_common_names,omitempty"`
+
+	// UnixSocket is the path of the Unix-domain socket to additionally serve the API on.
+	// The access is restricted by the socket file permissions, thus the clients
+	// over the socket are not required to authenticate.
+	UnixSocket string `json:"unix_socket,omitempty"`
+	// UnixSocketMode is the octal file mode of the socket (default "0660").
+	UnixSocketMode string `json:"unix_socket_mode,omitempty"`
+	// UnixSocketRole is the role granted to the clients over the socket (default "admin").
+	UnixSocketRole string `json:"unix_socket_role,omitempty"`
+}
+
+func (a *Auth) Validate() error {
+	if a.TokensFile == "" && a.ClientCAFile == "" && a.UnixSocket == "" {
+		return errors.New("at least one of tokens_file, client_ca_file, or unix_socket is required")
+	}
+	if len(a.AdminCommonNames) > 0 && a.ClientCAFile == "" {
+		return errors.New("admin_common_names requires client_ca_file")
+	}
+	if a.UnixSocketMode != "" {
+		if _, err := a.ParseUnixSocketMode(); err != nil {
+			return err
+		}
+	}
+	if a.UnixSocketRole != "" && !IsValidRole(a.UnixSocketRole) {
+		return fmt.Errorf("invalid unix_socket_role %q", a.UnixSocketRole)
+	}
+	return nil
+}
+
+func (a *Auth) SetDefaultsIfNotSet() {
+	if a.UnixSocket != "" {
+		if a.UnixSocketMode == "" {
+			a.UnixSocketMode = DefaultUnixSocketMode
+		}
+		if a.UnixSocketRole == "" {
+			a.UnixSocketRole = RoleAdmin
+		}
+	}
+}
+
+// ParseUnixSocketMode parses the octal socket file mode.
+func (a *Auth) ParseUnixSocketMode() (uint32, error) {
+	mode, err := strconv.ParseUint(a.UnixSocketMode, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid unix_socket_mode %q: %w", a.UnixSocketMode, err)
+	}
+	return uint32(mode), nil
+}
+
+func IsValidRole(role string) bool {
+	return role == RoleReadOnly || role == RoleAdmin
+}
@@ -24,6 +24,10 @@ type Config struct {
 	// Component specific configurations.
 	Components map[string]any `json:"components,omitempty"`
 
+	// Names of the components that must be healthy for the node to be ready.
+	// If any of them is unhealthy, the readiness endpoint returns 503.
+	CriticalComponents []string `json:"critical_components,omitempty"`
+
 	// State file that persists the latest status.
 	// If empty, the states are not persisted to file.
 	State string `json:"state"`
@@ -37,6 +41,30 @@ type Config struct {
 
 	// Configures the local web configuration.
 	Web *Web `json:"web,omitempty"`
+
+	// Configures the TLS certificate of the local HTTP API.
+	// If nil, a self-signed certificate is generated on every start.
+	TLS *TLS `json:"tls,omitempty"`
+
+	// Configures the authentication and authorization of the local HTTP API.
+	// If nil, the API is served without authentication.
+	Auth *Auth `json:"auth,omitempty"`
+
+	// Configures the notifier to send the state transitions and events to webhooks.
+	// If nil, no notification is sent.
+	Notifier *Notifier `json:"notifier,omitempty"`
+
+	// Configures the OpenTelemetry exporter to push the metrics and events over OTLP/HTTP.
+	// If nil, the metrics are only served at the Prometheus "/metrics" endpoint.
+	OTLP *OTLP `json:"otlp,omitempty"`
+
+	// Configures the Prometheus remote-write to push the metrics.
+	// If nil, the metrics are only served at the Prometheus "/metrics" endpoint.
+	RemoteWrite *RemoteWrite `json:"remote_write,omitempty"`
+
+	// Configures the Kubernetes node conditions and events from the component states and events.
+	// If nil, the node object is not updated.
+	K8sNode *K8sNode `json:"k8s_node,omitempty"`
 }
 
 // Configures the local web configuration.
@@ -67,6 +95,36 @@ func (config *Config) Validate() error {
 	if config.Web != nil && config.Web.SincePeriod.Duration < 10*time.Minute {
 		return fmt.Errorf("web_metrics_since_period must be at least 10 minutes, got %d", config.Web.SincePeriod.Duration)
 	}
+	if config.TLS != nil {
+		if err := config.TLS.Validate(); err != nil {
+			return fmt.Errorf("invalid tls: %w", err)
+		}
+	}
+	if config.Auth != nil {
+		if err := config.Auth.Validate(); err != nil {
+			return fmt.Errorf("invalid auth: %w", err)
+		}
+	}
+	if config.Notifier != nil {
+		if err := config.Notifier.Validate(); err != nil {
+			return fmt.Errorf("invalid notifier: %w", err)
+		}
+	}
+	if config.OTLP != nil {
+		if err := config.OTLP.Validate(); err != nil {
+			return fmt.Errorf("invalid otlp: %w", err)
+		}
+	}
+	if config.RemoteWrite != nil {
+		if err := config.RemoteWrite.Validate(); err != nil {
+			return fmt.Errorf("invalid remote_write: %w", err)
+		}
+	}
+	if config.K8sNode != nil {
+		if err := config.K8sNode.Validate(); err != nil {
+			return fmt.Errorf("invalid k8s_node: %w", err)
+		}
+	}
 	return nil
 }
 
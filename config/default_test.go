@@ -0,0 +1,32 @@
+package config
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/leptonai/gpud/components"
+)
+
+func TestAutoDetectComponents(t *testing.T) {
+	t.Parallel()
+
+	factories := []components.Factory{
+		{Name: "explicit-only"},
+		{Name: "not-detected", AutoDetect: func(context.Context) bool { return false }},
+		{Name: "detected", AutoDetect: func(context.Context) bool { return true }},
+		{
+			Name:          "detected-with-config",
+			AutoDetect:    func(context.Context) bool { return true },
+			DefaultConfig: func() any { return map[string]any{"port": 10255} },
+		},
+	}
+	got := autoDetectComponents(context.Background(), factories)
+	want := map[string]any{
+		"detected":             nil,
+		"detected-with-config": map[string]any{"port": 10255},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
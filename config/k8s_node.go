@@ -0,0 +1,116 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DefaultK8sNodeInterval       = 30 * time.Second
+	DefaultK8sNodeEventNamespace = "default"
+)
+
+// DefaultK8sNodeConditions are the node conditions to set if none is configured.
+var DefaultK8sNodeConditions = []K8sNodeCondition{
+	{
+		Type:       "GPUHealthy",
+		Components: []string{"accelerator-nvidia-*"},
+	},
+	{
+		Type:       "NVLinkHealthy",
+		Components: []string{"accelerator-nvidia-nvlink"},
+	},
+	{
+		Type:        "XidCritical",
+		Components:  []string{"accelerator-nvidia-error-xid"},
+		MinSeverity: "critical",
+		Problem:     true,
+	},
+}
+
+// Configures the Kubernetes node conditions and events
+// converted from the component states and events,
+// in the format compatible with the node-problem-detector.
+type K8sNode struct {
+	// NodeName is the name of the node object to patch.
+	// If empty, the node name is discovered from the "k8s-pod" component.
+	NodeName string `json:"node_name,omitempty"`
+	// Kubeconfig is the path to the kubeconfig file.
+	// If empty, the in-cluster config is used.
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+
+	// Interval to update the node conditions.
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// Conditions to set on the node.
+	// If empty, "DefaultK8sNodeConditions" are used.
+	Conditions []K8sNodeCondition `json:"conditions,omitempty"`
+
+	// Set true to not create the node events from the component events.
+	DisableEvents bool `json:"disable_events,omitempty"`
+	// EventTypes to create the node events for (e.g., "error", "warn").
+	// If empty, the error and warn events are created.
+	EventTypes []string `json:"event_types,omitempty"`
+	// EventNamespace is the namespace to create the node events in.
+	EventNamespace string `json:"event_namespace,omitempty"`
+}
+
+// Configures a node condition evaluated from the component states.
+type K8sNodeCondition struct {
+	// Type of the node condition (e.g., "GPUHealthy").
+	Type string `json:"type"`
+	// Components to evaluate the condition from, as the glob patterns
+	// (e.g., "accelerator-nvidia-*").
+	Components []string `json:"components"`
+	// MinSeverity is the minimum severity for an unhealthy component to affect the condition.
+	// If empty, any unhealthy component affects the condition.
+	MinSeverity string `json:"min_severity,omitempty"`
+	// Set true for the problem conditions (e.g., "XidCritical"),
+	// which are "True" if any component is unhealthy.
+	// Otherwise, the condition is "True" if all components are healthy.
+	Problem bool `json:"problem,omitempty"`
+}
+
+func (k *K8sNode) Validate() error {
+	if k.Interval.Duration < 0 {
+		return errors.New("interval must be non-negative")
+	}
+	seen := make(map[string]struct{})
+	for i, c := range k.Conditions {
+		if c.Type == "" {
+			return fmt.Errorf("condition %d: type is required", i)
+		}
+		if _, ok := seen[c.Type]; ok {
+			return fmt.Errorf("condition %d: duplicate type %q", i, c.Type)
+		}
+		seen[c.Type] = struct{}{}
+		if len(c.Components) == 0 {
+			return fmt.Errorf("condition %q: components are required", c.Type)
+		}
+		for _, p := range c.Components {
+			if _, err := path.Match(p, ""); err != nil {
+				return fmt.Errorf("condition %q: invalid component pattern %q: %w", c.Type, p, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (k *K8sNode) SetDefaultsIfNotSet() {
+	if k.Interval.Duration == 0 {
+		k.Interval.Duration = DefaultK8sNodeInterval
+	}
+	if len(k.Conditions) == 0 {
+		k.Conditions = append([]K8sNodeCondition{}, DefaultK8sNodeConditions...)
+	}
+	if len(k.EventTypes) == 0 {
+		k.EventTypes = []string{"error", "warn"}
+	}
+	if k.EventNamespace == "" {
+		k.EventNamespace = DefaultK8sNodeEventNamespace
+	}
+}
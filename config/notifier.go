@@ -0,0 +1,124 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DefaultWebhookTimeout        = 10 * time.Second
+	DefaultWebhookMaxRetries     = 3
+	DefaultWebhookInitialBackoff = time.Second
+	DefaultWebhookMaxBackoff     = 30 * time.Second
+	DefaultWebhookDedupWindow    = 5 * time.Minute
+)
+
+// Configures the notifier to send the component state transitions
+// and events to the webhooks, independent of the control plane session.
+type Notifier struct {
+	Webhooks []Webhook `json:"webhooks,omitempty"`
+}
+
+// Configures a webhook to POST the notifications to.
+type Webhook struct {
+	// Name of the webhook, used for logging.
+	// Defaults to the URL host.
+	Name string `json:"name,omitempty"`
+
+	// URL to POST the notifications to.
+	URL string `json:"url"`
+	// Headers to set in the request (e.g., "Authorization").
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Template is the Go text/template to render the request body
+	// with the notification payload (e.g., to match the Slack message format).
+	// If empty, the payload is encoded in JSON.
+	Template string `json:"template,omitempty"`
+
+	// Components to notify, if empty, all components are notified.
+	Components []string `json:"components,omitempty"`
+	// Components to not notify, applied after "components".
+	ExcludeComponents []string `json:"exclude_components,omitempty"`
+	// Event types to notify (e.g., "error", "warn").
+	// If empty, only the error events are notified.
+	EventTypes []string `json:"event_types,omitempty"`
+	// Set true to not notify the state transitions.
+	DisableStates bool `json:"disable_states,omitempty"`
+	// Set true to not notify the events.
+	DisableEvents bool `json:"disable_events,omitempty"`
+
+	// Timeout for each request.
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+	// Number of retries on the failed requests (network errors, 429, or 5xx).
+	// Defaults to 3 if not set, set 0 to disable the retries.
+	MaxRetries *int `json:"max_retries,omitempty"`
+	// Backoff before the first retry, doubled for each retry up to "max_backoff".
+	InitialBackoff metav1.Duration `json:"initial_backoff,omitempty"`
+	MaxBackoff     metav1.Duration `json:"max_backoff,omitempty"`
+
+	// The same notification (e.g., the same component state or event)
+	// is sent only once within the window.
+	// A state transition is always sent if the component transitioned
+	// to another state in between (e.g., unhealthy, healthy, then unhealthy again).
+	DedupWindow metav1.Duration `json:"dedup_window,omitempty"`
+}
+
+func (n *Notifier) Validate() error {
+	for i := range n.Webhooks {
+		if err := n.Webhooks[i].Validate(); err != nil {
+			return fmt.Errorf("webhook %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (n *Notifier) SetDefaultsIfNotSet() {
+	for i := range n.Webhooks {
+		n.Webhooks[i].SetDefaultsIfNotSet()
+	}
+}
+
+func (w *Webhook) Validate() error {
+	if w.URL == "" {
+		return errors.New("url is required")
+	}
+	u, err := url.Parse(w.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid url scheme %q (only http and https are supported)", u.Scheme)
+	}
+	if w.MaxRetries != nil && *w.MaxRetries < 0 {
+		return fmt.Errorf("max_retries must be non-negative, got %d", *w.MaxRetries)
+	}
+	return nil
+}
+
+func (w *Webhook) SetDefaultsIfNotSet() {
+	if w.Name == "" {
+		if u, err := url.Parse(w.URL); err == nil {
+			w.Name = u.Host
+		}
+	}
+	if w.Timeout.Duration == 0 {
+		w.Timeout.Duration = DefaultWebhookTimeout
+	}
+	if w.MaxRetries == nil {
+		maxRetries := DefaultWebhookMaxRetries
+		w.MaxRetries = &maxRetries
+	}
+	if w.InitialBackoff.Duration == 0 {
+		w.InitialBackoff.Duration = DefaultWebhookInitialBackoff
+	}
+	if w.MaxBackoff.Duration == 0 {
+		w.MaxBackoff.Duration = DefaultWebhookMaxBackoff
+	}
+	if w.DedupWindow.Duration == 0 {
+		w.DedupWindow.Duration = DefaultWebhookDedupWindow
+	}
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DefaultOTLPEndpoint       = "http://localhost:4318"
+	DefaultOTLPInterval       = 30 * time.Second
+	DefaultOTLPTimeout        = 10 * time.Second
+	DefaultOTLPMaxBatchSize   = 1000
+	DefaultOTLPMaxRetries     = 3
+	DefaultOTLPInitialBackoff = time.Second
+	DefaultOTLPMaxBackoff     = 30 * time.Second
+)
+
+// Configures the OpenTelemetry exporter to push the metrics and events
+// to an OTLP/HTTP receiver (e.g., the OpenTelemetry collector on the node).
+type OTLP struct {
+	// Endpoint is the base URL of the OTLP/HTTP receiver,
+	// where the signals are POSTed to "/v1/metrics" and "/v1/logs".
+	// Defaults to "http://localhost:4318".
+	Endpoint string `json:"endpoint,omitempty"`
+	// Headers to set in the request (e.g., "Authorization").
+	Headers map[string]string `json:"headers,omitempty"`
+	// Set true to gzip-compress the request body.
+	Gzip bool `json:"gzip,omitempty"`
+
+	// Interval to export the new metrics and events.
+	Interval metav1.Duration `json:"interval,omitempty"`
+	// Maximum number of the data points or log records in a single request.
+	MaxBatchSize int `json:"max_batch_size,omitempty"`
+
+	// Timeout for each request.
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+	// Number of retries on the failed requests (network errors, 429, 502, 503, or 504).
+	// Defaults to 3 if not set, set 0 to disable the retries.
+	MaxRetries *int `json:"max_retries,omitempty"`
+	// Backoff before the first retry, doubled for each retry up to "max_backoff".
+	InitialBackoff metav1.Duration `json:"initial_backoff,omitempty"`
+	MaxBackoff     metav1.Duration `json:"max_backoff,omitempty"`
+
+	// Set true to not export the metrics.
+	DisableMetrics bool `json:"disable_metrics,omitempty"`
+	// Set true to not export the events as log records.
+	DisableEvents bool `json:"disable_events,omitempty"`
+}
+
+func (o *OTLP) Validate() error {
+	if o.Endpoint != "" {
+		u, err := url.Parse(o.Endpoint)
+		if err != nil {
+			return fmt.Errorf("invalid endpoint: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("invalid endpoint scheme %q (only http and https are supported)", u.Scheme)
+		}
+	}
+	if o.Interval.Duration < 0 {
+		return errors.New("interval must be non-negative")
+	}
+	if o.MaxBatchSize < 0 {
+		return fmt.Errorf("max_batch_size must be non-negative, got %d", o.MaxBatchSize)
+	}
+	if o.MaxRetries != nil && *o.MaxRetries < 0 {
+		return fmt.Errorf("max_retries must be non-negative, got %d", *o.MaxRetries)
+	}
+	if o.DisableMetrics && o.DisableEvents {
+		return errors.New("both metrics and events are disabled")
+	}
+	return nil
+}
+
+func (o *OTLP) SetDefaultsIfNotSet() {
+	if o.Endpoint == "" {
+		o.Endpoint = DefaultOTLPEndpoint
+	}
+	if o.Interval.Duration == 0 {
+		o.Interval.Duration = DefaultOTLPInterval
+	}
+	if o.MaxBatchSize == 0 {
+		o.MaxBatchSize = DefaultOTLPMaxBatchSize
+	}
+	if o.Timeout.Duration == 0 {
+		o.Timeout.Duration = DefaultOTLPTimeout
+	}
+	if o.MaxRetries == nil {
+		maxRetries := DefaultOTLPMaxRetries
+		o.MaxRetries = &maxRetries
+	}
+	if o.InitialBackoff.Duration == 0 {
+		o.InitialBackoff.Duration = DefaultOTLPInitialBackoff
+	}
+	if o.MaxBackoff.Duration == 0 {
+		o.MaxBackoff.Duration = DefaultOTLPMaxBackoff
+	}
+}
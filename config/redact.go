@@ -0,0 +1,73 @@
+package config
+
+import (
+	"net/url"
+)
+
+// RedactedValue replaces the secret values in the redacted config.
+const RedactedValue = "REDACTED"
+
+// Redacted returns a copy of the config with the secrets redacted
+// (e.g., the "Authorization" request headers and the credentials in the URLs),
+// to be served over the API.
+func (cfg Config) Redacted() Config {
+	if cfg.Notifier != nil {
+		n := *cfg.Notifier
+		n.Webhooks = make([]Webhook, len(cfg.Notifier.Webhooks))
+		for i, w := range cfg.Notifier.Webhooks {
+			w.URL = redactURL(w.URL)
+			w.Headers = redactHeaders(w.Headers)
+			n.Webhooks[i] = w
+		}
+		cfg.Notifier = &n
+	}
+	if cfg.OTLP != nil {
+		o := *cfg.OTLP
+		o.Endpoint = redactURL(o.Endpoint)
+		o.Headers = redactHeaders(o.Headers)
+		cfg.OTLP = &o
+	}
+	if cfg.RemoteWrite != nil {
+		r := *cfg.RemoteWrite
+		r.URL = redactURL(r.URL)
+		r.Headers = redactHeaders(r.Headers)
+		cfg.RemoteWrite = &r
+	}
+	return cfg
+}
+
+// redactHeaders redacts all the header values, since any header
+// (e.g., "X-Api-Key") may carry the credentials.
+func redactHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(headers))
+	for k := range headers {
+		redacted[k] = RedactedValue
+	}
+	return redacted
+}
+
+// redactURL redacts the user info and the query values of the URL
+// (e.g., "?token=..."), or the whole URL if it cannot be parsed.
+func redactURL(s string) string {
+	if s == "" {
+		return s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return RedactedValue
+	}
+	if u.User != nil {
+		u.User = url.User(RedactedValue)
+	}
+	if u.RawQuery != "" {
+		q := u.Query()
+		for k := range q {
+			q[k] = []string{RedactedValue}
+		}
+		u.RawQuery = q.Encode()
+	}
+	return u.String()
+}
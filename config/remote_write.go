@@ -0,0 +1,83 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DefaultRemoteWriteInterval          = 30 * time.Second
+	DefaultRemoteWriteTimeout           = 10 * time.Second
+	DefaultRemoteWriteMaxQueuedRequests = 2880 // a day of the default interval
+)
+
+// labelNameRegex is the valid Prometheus label name.
+// ref. https://prometheus.io/docs/concepts/data_model/#metric-names-and-labels
+var labelNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Configures the Prometheus remote-write to push the metrics,
+// for the hosts that cannot be scraped (e.g., behind NAT).
+type RemoteWrite struct {
+	// URL of the remote-write endpoint (e.g., "https://prometheus.example.com/api/v1/write").
+	URL string `json:"url"`
+	// Headers to set in the request (e.g., "Authorization").
+	Headers map[string]string `json:"headers,omitempty"`
+	// ExternalLabels are added to every series,
+	// in addition to the annotations and the machine ID.
+	ExternalLabels map[string]string `json:"external_labels,omitempty"`
+
+	// Interval to gather and push the metrics.
+	Interval metav1.Duration `json:"interval,omitempty"`
+	// Timeout for each request.
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+
+	// QueueDir is the directory to persist the requests that failed to be sent
+	// (e.g., during the network outage), to retry on the next interval.
+	// Defaults to the "remote-write" directory next to the state file.
+	// If empty and the state is in memory, the requests are queued in memory.
+	QueueDir string `json:"queue_dir,omitempty"`
+	// Maximum number of the queued requests, the oldest are dropped first.
+	MaxQueuedRequests int `json:"max_queued_requests,omitempty"`
+}
+
+func (r *RemoteWrite) Validate() error {
+	if r.URL == "" {
+		return errors.New("url is required")
+	}
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid url scheme %q (only http and https are supported)", u.Scheme)
+	}
+	if r.Interval.Duration < 0 {
+		return errors.New("interval must be non-negative")
+	}
+	for k := range r.ExternalLabels {
+		if !labelNameRegex.MatchString(k) {
+			return fmt.Errorf("invalid external label name %q (must match %s)", k, labelNameRegex)
+		}
+	}
+	if r.MaxQueuedRequests < 0 {
+		return fmt.Errorf("max_queued_requests must be non-negative, got %d", r.MaxQueuedRequests)
+	}
+	return nil
+}
+
+func (r *RemoteWrite) SetDefaultsIfNotSet() {
+	if r.Interval.Duration == 0 {
+		r.Interval.Duration = DefaultRemoteWriteInterval
+	}
+	if r.Timeout.Duration == 0 {
+		r.Timeout.Duration = DefaultRemoteWriteTimeout
+	}
+	if r.MaxQueuedRequests == 0 {
+		r.MaxQueuedRequests = DefaultRemoteWriteMaxQueuedRequests
+	}
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"errors"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const DefaultTLSExpiryWarning = 30 * 24 * time.Hour
+
+// Configures the TLS certificate of the local HTTP API.
+// If nil, a self-signed certificate is generated on every start.
+type TLS struct {
+	// CertFile and KeyFile are the PEM-encoded certificate and private key to serve.
+	// The files are watched, and reloaded when rotated.
+	CertFile string `json:"cert_file,omitempty"`
+	KeyFile  string `json:"key_file,omitempty"`
+	// CAFile is the PEM-encoded CA bundle (e.g., the intermediate certificates)
+	// appended to the served certificate chain.
+	CAFile string `json:"ca_file,omitempty"`
+
+	// PersistSelfSigned persists the generated self-signed certificate
+	// in the state directory, so that its fingerprint stays stable across restarts.
+	// Ignored if "cert_file" is set.
+	PersistSelfSigned bool `json:"persist_self_signed,omitempty"`
+
+	// ExpiryWarning is the duration before the certificate expiry
+	// to report the certificate state as unhealthy (default 30 days).
+	// The self-signed certificate is regenerated within this duration instead.
+	ExpiryWarning metav1.Duration `json:"expiry_warning,omitempty"`
+}
+
+func (t *TLS) Validate() error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return errors.New("cert_file and key_file must be set together")
+	}
+	if t.CAFile != "" && t.CertFile == "" {
+		return errors.New("ca_file requires cert_file")
+	}
+	if t.ExpiryWarning.Duration < 0 {
+		return errors.New("expiry_warning must be non-negative")
+	}
+	return nil
+}
+
+func (t *TLS) SetDefaultsIfNotSet() {
+	if t.ExpiryWarning.Duration == 0 {
+		t.ExpiryWarning.Duration = DefaultTLSExpiryWarning
+	}
+}
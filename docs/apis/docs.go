@@ -36,6 +36,39 @@ const docTemplate = `{
                 }
             }
         },
+        "/v1/components/{component}/refresh": {
+            "post": {
+                "description": "trigger an out-of-band poll of the component, and return the fresh states",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Refresh the component states",
+                "operationId": "refreshComponent",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Component Name",
+                        "name": "component",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Duration to wait for the refresh (e.g., 10s), default 30s",
+                        "name": "timeout",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.LeptonComponentStates"
+                        }
+                    }
+                }
+            }
+        },
         "/v1/events": {
             "get": {
                 "description": "get component Events interface by component name",
@@ -50,6 +83,18 @@ const docTemplate = `{
                         "description": "Component Name, leave empty to query all components",
                         "name": "component",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Event Type, leave empty to query all event types",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Event Name, leave empty to query all event names",
+                        "name": "name",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -65,6 +110,56 @@ const docTemplate = `{
                 }
             }
         },
+        "/v1/events/stream": {
+            "get": {
+                "description": "stream the new component events as server-sent events, resumable with the \"Last-Event-ID\" header",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "summary": "Stream component events in gpud",
+                "operationId": "streamEvents",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma-separated component names, leave empty to stream all components",
+                        "name": "components",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resume from the event id, same as the Last-Event-ID header",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leptonai_gpud_components_events_state.Record"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/health": {
+            "get": {
+                "description": "get the node health verdict with the per-component breakdown",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Query the node health aggregated from all components",
+                "operationId": "getHealth",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.LeptonHealth"
+                        }
+                    }
+                }
+            }
+        },
         "/v1/info": {
             "get": {
                 "description": "get component Events/Metrics/States interface by component name",
@@ -123,6 +218,30 @@ const docTemplate = `{
                 }
             }
         },
+        "/v1/ready": {
+            "get": {
+                "description": "returns 503 if any component marked critical in the configuration is unhealthy",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Query the node readiness",
+                "operationId": "getReady",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.LeptonHealth"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/v1.LeptonHealth"
+                        }
+                    }
+                }
+            }
+        },
         "/v1/states": {
             "get": {
                 "description": "get component States interface by component name",
@@ -152,6 +271,38 @@ const docTemplate = `{
                 }
             }
         },
+        "/v1/states/stream": {
+            "get": {
+                "description": "stream the component health transitions with the states as server-sent events, resumable with the \"Last-Event-ID\" header",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "summary": "Stream component state changes in gpud",
+                "operationId": "streamStates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma-separated component names, leave empty to stream all components",
+                        "name": "components",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resume from the event id, same as the Last-Event-ID header",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leptonai_gpud_components_states_state.Record"
+                        }
+                    }
+                }
+            }
+        },
         "/v1/update/install": {
             "post": {
                 "description": "get current update progress",
@@ -225,6 +376,41 @@ const docTemplate = `{
                 }
             }
         },
+        "components.HealthStatus": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "The error returned from the States method, if any.",
+                    "type": "string"
+                },
+                "healthy": {
+                    "type": "boolean"
+                },
+                "reason": {
+                    "description": "The reasons of the unhealthy states.",
+                    "type": "string"
+                },
+                "severity": {
+                    "description": "The most severe level among the states, empty if unknown.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/components.Severity"
+                        }
+                    ]
+                },
+                "suggested_actions": {
+                    "description": "The merged suggested actions of all the states.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/components.SuggestedAction"
+                    }
+                },
+                "time": {
+                    "description": "The time when the health was evaluated.",
+                    "type": "string"
+                }
+            }
+        },
         "components.Info": {
             "type": "object",
             "properties": {
@@ -252,6 +438,23 @@ const docTemplate = `{
             "type": "object",
             "additionalProperties": true
         },
+        "components.Severity": {
+            "type": "string",
+            "enum": [
+                "info",
+                "warning",
+                "degraded",
+                "critical",
+                "fatal"
+            ],
+            "x-enum-varnames": [
+                "SeverityInfo",
+                "SeverityWarning",
+                "SeverityDegraded",
+                "SeverityCritical",
+                "SeverityFatal"
+            ]
+        },
         "components.State": {
             "type": "object",
             "properties": {
@@ -274,6 +477,72 @@ const docTemplate = `{
                 "reason": {
                     "description": "a detailed and processed reason on why the component is not healthy",
                     "type": "string"
+                },
+                "severity": {
+                    "description": "optional: how bad the state is, empty if unknown",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/components.Severity"
+                        }
+                    ]
+                },
+                "suggested_actions": {
+                    "description": "optional: the actions to recover from the state",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/components.SuggestedAction"
+                    }
+                }
+            }
+        },
+        "components.SuggestedAction": {
+            "type": "string",
+            "enum": [
+                "none",
+                "restart_workload",
+                "reset_gpu",
+                "reboot",
+                "hardware_inspection"
+            ],
+            "x-enum-varnames": [
+                "SuggestedActionNone",
+                "SuggestedActionRestartWorkload",
+                "SuggestedActionResetGPU",
+                "SuggestedActionReboot",
+                "SuggestedActionHardwareInspection"
+            ]
+        },
+        "github_com_leptonai_gpud_components_events_state.Record": {
+            "type": "object",
+            "properties": {
+                "component": {
+                    "type": "string"
+                },
+                "event": {
+                    "$ref": "#/definitions/components.Event"
+                },
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_leptonai_gpud_components_states_state.Record": {
+            "type": "object",
+            "properties": {
+                "component": {
+                    "type": "string"
+                },
+                "health": {
+                    "$ref": "#/definitions/components.HealthStatus"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "states": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/components.State"
+                    }
                 }
             }
         },
@@ -312,6 +581,48 @@ const docTemplate = `{
                 }
             }
         },
+        "v1.LeptonComponentHealth": {
+            "type": "object",
+            "properties": {
+                "component": {
+                    "type": "string"
+                },
+                "critical": {
+                    "description": "Critical is true if the component is marked as critical in the configuration,\nthus the node is not ready when the component is unhealthy.",
+                    "type": "boolean"
+                },
+                "error": {
+                    "description": "The error returned from the States method, if any.",
+                    "type": "string"
+                },
+                "healthy": {
+                    "type": "boolean"
+                },
+                "reason": {
+                    "description": "The reasons of the unhealthy states.",
+                    "type": "string"
+                },
+                "severity": {
+                    "description": "The most severe level among the states, empty if unknown.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/components.Severity"
+                        }
+                    ]
+                },
+                "suggested_actions": {
+                    "description": "The merged suggested actions of all the states.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/components.SuggestedAction"
+                    }
+                },
+                "time": {
+                    "description": "The time when the health was evaluated.",
+                    "type": "string"
+                }
+            }
+        },
         "v1.LeptonComponentInfo": {
             "type": "object",
             "properties": {
@@ -356,6 +667,41 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "v1.LeptonHealth": {
+            "type": "object",
+            "properties": {
+                "components": {
+                    "description": "Components is the per-component health breakdown.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.LeptonComponentHealth"
+                    }
+                },
+                "healthy": {
+                    "description": "Healthy is true if all the components are healthy.",
+                    "type": "boolean"
+                },
+                "ready": {
+                    "description": "Ready is true if all the critical components are healthy.",
+                    "type": "boolean"
+                },
+                "severity": {
+                    "description": "Severity is the most severe level among the components.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/components.Severity"
+                        }
+                    ]
+                },
+                "suggested_actions": {
+                    "description": "SuggestedActions are the merged suggested actions of all the components.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/components.SuggestedAction"
+                    }
+                }
+            }
         }
     }
 }`
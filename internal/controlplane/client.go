@@ -0,0 +1,67 @@
+package controlplane
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+var _ ControlPlane = (*client)(nil)
+
+type client struct {
+	endpoint string
+	cli      *http.Client
+}
+
+// New creates the control plane client of the endpoint,
+// either a host name served over HTTPS or a base URL with the scheme.
+func New(endpoint string) ControlPlane {
+	return &client{endpoint: endpoint, cli: http.DefaultClient}
+}
+
+func (c *client) Login(ctx context.Context, req LoginRequest) error {
+	return c.post(ctx, PathLogin, req)
+}
+
+func (c *client) Gossip(ctx context.Context, req GossipRequest) error {
+	return c.post(ctx, PathGossip, req)
+}
+
+func (c *client) SessionURL() string {
+	return URL(c.endpoint, PathSession)
+}
+
+func (c *client) post(ctx context.Context, path string, payload any) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, URL(c.endpoint, path), bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %w", err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	e := &Error{StatusCode: resp.StatusCode}
+	if err := json.Unmarshal(body, &e.Response); err != nil {
+		e.Body = string(body)
+	}
+	return e
+}
@@ -0,0 +1,90 @@
+// Package controlplane implements the protocol between gpud and the control plane
+// that the machines log into and hold the sessions with.
+// See "docs/CONTROL_PLANE.md" for the wire format.
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	// PathLogin is the path to register the machine with the token.
+	PathLogin = "/api/v1/login"
+	// PathGossip is the path to report the machine version.
+	PathGossip = "/api/v1/gossip"
+	// PathSession is the path to hold the read and write session streams.
+	PathSession = "/api/v1/session"
+)
+
+const (
+	// HeaderMachineID is the session request header of the machine ID.
+	HeaderMachineID = "machine_id"
+	// HeaderSessionType is the session request header of the stream type,
+	// either "read" (control plane to gpud) or "write" (gpud to control plane).
+	HeaderSessionType = "session_type"
+
+	SessionTypeRead  = "read"
+	SessionTypeWrite = "write"
+)
+
+// ControlPlane is the client of the control plane.
+type ControlPlane interface {
+	// Login registers the machine with the token.
+	Login(ctx context.Context, req LoginRequest) error
+	// Gossip reports the machine version.
+	Gossip(ctx context.Context, req GossipRequest) error
+	// SessionURL returns the URL to hold the session with.
+	SessionURL() string
+}
+
+// LoginRequest is the payload of the login request.
+type LoginRequest struct {
+	Name       string `json:"name"`
+	ID         string `json:"id"`
+	PublicIP   string `json:"public_ip"`
+	Provider   string `json:"provider"`
+	Components string `json:"components"`
+	Token      string `json:"token"`
+}
+
+// GossipRequest is the payload of the gossip request.
+type GossipRequest struct {
+	Name          string `json:"name"`
+	ID            string `json:"id"`
+	Provider      string `json:"provider"`
+	DaemonVersion string `json:"daemon_version"`
+}
+
+// ErrorResponse is the payload of the non-200 response.
+type ErrorResponse struct {
+	Error  string `json:"error"`
+	Status string `json:"status"`
+}
+
+// Error is returned when the control plane responds with the non-200 status code.
+type Error struct {
+	StatusCode int
+	Response   ErrorResponse
+	// Body is the raw response body, if not the error response.
+	Body string
+}
+
+func (e *Error) Error() string {
+	if e.Response.Error == "" && e.Response.Status == "" {
+		return fmt.Sprintf("control plane responded %d: %s", e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("control plane responded %d: %s (%s)", e.StatusCode, e.Response.Error, e.Response.Status)
+}
+
+// URL returns the URL of the path on the endpoint.
+// The endpoint is either a host name (e.g., "cp.example.com:443") served over HTTPS,
+// or a base URL with the scheme (e.g., "http://localhost:15133").
+func URL(endpoint string, path string) string {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		endpoint = "https://" + endpoint
+	}
+	return endpoint + path
+}
@@ -0,0 +1,41 @@
+package server
+
+type Op struct {
+	tokens        []string
+	operatorToken string
+	stateFile     string
+}
+
+type OpOption func(*Op)
+
+func (op *Op) applyOpts(opts []OpOption) {
+	for _, opt := range opts {
+		opt(op)
+	}
+}
+
+// WithTokens sets the tokens to accept the login with.
+// If not set, the login with any token is accepted.
+func WithTokens(tokens ...string) OpOption {
+	return func(op *Op) {
+		op.tokens = append(op.tokens, tokens...)
+	}
+}
+
+// WithOperatorToken sets the bearer token required by the operator API
+// (listing the machines and fanning out the requests).
+// If not set, the operator API is disabled.
+func WithOperatorToken(token string) OpOption {
+	return func(op *Op) {
+		op.operatorToken = token
+	}
+}
+
+// WithStateFile sets the file to persist the machines logged in,
+// so that the sessions are accepted after the control plane restarts.
+// If not set, the machines are kept in memory.
+func WithStateFile(file string) OpOption {
+	return func(op *Op) {
+		op.stateFile = file
+	}
+}
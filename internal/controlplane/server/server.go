@@ -0,0 +1,515 @@
+// Package server implements the reference control plane server,
+// which accepts the logins, holds the sessions with gpud, and
+// fans out the requests to the connected machines.
+// The machines logged in are kept in memory, and optionally persisted
+// to the state file so that the sessions are accepted after the restart.
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/errdefs"
+	"github.com/leptonai/gpud/internal/controlplane"
+	"github.com/leptonai/gpud/internal/session"
+	"github.com/leptonai/gpud/log"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// PathMachines lists the machines logged in.
+	PathMachines = "/api/v1/machines"
+	// PathFanOut sends the request to the machines and returns the responses.
+	PathFanOut = "/api/v1/fanout"
+
+	// DefaultFanOutTimeout is the timeout to wait for the responses, if not specified.
+	DefaultFanOutTimeout = 30 * time.Second
+
+	// pendingRequestsPerMachine is the number of the requests
+	// waiting for the machine to read.
+	pendingRequestsPerMachine = 100
+)
+
+// fanOutMethods are the session methods allowed to fan out,
+// the read-only ones.
+var fanOutMethods = map[string]struct{}{
+	"states": {},
+	"events": {},
+}
+
+// Machine is the machine logged in.
+type Machine struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	PublicIP      string    `json:"public_ip,omitempty"`
+	Components    string    `json:"components,omitempty"`
+	DaemonVersion string    `json:"daemon_version,omitempty"`
+	LoginTime     time.Time `json:"login_time"`
+	LastSeen      time.Time `json:"last_seen"`
+
+	// Set true if the read and write streams are connected.
+	Connected bool `json:"connected"`
+}
+
+// Server is the reference control plane server.
+type Server struct {
+	// tokens accepted for login, any token if empty
+	tokens map[string]struct{}
+	// bearer token of the operator API, disabled if empty
+	operatorToken string
+	// file to persist the machines logged in, not persisted if empty
+	stateFile string
+
+	mu       sync.RWMutex
+	machines map[string]*machine
+}
+
+type machine struct {
+	Machine
+
+	// requests to write to the read stream of the machine
+	requests chan session.Body
+
+	readers int
+	writers int
+
+	// maps the request ID to the response receiver
+	pending map[string]chan session.Body
+}
+
+// New creates the control plane server,
+// loading the machines logged in from the state file if set.
+func New(opts ...OpOption) (*Server, error) {
+	op := &Op{}
+	op.applyOpts(opts)
+
+	s := &Server{
+		tokens:        make(map[string]struct{}),
+		operatorToken: op.operatorToken,
+		stateFile:     op.stateFile,
+		machines:      make(map[string]*machine),
+	}
+	for _, t := range op.tokens {
+		if t != "" {
+			s.tokens[t] = struct{}{}
+		}
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func newMachine(m Machine) *machine {
+	return &machine{
+		Machine:  m,
+		requests: make(chan session.Body, pendingRequestsPerMachine),
+		pending:  make(map[string]chan session.Body),
+	}
+}
+
+// load restores the machines logged in from the state file,
+// no-op if the file is not set or does not exist yet.
+func (s *Server) load() error {
+	if s.stateFile == "" {
+		return nil
+	}
+	b, err := os.ReadFile(s.stateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read state file: %w", err)
+	}
+	var ms []Machine
+	if err := json.Unmarshal(b, &ms); err != nil {
+		return fmt.Errorf("failed to parse state file: %w", err)
+	}
+	for _, m := range ms {
+		m.Connected = false
+		s.machines[m.ID] = newMachine(m)
+	}
+	log.Logger.Infow("loaded machines from the state file", "file", s.stateFile, "machines", len(ms))
+	return nil
+}
+
+// saveLocked persists the machines logged in to the state file,
+// no-op if the file is not set. The caller must hold the lock.
+func (s *Server) saveLocked() error {
+	if s.stateFile == "" {
+		return nil
+	}
+	ms := make([]Machine, 0, len(s.machines))
+	for _, m := range s.machines {
+		ms = append(ms, m.Machine)
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		return ms[i].ID < ms[j].ID
+	})
+	b, err := json.Marshal(ms)
+	if err != nil {
+		return err
+	}
+
+	// write to the temporary file first, to not corrupt the state on crash
+	tmp := filepath.Join(filepath.Dir(s.stateFile), "."+filepath.Base(s.stateFile)+".tmp")
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.stateFile)
+}
+
+// Handler returns the HTTP handler of the control plane protocol
+// and the fan-out API.
+func (s *Server) Handler() http.Handler {
+	router := gin.New()
+	router.Use(gin.Recovery())
+
+	router.POST(controlplane.PathLogin, s.login)
+	router.POST(controlplane.PathGossip, s.gossip)
+	router.POST(controlplane.PathSession, s.session)
+
+	operator := router.Group("", s.authorizeOperator)
+	operator.GET(PathMachines, s.listMachines)
+	operator.POST(PathFanOut, s.fanOut)
+	return router
+}
+
+// authorizeOperator rejects the operator API request
+// without the operator bearer token.
+func (s *Server) authorizeOperator(c *gin.Context) {
+	if s.operatorToken == "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"code": errdefs.ErrFailedPrecondition, "message": "operator API is disabled, no operator token configured"})
+		return
+	}
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.operatorToken)) != 1 {
+		c.Header("WWW-Authenticate", "Bearer")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": errdefs.ErrInvalidArgument, "message": "unauthenticated"})
+		return
+	}
+	c.Next()
+}
+
+func (s *Server) login(c *gin.Context) {
+	var req controlplane.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, controlplane.ErrorResponse{Error: err.Error(), Status: "invalid request"})
+		return
+	}
+	if req.ID == "" {
+		c.JSON(http.StatusBadRequest, controlplane.ErrorResponse{Error: "machine id is empty", Status: "invalid request"})
+		return
+	}
+	if len(s.tokens) > 0 {
+		if _, ok := s.tokens[req.Token]; !ok {
+			c.JSON(http.StatusUnauthorized, controlplane.ErrorResponse{Error: "unauthorized", Status: "invalid workspace token"})
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+
+	s.mu.Lock()
+	m, ok := s.machines[req.ID]
+	if !ok {
+		m = newMachine(Machine{})
+		s.machines[req.ID] = m
+	}
+	m.ID = req.ID
+	m.Name = req.Name
+	m.PublicIP = req.PublicIP
+	m.Components = req.Components
+	m.LoginTime = now
+	m.LastSeen = now
+	err := s.saveLocked()
+	s.mu.Unlock()
+	if err != nil {
+		log.Logger.Errorw("failed to persist machine login", "machine", req.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, controlplane.ErrorResponse{Error: err.Error(), Status: "failed to persist login"})
+		return
+	}
+
+	log.Logger.Infow("machine logged in", "machine", req.ID, "name", req.Name, "publicIP", req.PublicIP)
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (s *Server) gossip(c *gin.Context) {
+	var req controlplane.GossipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, controlplane.ErrorResponse{Error: err.Error(), Status: "invalid request"})
+		return
+	}
+
+	s.mu.Lock()
+	if m, ok := s.machines[req.ID]; ok {
+		m.DaemonVersion = req.DaemonVersion
+		m.LastSeen = time.Now().UTC()
+	}
+	s.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// session holds the read stream (requests to the machine)
+// or the write stream (responses from the machine) until disconnected.
+func (s *Server) session(c *gin.Context) {
+	machineID := c.GetHeader(controlplane.HeaderMachineID)
+
+	s.mu.RLock()
+	m, ok := s.machines[machineID]
+	s.mu.RUnlock()
+	if !ok {
+		c.JSON(http.StatusUnauthorized, controlplane.ErrorResponse{Error: "machine not logged in", Status: "unauthorized"})
+		return
+	}
+
+	switch typ := c.GetHeader(controlplane.HeaderSessionType); typ {
+	case controlplane.SessionTypeRead:
+		s.connected(m, typ, 1)
+		defer s.connected(m, typ, -1)
+		s.serveRead(c, m)
+
+	case controlplane.SessionTypeWrite:
+		s.connected(m, typ, 1)
+		defer s.connected(m, typ, -1)
+		s.serveWrite(c, m)
+
+	default:
+		c.JSON(http.StatusBadRequest, controlplane.ErrorResponse{Error: fmt.Sprintf("invalid session type %q", typ), Status: "invalid request"})
+	}
+}
+
+func (s *Server) connected(m *machine, typ string, delta int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if typ == controlplane.SessionTypeRead {
+		m.readers += delta
+	} else {
+		m.writers += delta
+	}
+	m.Connected = m.readers > 0 && m.writers > 0
+	m.LastSeen = time.Now().UTC()
+}
+
+// serveRead writes the requests to the machine until disconnected.
+func (s *Server) serveRead(c *gin.Context, m *machine) {
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	enc := json.NewEncoder(c.Writer)
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case body := <-m.requests:
+			if err := enc.Encode(body); err != nil {
+				log.Logger.Warnw("failed to write request", "machine", m.ID, "reqID", body.ReqID, "error", err)
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// serveWrite reads the responses from the machine until disconnected,
+// and delivers them to the waiting requests.
+func (s *Server) serveWrite(c *gin.Context, m *machine) {
+	dec := json.NewDecoder(c.Request.Body)
+	for {
+		var body session.Body
+		if err := dec.Decode(&body); err != nil {
+			log.Logger.Debugw("session write stream closed", "machine", m.ID, "error", err)
+			c.Status(http.StatusOK)
+			return
+		}
+
+		if body.ReqID == "" {
+			log.Logger.Infow("received notification", "machine", m.ID, "data", string(body.Data))
+			continue
+		}
+
+		s.mu.RLock()
+		ch, ok := m.pending[body.ReqID]
+		s.mu.RUnlock()
+		if !ok {
+			log.Logger.Debugw("received response of unknown request", "machine", m.ID, "reqID", body.ReqID)
+			continue
+		}
+		select {
+		case ch <- body:
+		default:
+		}
+	}
+}
+
+func (s *Server) listMachines(c *gin.Context) {
+	c.JSON(http.StatusOK, s.Machines())
+}
+
+// Machines returns the machines logged in, sorted by ID.
+func (s *Server) Machines() []Machine {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ms := make([]Machine, 0, len(s.machines))
+	for _, m := range s.machines {
+		ms = append(ms, m.Machine)
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		return ms[i].ID < ms[j].ID
+	})
+	return ms
+}
+
+// Request sends the request to the machine over the session,
+// and waits for the response until the context is done.
+func (s *Server) Request(ctx context.Context, machineID string, req session.Request) (*session.Response, error) {
+	s.mu.RLock()
+	m, ok := s.machines[machineID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("machine %s: %w", machineID, errdefs.ErrNotFound)
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	reqID, err := newRequestID()
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan session.Body, 1)
+	s.mu.Lock()
+	m.pending[reqID] = ch
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(m.pending, reqID)
+		s.mu.Unlock()
+	}()
+
+	select {
+	case m.requests <- session.Body{Data: data, ReqID: reqID}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		return nil, fmt.Errorf("machine %s has too many pending requests", machineID)
+	}
+
+	select {
+	case body := <-ch:
+		resp := new(session.Response)
+		if err := json.Unmarshal(body.Data, resp); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		return resp, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// FanOutRequest is the payload of the fan-out request.
+type FanOutRequest struct {
+	// MachineIDs are the machines to send the request to,
+	// all the machines logged in if empty.
+	MachineIDs []string `json:"machine_ids,omitempty"`
+	// Timeout is the duration to wait for the responses,
+	// defaults to 30 seconds.
+	Timeout string `json:"timeout,omitempty"`
+
+	Request session.Request `json:"request"`
+}
+
+// FanOutResult is the response or the error of a machine.
+type FanOutResult struct {
+	MachineID string            `json:"machine_id"`
+	Response  *session.Response `json:"response,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+func (s *Server) fanOut(c *gin.Context) {
+	var req FanOutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse request: " + err.Error()})
+		return
+	}
+	if _, ok := fanOutMethods[req.Request.Method]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": fmt.Sprintf("method %q cannot fan out", req.Request.Method)})
+		return
+	}
+	timeout := DefaultFanOutTimeout
+	if req.Timeout != "" {
+		d, err := time.ParseDuration(req.Timeout)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse timeout: " + err.Error()})
+			return
+		}
+		timeout = d
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+	c.JSON(http.StatusOK, s.FanOut(ctx, req.MachineIDs, req.Request))
+}
+
+// FanOut sends the request to the machines concurrently,
+// or all the machines logged in if none specified,
+// and returns the results sorted by the machine ID.
+func (s *Server) FanOut(ctx context.Context, machineIDs []string, req session.Request) []FanOutResult {
+	if len(machineIDs) == 0 {
+		for _, m := range s.Machines() {
+			machineIDs = append(machineIDs, m.ID)
+		}
+	}
+
+	results := make([]FanOutResult, len(machineIDs))
+	var wg sync.WaitGroup
+	for i, id := range machineIDs {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+
+			results[i].MachineID = id
+			resp, err := s.Request(ctx, id, req)
+			if err != nil {
+				if errors.Is(err, context.DeadlineExceeded) {
+					err = errors.New("timed out waiting for the response")
+				}
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Response = resp
+		}(i, id)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].MachineID < results[j].MachineID
+	})
+	return results
+}
+
+func newRequestID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,249 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/internal/controlplane"
+	"github.com/leptonai/gpud/internal/session"
+)
+
+const testOperatorToken = "operator"
+
+func newTestServer(t *testing.T, opts ...OpOption) *Server {
+	srv, err := New(append([]OpOption{WithOperatorToken(testOperatorToken)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv
+}
+
+// doOperator sends the operator API request with the token.
+func doOperator(t *testing.T, method string, url string, token string, body []byte) *http.Response {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestLogin(t *testing.T) {
+	ts := httptest.NewServer(newTestServer(t, WithTokens("valid")).Handler())
+	defer ts.Close()
+
+	cp := controlplane.New(ts.URL)
+	ctx := context.Background()
+
+	err := cp.Login(ctx, controlplane.LoginRequest{ID: "m1", Token: "invalid"})
+	var cpErr *controlplane.Error
+	if !errors.As(err, &cpErr) || cpErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+
+	if err := cp.Login(ctx, controlplane.LoginRequest{ID: "m1", Name: "host1", Token: "valid"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cp.Gossip(ctx, controlplane.GossipRequest{ID: "m1", DaemonVersion: "v0.1.0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := doOperator(t, http.MethodGet, ts.URL+PathMachines, testOperatorToken, nil)
+	defer resp.Body.Close()
+	var ms []Machine
+	if err := json.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 1 || ms[0].ID != "m1" || ms[0].Name != "host1" || ms[0].DaemonVersion != "v0.1.0" {
+		t.Fatalf("unexpected machines %+v", ms)
+	}
+}
+
+func TestOperatorAuth(t *testing.T) {
+	ts := httptest.NewServer(newTestServer(t).Handler())
+	defer ts.Close()
+
+	for token, want := range map[string]int{
+		"":                http.StatusUnauthorized,
+		"invalid":         http.StatusUnauthorized,
+		testOperatorToken: http.StatusOK,
+	} {
+		resp := doOperator(t, http.MethodGet, ts.URL+PathMachines, token, nil)
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Fatalf("token %q: expected %d, got %d", token, want, resp.StatusCode)
+		}
+	}
+
+	// the operator API is disabled without the operator token
+	srv, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts2 := httptest.NewServer(srv.Handler())
+	defer ts2.Close()
+	resp := doOperator(t, http.MethodPost, ts2.URL+PathFanOut, "", []byte(`{"request":{"method":"states"}}`))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected %d, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+}
+
+func TestStateFile(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "control-plane.json")
+	ctx := context.Background()
+
+	srv := newTestServer(t, WithStateFile(stateFile))
+	ts := httptest.NewServer(srv.Handler())
+	if err := controlplane.New(ts.URL).Login(ctx, controlplane.LoginRequest{ID: "m1", Name: "host1"}); err != nil {
+		t.Fatal(err)
+	}
+	ts.Close()
+
+	// the machine logged in before the restart is accepted
+	srv = newTestServer(t, WithStateFile(stateFile))
+	ms := srv.Machines()
+	if len(ms) != 1 || ms[0].ID != "m1" || ms[0].Name != "host1" || ms[0].Connected {
+		t.Fatalf("unexpected machines %+v", ms)
+	}
+	ts = httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	req, err := http.NewRequest(http.MethodPost, controlplane.New(ts.URL).SessionURL(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(controlplane.HeaderMachineID, "m1")
+	req.Header.Set(controlplane.HeaderSessionType, controlplane.SessionTypeWrite)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if err := os.WriteFile(stateFile, []byte("invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(WithStateFile(stateFile)); err == nil {
+		t.Fatal("expected the invalid state file error, got nil")
+	}
+}
+
+func TestSessionRejectsUnknownMachine(t *testing.T) {
+	ts := httptest.NewServer(newTestServer(t).Handler())
+	defer ts.Close()
+
+	req, err := http.NewRequest(http.MethodPost, controlplane.New(ts.URL).SessionURL(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(controlplane.HeaderMachineID, "unknown")
+	req.Header.Set(controlplane.HeaderSessionType, controlplane.SessionTypeRead)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected %d, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+}
+
+func TestFanOut(t *testing.T) {
+	srv := newTestServer(t)
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	cp := controlplane.New(ts.URL)
+	for _, id := range []string{"m1", "m2"} {
+		if err := cp.Login(ctx, controlplane.LoginRequest{ID: id, Name: id}); err != nil {
+			t.Fatal(err)
+		}
+		s := session.NewSession(ctx, cp.SessionURL(), id, 100*time.Millisecond)
+		defer s.Stop()
+	}
+
+	// wait for the sessions to connect
+	for {
+		connected := 0
+		for _, m := range srv.Machines() {
+			if m.Connected {
+				connected++
+			}
+		}
+		if connected == 2 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the sessions")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	b, err := json.Marshal(FanOutRequest{
+		MachineIDs: []string{"m2", "m1", "unknown"},
+		Timeout:    "30s",
+		Request:    session.Request{Method: "states", Components: []string{"test-component"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := doOperator(t, http.MethodPost, ts.URL+PathFanOut, testOperatorToken, b)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	var results []FanOutResult
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	for _, r := range results[:2] {
+		if r.Error != "" || r.Response == nil {
+			t.Fatalf("unexpected result %+v", r)
+		}
+		if len(r.Response.States) != 1 || r.Response.States[0].Component != "test-component" {
+			t.Fatalf("unexpected states %+v", r.Response.States)
+		}
+	}
+	if results[0].MachineID != "m1" || results[1].MachineID != "m2" {
+		t.Fatalf("unexpected order %s, %s", results[0].MachineID, results[1].MachineID)
+	}
+	if results[2].MachineID != "unknown" || results[2].Error == "" {
+		t.Fatalf("expected error for unknown machine, got %+v", results[2])
+	}
+
+	// only the read-only methods fan out
+	b, err = json.Marshal(FanOutRequest{Request: session.Request{Method: "update"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp2 := doOperator(t, http.MethodPost, ts.URL+PathFanOut, testOperatorToken, b)
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected %d, got %d", http.StatusBadRequest, resp2.StatusCode)
+	}
+}
@@ -0,0 +1,113 @@
+package k8snode
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/config"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// node condition reasons, in CamelCase as the Kubernetes convention
+const (
+	reasonHealthy   = "ComponentsHealthy"
+	reasonUnhealthy = "ComponentsUnhealthy"
+	reasonUnknown   = "ComponentsNotEvaluated"
+)
+
+// evaluateCondition returns the node condition from the last health of the matching components.
+// Returns false if no registered component matches the condition.
+func evaluateCondition(cfg config.K8sNodeCondition, healths map[string]*components.HealthStatus, now time.Time) (corev1.NodeCondition, bool) {
+	var matched []string
+	for name := range healths {
+		if matchAny(cfg.Components, name) {
+			matched = append(matched, name)
+		}
+	}
+	if len(matched) == 0 {
+		return corev1.NodeCondition{}, false
+	}
+	sort.Strings(matched)
+
+	var unhealthy []string
+	evaluated := 0
+	for _, name := range matched {
+		h := healths[name]
+		if h == nil {
+			continue
+		}
+		evaluated++
+		if h.Healthy {
+			continue
+		}
+		// the unhealthy state without the severity is always counted
+		if cfg.MinSeverity != "" && h.Severity != "" && components.Severity(cfg.MinSeverity).MoreSevere(h.Severity) {
+			continue
+		}
+		msg := name
+		if h.Reason != "" {
+			msg += ": " + h.Reason
+		}
+		unhealthy = append(unhealthy, msg)
+	}
+
+	cond := corev1.NodeCondition{
+		Type:              corev1.NodeConditionType(cfg.Type),
+		LastHeartbeatTime: metav1.NewTime(now),
+	}
+	switch {
+	case len(unhealthy) > 0:
+		cond.Status = statusOf(cfg.Problem)
+		cond.Reason = reasonUnhealthy
+		cond.Message = strings.Join(unhealthy, "; ")
+	case evaluated == 0:
+		cond.Status = corev1.ConditionUnknown
+		cond.Reason = reasonUnknown
+		cond.Message = fmt.Sprintf("%s not evaluated yet", strings.Join(matched, ", "))
+	default:
+		cond.Status = statusOf(!cfg.Problem)
+		cond.Reason = reasonHealthy
+		cond.Message = fmt.Sprintf("%s healthy", strings.Join(matched, ", "))
+	}
+	return cond, true
+}
+
+func statusOf(b bool) corev1.ConditionStatus {
+	if b {
+		return corev1.ConditionTrue
+	}
+	return corev1.ConditionFalse
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeConditions sets the transition time of the conditions,
+// keeping the previous transition time if the status has not changed.
+func mergeConditions(prev []corev1.NodeCondition, conds []corev1.NodeCondition) []corev1.NodeCondition {
+	prevByType := make(map[corev1.NodeConditionType]corev1.NodeCondition, len(prev))
+	for _, c := range prev {
+		prevByType[c.Type] = c
+	}
+	for i := range conds {
+		p, ok := prevByType[conds[i].Type]
+		if ok && p.Status == conds[i].Status && !p.LastTransitionTime.IsZero() {
+			conds[i].LastTransitionTime = p.LastTransitionTime
+		} else {
+			conds[i].LastTransitionTime = conds[i].LastHeartbeatTime
+		}
+	}
+	return conds
+}
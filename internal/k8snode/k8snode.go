@@ -0,0 +1,275 @@
+// Package k8snode converts the component states into the Kubernetes node conditions,
+// and the component events into the node events,
+// in the format compatible with the node-problem-detector.
+package k8snode
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	components_events_state "github.com/leptonai/gpud/components/events/state"
+	k8s_pod "github.com/leptonai/gpud/components/k8s/pod"
+	"github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/log"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// eventSource is the source component of the node events.
+	eventSource = "gpud"
+	// readLimit bounds the number of events to read at once.
+	readLimit = 1000
+)
+
+// Updater periodically patches the node conditions,
+// and creates the node events for the new component events.
+type Updater struct {
+	cfg    config.K8sNode
+	db     *sql.DB
+	client kubernetes.Interface
+
+	lastEventID int64
+}
+
+// NewClientset creates the clientset from the kubeconfig file,
+// or the in-cluster config if the file is empty.
+func NewClientset(kubeconfig string) (kubernetes.Interface, error) {
+	restCfg, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kube config: %w", err)
+	}
+	return kubernetes.NewForConfig(restCfg)
+}
+
+func New(cfg config.K8sNode, db *sql.DB, client kubernetes.Interface) (*Updater, error) {
+	cfg.Conditions = append([]config.K8sNodeCondition{}, cfg.Conditions...)
+	cfg.SetDefaultsIfNotSet()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &Updater{cfg: cfg, db: db, client: client}, nil
+}
+
+// Start starts updating the node until the context is canceled.
+// Only the events persisted after the start are created.
+func (u *Updater) Start(ctx context.Context) error {
+	var err error
+	u.lastEventID, err = components_events_state.LastID(ctx, u.db, components_events_state.DefaultTableName)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(u.cfg.Interval.Duration)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if err := u.sync(ctx, time.Now()); err != nil {
+				log.Logger.Warnw("failed to update kubernetes node", "error", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// nodeName returns the configured node name,
+// or the node name discovered by the "k8s-pod" component.
+func (u *Updater) nodeName() string {
+	if u.cfg.NodeName != "" {
+		return u.cfg.NodeName
+	}
+	poller := k8s_pod.GetDefaultPoller()
+	if poller == nil {
+		return ""
+	}
+	last, err := poller.Last()
+	if err != nil || last == nil || last.Output == nil {
+		return ""
+	}
+	if o, ok := last.Output.(*k8s_pod.Output); ok {
+		return o.NodeName
+	}
+	return ""
+}
+
+func (u *Updater) sync(ctx context.Context, now time.Time) error {
+	nodeName := u.nodeName()
+	if nodeName == "" {
+		return errors.New("node name not found (set the node name or enable the k8s-pod component)")
+	}
+
+	if err := u.updateConditions(ctx, nodeName, now); err != nil {
+		return fmt.Errorf("failed to update node conditions: %w", err)
+	}
+	if !u.cfg.DisableEvents {
+		if err := u.createEvents(ctx, nodeName); err != nil {
+			return fmt.Errorf("failed to create node events: %w", err)
+		}
+	}
+	return nil
+}
+
+// healths returns the last health of all the registered components,
+// with nil for the components not evaluated yet.
+func healths() map[string]*components.HealthStatus {
+	all := components.GetAllComponents()
+	hs := make(map[string]*components.HealthStatus, len(all))
+	for name, c := range all {
+		hs[name] = nil
+		if wc, ok := c.(components.WatchableComponent); ok {
+			if h, ok := wc.LastHealth(); ok {
+				hs[name] = &h
+			}
+		}
+	}
+	return hs
+}
+
+func (u *Updater) updateConditions(ctx context.Context, nodeName string, now time.Time) error {
+	hs := healths()
+	var conds []corev1.NodeCondition
+	for _, cc := range u.cfg.Conditions {
+		if cond, ok := evaluateCondition(cc, hs, now); ok {
+			conds = append(conds, cond)
+		}
+	}
+	if len(conds) == 0 {
+		return nil
+	}
+
+	node, err := u.client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	conds = mergeConditions(node.Status.Conditions, conds)
+
+	// strategic merge patch on the status subresource,
+	// to only update the conditions of the same types (merge key)
+	patch, err := json.Marshal(map[string]any{
+		"status": map[string]any{
+			"conditions": conds,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = u.client.CoreV1().Nodes().PatchStatus(ctx, nodeName, patch)
+	return err
+}
+
+func (u *Updater) createEvents(ctx context.Context, nodeName string) error {
+	records, err := components_events_state.ReadAfter(ctx, u.db, components_events_state.DefaultTableName, u.lastEventID, components_events_state.WithLimit(readLimit))
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		if contains(u.cfg.EventTypes, r.Event.Type) {
+			ev := convertEvent(r, nodeName, u.cfg.EventNamespace)
+			if _, err := u.client.CoreV1().Events(ev.Namespace).Create(ctx, ev, metav1.CreateOptions{}); err != nil {
+				switch {
+				case apierrors.IsAlreadyExists(err):
+					// created before the restart, the event name is deterministic
+				case isPermanentError(err):
+					// skip, otherwise the event blocks all the following events
+					log.Logger.Warnw("skipping node event", "id", r.ID, "component", r.Component, "name", r.Event.Name, "error", err)
+				default:
+					return err
+				}
+			}
+		}
+		u.lastEventID = r.ID
+	}
+	return nil
+}
+
+// isPermanentError returns true if the request fails again on retry
+// (e.g., 400 on the invalid event, 403 on the missing permission),
+// which are the client errors except the timeout and the throttling.
+func isPermanentError(err error) bool {
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		return false
+	}
+	code := status.Status().Code
+	if code == http.StatusRequestTimeout || code == http.StatusTooManyRequests {
+		return false
+	}
+	return code >= 400 && code < 500
+}
+
+// convertEvent converts the component event into the node event,
+// in the same way as the node-problem-detector
+// (e.g., the node name as the involved object UID).
+func convertEvent(r components_events_state.Record, nodeName string, namespace string) *corev1.Event {
+	evType := corev1.EventTypeNormal
+	if r.Event.Type == components.EventTypeError || r.Event.Type == components.EventTypeWarn {
+		evType = corev1.EventTypeWarning
+	}
+	reason := r.Event.Name
+	if reason == "" {
+		reason = r.Component
+	}
+	msg := r.Event.Message
+	if msg == "" {
+		msg = r.Event.Name
+	}
+
+	t := r.Event.Time
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", nodeName, t.UnixNano()+r.ID),
+			Namespace: namespace,
+			Labels:    map[string]string{"gpud.leptonai.io/component": r.Component},
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind: "Node",
+			Name: nodeName,
+			UID:  types.UID(nodeName),
+		},
+		Reason:         camelCase(reason),
+		Message:        fmt.Sprintf("[%s] %s", r.Component, msg),
+		Type:           evType,
+		Source:         corev1.EventSource{Component: eventSource, Host: nodeName},
+		FirstTimestamp: t,
+		LastTimestamp:  t,
+		Count:          1,
+	}
+}
+
+// camelCase converts the event name (e.g., "xid_error") into the CamelCase reason ("XidError").
+func camelCase(s string) string {
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '_' || r == '-' || r == ' ' || r == '.'
+	})
+	var b strings.Builder
+	for _, p := range parts {
+		b.WriteString(strings.ToUpper(p[:1]) + p[1:])
+	}
+	return b.String()
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
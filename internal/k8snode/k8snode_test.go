@@ -0,0 +1,264 @@
+package k8snode
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	components_events_state "github.com/leptonai/gpud/components/events/state"
+	"github.com/leptonai/gpud/components/state"
+	"github.com/leptonai/gpud/config"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8s_testing "k8s.io/client-go/testing"
+)
+
+func TestEvaluateCondition(t *testing.T) {
+	now := time.Now()
+	hs := map[string]*components.HealthStatus{
+		"accelerator-nvidia-ecc":       {Healthy: true, Severity: components.SeverityInfo},
+		"accelerator-nvidia-nvlink":    {Healthy: false, Severity: components.SeverityWarning, Reason: "nvlink down"},
+		"accelerator-nvidia-error-xid": {Healthy: false, Severity: components.SeverityWarning, Reason: "xid 31"},
+		"accelerator-nvidia-power":     nil,
+		"cpu":                          {Healthy: false},
+	}
+
+	tests := []struct {
+		name        string
+		cfg         config.K8sNodeCondition
+		wantOK      bool
+		wantStatus  corev1.ConditionStatus
+		wantMessage string
+	}{
+		{
+			name:        "gpu unhealthy",
+			cfg:         config.DefaultK8sNodeConditions[0],
+			wantOK:      true,
+			wantStatus:  corev1.ConditionFalse,
+			wantMessage: "accelerator-nvidia-error-xid: xid 31; accelerator-nvidia-nvlink: nvlink down",
+		},
+		{
+			name:        "xid below critical",
+			cfg:         config.DefaultK8sNodeConditions[2],
+			wantOK:      true,
+			wantStatus:  corev1.ConditionFalse,
+			wantMessage: "accelerator-nvidia-error-xid healthy",
+		},
+		{
+			name:        "not evaluated",
+			cfg:         config.K8sNodeCondition{Type: "PowerHealthy", Components: []string{"accelerator-nvidia-power"}},
+			wantOK:      true,
+			wantStatus:  corev1.ConditionUnknown,
+			wantMessage: "accelerator-nvidia-power not evaluated yet",
+		},
+		{
+			name:   "no component",
+			cfg:    config.K8sNodeCondition{Type: "InfinibandHealthy", Components: []string{"accelerator-nvidia-infiniband"}},
+			wantOK: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond, ok := evaluateCondition(tt.cfg, hs, now)
+			if ok != tt.wantOK {
+				t.Fatalf("expected ok %v, got %v", tt.wantOK, ok)
+			}
+			if !ok {
+				return
+			}
+			if cond.Status != tt.wantStatus || cond.Message != tt.wantMessage {
+				t.Fatalf("unexpected condition %+v", cond)
+			}
+		})
+	}
+
+	// problem condition is true when unhealthy
+	hs["accelerator-nvidia-error-xid"].Severity = components.SeverityCritical
+	cond, _ := evaluateCondition(config.DefaultK8sNodeConditions[2], hs, now)
+	if cond.Status != corev1.ConditionTrue || cond.Reason != reasonUnhealthy {
+		t.Fatalf("unexpected condition %+v", cond)
+	}
+}
+
+type testComponent struct {
+	name   string
+	health components.HealthStatus
+}
+
+func (c *testComponent) Name() string { return c.name }
+func (c *testComponent) States(context.Context) ([]components.State, error) {
+	return nil, nil
+}
+func (c *testComponent) Events(context.Context, time.Time) ([]components.Event, error) {
+	return nil, nil
+}
+func (c *testComponent) Metrics(context.Context, time.Time) ([]components.Metric, error) {
+	return nil, nil
+}
+func (c *testComponent) Close() error { return nil }
+func (c *testComponent) LastHealth() (components.HealthStatus, bool) {
+	return c.health, true
+}
+
+func TestUpdaterSync(t *testing.T) {
+	ctx := context.Background()
+	db, err := state.Open(filepath.Join(t.TempDir(), "gpud.state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := components_events_state.CreateTable(ctx, db, components_events_state.DefaultTableName); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &testComponent{name: "accelerator-nvidia-nvlink", health: components.HealthStatus{Healthy: true}}
+	if err := components.RegisterComponent(c.Name(), c); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = components.DeregisterComponent(c.Name())
+	}()
+
+	client := fake.NewSimpleClientset(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	})
+	u, err := New(config.K8sNode{NodeName: "node1"}, db, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ev := range []components.Event{
+		{Time: metav1.Now(), Name: "nvlink_down", Type: components.EventTypeError, Message: "nvlink 0 down"},
+		{Time: metav1.Now(), Name: "nvlink_info", Type: components.EventTypeInfo},
+	} {
+		if err := components_events_state.Insert(ctx, db, components_events_state.DefaultTableName, c.Name(), ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now().Truncate(time.Second)
+	if err := u.sync(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	c.health = components.HealthStatus{Healthy: false, Severity: components.SeverityCritical, Reason: "nvlink down"}
+	if err := u.sync(ctx, now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := client.CoreV1().Nodes().Get(ctx, "node1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conds := make(map[corev1.NodeConditionType]corev1.NodeCondition)
+	for _, cond := range node.Status.Conditions {
+		conds[cond.Type] = cond
+	}
+	if _, ok := conds[corev1.NodeReady]; !ok {
+		t.Fatal("expected the existing condition to be kept")
+	}
+	if _, ok := conds["XidCritical"]; ok {
+		t.Fatal("expected no condition without the matching components")
+	}
+	for _, typ := range []corev1.NodeConditionType{"GPUHealthy", "NVLinkHealthy"} {
+		cond, ok := conds[typ]
+		if !ok {
+			t.Fatalf("expected condition %q", typ)
+		}
+		if cond.Status != corev1.ConditionFalse || cond.Message != "accelerator-nvidia-nvlink: nvlink down" {
+			t.Fatalf("unexpected condition %+v", cond)
+		}
+		if !cond.LastTransitionTime.Time.Equal(now.Add(time.Minute)) {
+			t.Fatalf("expected transition time %v, got %v", now.Add(time.Minute), cond.LastTransitionTime)
+		}
+	}
+
+	events, err := client.CoreV1().Events(config.DefaultK8sNodeEventNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events.Items) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events.Items))
+	}
+	ev := events.Items[0]
+	if ev.Type != corev1.EventTypeWarning || ev.Reason != "NvlinkDown" || ev.InvolvedObject.Name != "node1" || ev.Source.Component != eventSource {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+}
+
+func TestCreateEventsErrors(t *testing.T) {
+	ctx := context.Background()
+	db, err := state.Open(filepath.Join(t.TempDir(), "gpud.state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := components_events_state.CreateTable(ctx, db, components_events_state.DefaultTableName); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"exists", "invalid", "unavailable"} {
+		ev := components.Event{Time: metav1.Now(), Name: name, Type: components.EventTypeError}
+		if err := components_events_state.Insert(ctx, db, components_events_state.DefaultTableName, "test", ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client := fake.NewSimpleClientset()
+	var unavailable bool
+	client.PrependReactor("create", "events", func(action k8s_testing.Action) (bool, runtime.Object, error) {
+		ev := action.(k8s_testing.CreateAction).GetObject().(*corev1.Event)
+		gr := schema.GroupResource{Resource: "events"}
+		switch ev.Reason {
+		case "Exists":
+			return true, nil, apierrors.NewAlreadyExists(gr, ev.Name)
+		case "Invalid":
+			return true, nil, apierrors.NewBadRequest("invalid event")
+		case "Unavailable":
+			if unavailable {
+				return true, nil, apierrors.NewServiceUnavailable("unavailable")
+			}
+		}
+		return false, nil, nil
+	})
+	u, err := New(config.K8sNode{NodeName: "node1"}, db, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the already existing and the invalid events are not retried
+	unavailable = true
+	if err := u.createEvents(ctx, "node1"); err == nil {
+		t.Fatal("expected error on the unavailable server")
+	}
+	if u.lastEventID != 2 {
+		t.Fatalf("expected the last event ID 2, got %d", u.lastEventID)
+	}
+
+	// the server error is retried
+	unavailable = false
+	if err := u.createEvents(ctx, "node1"); err != nil {
+		t.Fatal(err)
+	}
+	if u.lastEventID != 3 {
+		t.Fatalf("expected the last event ID 3, got %d", u.lastEventID)
+	}
+	events, err := client.CoreV1().Events(config.DefaultK8sNodeEventNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events.Items) != 1 || events.Items[0].Reason != "Unavailable" {
+		t.Fatalf("unexpected events %+v", events.Items)
+	}
+}
@@ -1,110 +1,65 @@
 package login
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"strings"
 
 	"github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/internal/controlplane"
 	"github.com/leptonai/gpud/log"
 	"github.com/leptonai/gpud/version"
 )
 
-func Login(name string, token string, endpoint string, components string, uid string) error {
+func Login(ctx context.Context, cp controlplane.ControlPlane, name string, token string, components string, uid string) error {
 	ip, err := PublicIP()
 	if err != nil {
 		return fmt.Errorf("failed to fetch public ip: %w", err)
 	}
 
-	type payload struct {
-		Name       string `json:"name"`
-		ID         string `json:"id"`
-		PublicIP   string `json:"public_ip"`
-		Provider   string `json:"provider"`
-		Components string `json:"components"`
-		Token      string `json:"token"`
-	}
-	type RespErr struct {
-		Error  string `json:"error"`
-		Status string `json:"status"`
-	}
-	content := payload{
+	err = cp.Login(ctx, controlplane.LoginRequest{
 		Name:       name,
 		ID:         uid,
 		PublicIP:   ip,
 		Provider:   "personal",
 		Components: components,
 		Token:      token,
-	}
-	rawPayload, _ := json.Marshal(&content)
-	response, err := http.Post(fmt.Sprintf("https://%s/api/v1/login", endpoint), "application/json", bytes.NewBuffer(rawPayload))
-	if err != nil {
+	})
+	var cpErr *controlplane.Error
+	if !errors.As(err, &cpErr) {
 		return err
 	}
-	defer response.Body.Close()
-	body, err := io.ReadAll(response.Body)
-	if err != nil {
-		return fmt.Errorf("error reading response body: %w", err)
+	if cpErr.Body != "" {
+		return fmt.Errorf("Error parsing error response\nResponse body: %s", cpErr.Body)
 	}
-	if response.StatusCode != http.StatusOK {
-		var errorResponse RespErr
-		err = json.Unmarshal(body, &errorResponse)
-		if err != nil {
-			return fmt.Errorf("Error parsing error response: %v\nResponse body: %s", err, body)
-		}
-		if strings.Contains(errorResponse.Status, "invalid workspace token") {
-			return fmt.Errorf("invalid token provided, please use the workspace token under Setting/Tokens and execute\n    gpud login --token yourToken")
-		}
-		return fmt.Errorf("\nCurrently, we only support machines with a public IP address. Please ensure that your public IP and port combination (%s:%d) is reachable.\nerror: %v", ip, config.DefaultGPUdPort, errorResponse)
+	if strings.Contains(cpErr.Response.Status, "invalid workspace token") {
+		return fmt.Errorf("invalid token provided, please use the workspace token under Setting/Tokens and execute\n    gpud login --token yourToken")
 	}
-	return nil
+	return fmt.Errorf("\nCurrently, we only support machines with a public IP address. Please ensure that your public IP and port combination (%s:%d) is reachable.\nerror: %v", ip, config.DefaultGPUdPort, cpErr.Response)
 }
 
-func Gossip(endpoint string, uid string, address string) error {
+func Gossip(ctx context.Context, cp controlplane.ControlPlane, uid string, address string) error {
 	if os.Getenv("GPUD_NO_USAGE_STATS") == "true" {
 		log.Logger.Debug("gossip skipped since GPUD_NO_USAGE_STATS=true specified")
 		return nil
 	}
 
-	type payload struct {
-		Name          string `json:"name"`
-		ID            string `json:"id"`
-		Provider      string `json:"provider"`
-		DaemonVersion string `json:"daemon_version"`
-	}
-	type RespErr struct {
-		Error  string `json:"error"`
-		Status string `json:"status"`
-	}
-	content := payload{
+	err := cp.Gossip(ctx, controlplane.GossipRequest{
 		Name:          uid,
 		ID:            uid,
 		Provider:      "personal",
 		DaemonVersion: version.Version,
+	})
+	var cpErr *controlplane.Error
+	if errors.As(err, &cpErr) && cpErr.Body == "" {
+		// the error response is not an error for gossip
+		log.Logger.Debugw("gossip failed", "error", cpErr)
+		return nil
 	}
-	rawPayload, _ := json.Marshal(&content)
-	response, err := http.Post(fmt.Sprintf("https://%s/api/v1/gossip", endpoint), "application/json", bytes.NewBuffer(rawPayload))
-	if err != nil {
-		return err
-	}
-	defer response.Body.Close()
-	body, err := io.ReadAll(response.Body)
-	if err != nil {
-		return fmt.Errorf("error reading response body: %w", err)
-	}
-	if response.StatusCode != http.StatusOK {
-		var errorResponse RespErr
-		err = json.Unmarshal(body, &errorResponse)
-		if err != nil {
-			return fmt.Errorf("Error parsing error response: %v\nResponse body: %s", err, body)
-		}
-	}
-	return nil
+	return err
 }
 
 func PublicIP() (string, error) {
@@ -0,0 +1,223 @@
+// Package notifier sends the component state transitions and events to the webhooks,
+// so that the critical changes leave the box without the control plane session,
+// and to the subscribers (e.g., the session to push them to the control plane).
+package notifier
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	components_events_state "github.com/leptonai/gpud/components/events/state"
+	components_states_state "github.com/leptonai/gpud/components/states/state"
+	"github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/log"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// pollInterval is the interval to read the new state changes and events
+	// from the state database.
+	pollInterval = 5 * time.Second
+	// readLimit bounds the number of records to read at once.
+	readLimit = 1000
+)
+
+const (
+	KindState = "state"
+	KindEvent = "event"
+)
+
+// Payload is the notification sent to the webhooks.
+type Payload struct {
+	// Kind is either "state" or "event".
+	Kind string `json:"kind"`
+
+	MachineID   string            `json:"machine_id"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	Component string      `json:"component"`
+	Time      metav1.Time `json:"time"`
+
+	// Set for the state transitions.
+	Health         *components.HealthStatus `json:"health,omitempty"`
+	PreviousHealth *components.HealthStatus `json:"previous_health,omitempty"`
+	States         []components.State       `json:"states,omitempty"`
+
+	// Set for the events.
+	Event *components.Event `json:"event,omitempty"`
+}
+
+// Notifier reads the state changes and events persisted by the server,
+// and sends the notifications to the configured webhooks.
+type Notifier struct {
+	db          *sql.DB
+	machineID   string
+	annotations map[string]string
+
+	webhooks []*webhook
+
+	lastStateID int64
+	lastEventID int64
+	lastHealth  map[string]components.HealthStatus
+
+	subsMu  sync.RWMutex
+	subs    map[int]func(Payload)
+	nextSub int
+}
+
+func New(cfg config.Notifier, db *sql.DB, machineID string, annotations map[string]string) (*Notifier, error) {
+	cfg.Webhooks = append([]config.Webhook{}, cfg.Webhooks...)
+	cfg.SetDefaultsIfNotSet()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	n := &Notifier{
+		db:          db,
+		machineID:   machineID,
+		annotations: annotations,
+		lastHealth:  make(map[string]components.HealthStatus),
+		subs:        make(map[int]func(Payload)),
+	}
+	for _, wc := range cfg.Webhooks {
+		w, err := newWebhook(wc)
+		if err != nil {
+			return nil, fmt.Errorf("webhook %s: %w", wc.Name, err)
+		}
+		n.webhooks = append(n.webhooks, w)
+	}
+	return n, nil
+}
+
+// Start starts sending the notifications of the state changes and events
+// persisted after the start, until the context is canceled.
+func (n *Notifier) Start(ctx context.Context) error {
+	// the last known health to detect the transitions
+	for name := range components.GetAllComponents() {
+		r, err := components_states_state.ReadLast(ctx, n.db, components_states_state.DefaultTableName, name)
+		if err != nil {
+			return err
+		}
+		if r != nil {
+			n.lastHealth[name] = r.Health
+		}
+	}
+
+	var err error
+	n.lastStateID, err = components_states_state.LastID(ctx, n.db, components_states_state.DefaultTableName)
+	if err != nil {
+		return err
+	}
+	n.lastEventID, err = components_events_state.LastID(ctx, n.db, components_events_state.DefaultTableName)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range n.webhooks {
+		go w.run(ctx)
+	}
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if err := n.poll(ctx); err != nil {
+				log.Logger.Warnw("failed to poll notifications", "error", err)
+			}
+		}
+	}()
+	return nil
+}
+
+func (n *Notifier) poll(ctx context.Context) error {
+	states, err := components_states_state.ReadAfter(ctx, n.db, components_states_state.DefaultTableName, n.lastStateID, components_states_state.WithLimit(readLimit))
+	if err != nil {
+		return err
+	}
+	for _, r := range states {
+		n.lastStateID = r.ID
+
+		cur := r.Health
+		prev, known := n.lastHealth[r.Component]
+		n.lastHealth[r.Component] = cur
+
+		// only the transitions of the healthy-ness or severity,
+		// or the unhealthy state of a newly seen component
+		if known && prev.Healthy == cur.Healthy && prev.Severity == cur.Severity {
+			continue
+		}
+		if !known && cur.Healthy {
+			continue
+		}
+
+		p := n.newPayload(KindState, r.Component, cur.Time)
+		p.Health = &cur
+		if known {
+			p.PreviousHealth = &prev
+		}
+		p.States = r.States
+		n.notify(p)
+	}
+
+	events, err := components_events_state.ReadAfter(ctx, n.db, components_events_state.DefaultTableName, n.lastEventID, components_events_state.WithLimit(readLimit))
+	if err != nil {
+		return err
+	}
+	for _, r := range events {
+		n.lastEventID = r.ID
+
+		ev := r.Event
+		p := n.newPayload(KindEvent, r.Component, ev.Time)
+		p.Event = &ev
+		n.notify(p)
+	}
+	return nil
+}
+
+func (n *Notifier) newPayload(kind string, componentName string, t metav1.Time) Payload {
+	return Payload{
+		Kind:        kind,
+		MachineID:   n.machineID,
+		Annotations: n.annotations,
+		Component:   componentName,
+		Time:        t,
+	}
+}
+
+// Subscribe calls the function with every notification (without the webhook filters),
+// until the returned function is called.
+// The function must not block, since it is called in the polling loop.
+func (n *Notifier) Subscribe(fn func(Payload)) (unsubscribe func()) {
+	n.subsMu.Lock()
+	id := n.nextSub
+	n.nextSub++
+	n.subs[id] = fn
+	n.subsMu.Unlock()
+
+	return func() {
+		n.subsMu.Lock()
+		delete(n.subs, id)
+		n.subsMu.Unlock()
+	}
+}
+
+func (n *Notifier) notify(p Payload) {
+	for _, w := range n.webhooks {
+		w.enqueue(p)
+	}
+
+	n.subsMu.RLock()
+	defer n.subsMu.RUnlock()
+	for _, fn := range n.subs {
+		fn(p)
+	}
+}
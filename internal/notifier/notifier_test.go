@@ -0,0 +1,253 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	components_events_state "github.com/leptonai/gpud/components/events/state"
+	"github.com/leptonai/gpud/components/state"
+	components_states_state "github.com/leptonai/gpud/components/states/state"
+	"github.com/leptonai/gpud/config"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	bodies [][]byte
+	// status codes to respond in order, then 200
+	codes []int
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	b, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bodies = append(r.bodies, b)
+	if len(r.codes) > 0 {
+		code := r.codes[0]
+		r.codes = r.codes[1:]
+		w.WriteHeader(code)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (r *recorder) received() [][]byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]byte{}, r.bodies...)
+}
+
+func testWebhookConfig(url string) config.Webhook {
+	cfg := config.Webhook{URL: url}
+	cfg.SetDefaultsIfNotSet()
+	cfg.InitialBackoff = metav1.Duration{Duration: time.Millisecond}
+	cfg.MaxBackoff = metav1.Duration{Duration: 5 * time.Millisecond}
+	return cfg
+}
+
+func TestWebhookSendRetry(t *testing.T) {
+	rec := &recorder{codes: []int{http.StatusInternalServerError, http.StatusTooManyRequests}}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	w, err := newWebhook(testWebhookConfig(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := Payload{Kind: KindState, MachineID: "m1", Component: "xid", Health: &components.HealthStatus{Healthy: false}}
+	if err := w.send(context.Background(), p); err != nil {
+		t.Fatal(err)
+	}
+	bodies := rec.received()
+	if len(bodies) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(bodies))
+	}
+	var got Payload
+	if err := json.Unmarshal(bodies[2], &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.MachineID != "m1" || got.Component != "xid" {
+		t.Fatalf("unexpected payload %+v", got)
+	}
+
+	// not retried on the client errors
+	rec.codes = []int{http.StatusBadRequest}
+	if err := w.send(context.Background(), p); err == nil {
+		t.Fatal("expected error")
+	}
+	if n := len(rec.received()); n != 4 {
+		t.Fatalf("expected 4 requests, got %d", n)
+	}
+}
+
+func TestWebhookSendNoRetry(t *testing.T) {
+	rec := &recorder{codes: []int{http.StatusInternalServerError}}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	noRetries := 0
+	cfg := config.Webhook{URL: srv.URL, MaxRetries: &noRetries}
+	cfg.SetDefaultsIfNotSet()
+	w, err := newWebhook(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.send(context.Background(), Payload{Kind: KindState, Component: "xid", Health: &components.HealthStatus{}}); err == nil {
+		t.Fatal("expected error")
+	}
+	if n := len(rec.received()); n != 1 {
+		t.Fatalf("expected 1 request without retries, got %d", n)
+	}
+}
+
+func TestWebhookTemplate(t *testing.T) {
+	cfg := testWebhookConfig("http://localhost")
+	cfg.Template = `{"text": {{ printf "%s is %s" .Component .Health.Severity | json }}}`
+	w, err := newWebhook(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := w.render(Payload{Kind: KindState, Component: "xid", Health: &components.HealthStatus{Severity: components.SeverityFatal}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"text": "xid is fatal"}` {
+		t.Fatalf("unexpected body %s", b)
+	}
+
+	cfg.Template = "{{ .Unknown"
+	if _, err := newWebhook(cfg); err == nil {
+		t.Fatal("expected template parse error")
+	}
+}
+
+func TestWebhookFilterAndDedup(t *testing.T) {
+	cfg := testWebhookConfig("http://localhost")
+	cfg.ExcludeComponents = []string{"cpu"}
+	w, err := newWebhook(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unhealthy := Payload{Kind: KindState, Component: "xid", Health: &components.HealthStatus{Healthy: false, Severity: components.SeverityCritical}}
+	w.enqueue(unhealthy)
+	w.enqueue(unhealthy)
+	w.enqueue(Payload{Kind: KindState, Component: "cpu", Health: &components.HealthStatus{Healthy: false}})
+	w.enqueue(Payload{Kind: KindEvent, Component: "xid", Event: &components.Event{Name: "xid", Type: components.EventTypeInfo}})
+	w.enqueue(Payload{Kind: KindEvent, Component: "xid", Event: &components.Event{Name: "xid", Type: components.EventTypeError}})
+
+	if n := len(w.queue); n != 2 {
+		t.Fatalf("expected 2 queued notifications, got %d", n)
+	}
+}
+
+func TestWebhookDedupFlapping(t *testing.T) {
+	w, err := newWebhook(testWebhookConfig("http://localhost"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unhealthy := Payload{Kind: KindState, Component: "xid", Health: &components.HealthStatus{Healthy: false, Severity: components.SeverityCritical}}
+	healthy := Payload{Kind: KindState, Component: "xid", Health: &components.HealthStatus{Healthy: true}}
+	w.enqueue(unhealthy)
+	w.enqueue(healthy)
+	w.enqueue(unhealthy)
+	w.enqueue(unhealthy)
+
+	// the second unhealthy transition within the window is sent, only the repeated one is not
+	var got []bool
+	for len(w.queue) > 0 {
+		got = append(got, (<-w.queue).Health.Healthy)
+	}
+	if len(got) != 3 || got[0] || !got[1] || got[2] {
+		t.Fatalf("expected unhealthy, healthy, unhealthy, got %v", got)
+	}
+}
+
+func TestNotifierPoll(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := state.Open(filepath.Join(t.TempDir(), "gpud.state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := components_states_state.CreateTable(ctx, db, components_states_state.DefaultTableName); err != nil {
+		t.Fatal(err)
+	}
+	if err := components_events_state.CreateTable(ctx, db, components_events_state.DefaultTableName); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	n, err := New(config.Notifier{Webhooks: []config.Webhook{testWebhookConfig(srv.URL)}}, db, "m1", map[string]string{"team": "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var subscribed []Payload
+	unsubscribe := n.Subscribe(func(p Payload) {
+		subscribed = append(subscribed, p)
+	})
+
+	now := metav1.Now()
+	healths := []components.HealthStatus{
+		{Healthy: true, Severity: components.SeverityInfo, Time: now},
+		{Healthy: true, Severity: components.SeverityInfo, Reason: "changed reason", Time: now},
+		{Healthy: false, Severity: components.SeverityCritical, Reason: "xid 79", Time: now},
+	}
+	for _, h := range healths {
+		if err := components_states_state.Insert(ctx, db, components_states_state.DefaultTableName, "xid", h, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := components_events_state.Insert(ctx, db, components_events_state.DefaultTableName, "xid", components.Event{Time: now, Name: "xid", Type: components.EventTypeError, Message: "xid 79"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := n.poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(n.webhooks[0].queue) != 2 {
+		t.Fatalf("expected 2 queued notifications, got %d", len(n.webhooks[0].queue))
+	}
+
+	st := <-n.webhooks[0].queue
+	if st.Kind != KindState || st.Health.Healthy || st.PreviousHealth == nil || !st.PreviousHealth.Healthy {
+		t.Fatalf("unexpected state notification %+v", st)
+	}
+	if st.MachineID != "m1" || st.Annotations["team"] != "a" {
+		t.Fatalf("unexpected machine info %+v", st)
+	}
+	ev := <-n.webhooks[0].queue
+	if ev.Kind != KindEvent || ev.Event.Message != "xid 79" {
+		t.Fatalf("unexpected event notification %+v", ev)
+	}
+	if len(subscribed) != 2 || subscribed[0].Kind != KindState || subscribed[1].Kind != KindEvent {
+		t.Fatalf("unexpected subscribed notifications %+v", subscribed)
+	}
+	unsubscribe()
+
+	// nothing new
+	if err := n.poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(n.webhooks[0].queue) != 0 {
+		t.Fatalf("expected no queued notifications, got %d", len(n.webhooks[0].queue))
+	}
+}
@@ -0,0 +1,224 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/log"
+)
+
+// queueSize bounds the pending notifications per webhook,
+// so that a slow webhook does not block the others.
+const queueSize = 100
+
+type webhook struct {
+	cfg  config.Webhook
+	tmpl *template.Template
+	cli  *http.Client
+
+	queue chan Payload
+
+	mu   sync.Mutex
+	sent map[string]time.Time
+}
+
+func newWebhook(cfg config.Webhook) (*webhook, error) {
+	w := &webhook{
+		cfg:   cfg,
+		cli:   &http.Client{Timeout: cfg.Timeout.Duration},
+		queue: make(chan Payload, queueSize),
+		sent:  make(map[string]time.Time),
+	}
+	if cfg.Template != "" {
+		tmpl, err := template.New(cfg.Name).Funcs(template.FuncMap{
+			"json": func(v any) (string, error) {
+				b, err := json.Marshal(v)
+				return string(b), err
+			},
+		}).Parse(cfg.Template)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template: %w", err)
+		}
+		w.tmpl = tmpl
+	}
+	return w, nil
+}
+
+// match returns true if the payload passes the webhook filters.
+func (w *webhook) match(p Payload) bool {
+	if len(w.cfg.Components) > 0 && !contains(w.cfg.Components, p.Component) {
+		return false
+	}
+	if contains(w.cfg.ExcludeComponents, p.Component) {
+		return false
+	}
+
+	switch p.Kind {
+	case KindState:
+		return !w.cfg.DisableStates
+	case KindEvent:
+		if w.cfg.DisableEvents {
+			return false
+		}
+		if len(w.cfg.EventTypes) == 0 {
+			return p.Event.Type == components.EventTypeError
+		}
+		return contains(w.cfg.EventTypes, p.Event.Type)
+	default:
+		return false
+	}
+}
+
+// dedupKey returns the key to identify the same notification.
+func dedupKey(p Payload) string {
+	switch p.Kind {
+	case KindState:
+		return fmt.Sprintf("%s/%s/%v/%s", p.Kind, p.Component, p.Health.Healthy, p.Health.Severity)
+	case KindEvent:
+		return fmt.Sprintf("%s/%s/%s/%s/%s", p.Kind, p.Component, p.Event.Name, p.Event.Type, p.Event.Message)
+	default:
+		return p.Kind + "/" + p.Component
+	}
+}
+
+// enqueue queues the notification if it passes the filters
+// and the same notification was not sent within the dedup window.
+func (w *webhook) enqueue(p Payload) {
+	if !w.match(p) {
+		return
+	}
+
+	now := time.Now()
+	key := dedupKey(p)
+
+	w.mu.Lock()
+	for k, t := range w.sent {
+		if now.Sub(t) >= w.cfg.DedupWindow.Duration {
+			delete(w.sent, k)
+		}
+	}
+	_, dup := w.sent[key]
+	if !dup {
+		if p.Kind == KindState {
+			// the previous transitions of the component are no longer the same notification
+			// (e.g., unhealthy -> healthy -> unhealthy within the window sends both unhealthy)
+			prefix := fmt.Sprintf("%s/%s/", p.Kind, p.Component)
+			for k := range w.sent {
+				if strings.HasPrefix(k, prefix) {
+					delete(w.sent, k)
+				}
+			}
+		}
+		w.sent[key] = now
+	}
+	w.mu.Unlock()
+
+	if dup {
+		log.Logger.Debugw("skipping duplicate notification", "webhook", w.cfg.Name, "key", key)
+		return
+	}
+
+	select {
+	case w.queue <- p:
+	default:
+		log.Logger.Warnw("webhook queue is full -- dropping notification", "webhook", w.cfg.Name, "key", key)
+	}
+}
+
+func (w *webhook) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case p := <-w.queue:
+			if err := w.send(ctx, p); err != nil {
+				log.Logger.Warnw("failed to send notification", "webhook", w.cfg.Name, "component", p.Component, "error", err)
+			}
+		}
+	}
+}
+
+// send posts the payload to the webhook, retrying with the exponential backoff.
+func (w *webhook) send(ctx context.Context, p Payload) error {
+	body, err := w.render(p)
+	if err != nil {
+		return err
+	}
+
+	backoff := w.cfg.InitialBackoff.Duration
+	for attempt := 0; ; attempt++ {
+		retryable, err := w.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= *w.cfg.MaxRetries {
+			return err
+		}
+
+		log.Logger.Debugw("retrying notification", "webhook", w.cfg.Name, "attempt", attempt+1, "backoff", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > w.cfg.MaxBackoff.Duration {
+			backoff = w.cfg.MaxBackoff.Duration
+		}
+	}
+}
+
+// post returns true if the failed request can be retried.
+func (w *webhook) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := w.cli.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retryable, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+}
+
+func (w *webhook) render(p Payload) ([]byte, error) {
+	if w.tmpl == nil {
+		return json.Marshal(p)
+	}
+	buf := new(bytes.Buffer)
+	if err := w.tmpl.Execute(buf, p); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package otlp
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	components_events_state "github.com/leptonai/gpud/components/events/state"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// convertMetricFamilies converts the gathered Prometheus metrics
+// into the OTLP metrics, with the cumulative values since "start".
+func convertMetricFamilies(mfs []*dto.MetricFamily, start time.Time, now time.Time) []metric {
+	ms := make([]metric, 0, len(mfs))
+	for _, mf := range mfs {
+		m := metric{
+			Name:        mf.GetName(),
+			Description: mf.GetHelp(),
+		}
+		switch mf.GetType() {
+		case dto.MetricType_COUNTER:
+			m.Sum = &sum{AggregationTemporality: aggregationTemporalityCumulative, IsMonotonic: true}
+		case dto.MetricType_HISTOGRAM:
+			m.Histogram = &histogram{AggregationTemporality: aggregationTemporalityCumulative}
+		case dto.MetricType_SUMMARY:
+			m.Summary = &summary{}
+		default:
+			// gauge and untyped
+			m.Gauge = &gauge{}
+		}
+
+		for _, pm := range mf.GetMetric() {
+			attrs := labelAttributes(pm.GetLabel())
+			ts := now
+			if pm.TimestampMs != nil {
+				ts = time.UnixMilli(pm.GetTimestampMs())
+			}
+
+			switch {
+			case m.Sum != nil:
+				v := pm.GetCounter().GetValue()
+				if !finite(v) {
+					continue
+				}
+				m.Sum.DataPoints = append(m.Sum.DataPoints, numberDataPoint{
+					Attributes:        attrs,
+					StartTimeUnixNano: unixNano(start),
+					TimeUnixNano:      unixNano(ts),
+					AsDouble:          v,
+				})
+
+			case m.Histogram != nil:
+				h := pm.GetHistogram()
+				if !finite(h.GetSampleSum()) {
+					continue
+				}
+				dp := histogramDataPoint{
+					Attributes:        attrs,
+					StartTimeUnixNano: unixNano(start),
+					TimeUnixNano:      unixNano(ts),
+					Count:             strconv.FormatUint(h.GetSampleCount(), 10),
+					Sum:               h.GetSampleSum(),
+				}
+				// Prometheus buckets are cumulative, while OTLP buckets are not,
+				// and the last OTLP bucket counts the samples above the last bound
+				var prev uint64
+				for _, b := range h.GetBucket() {
+					if !finite(b.GetUpperBound()) {
+						continue
+					}
+					dp.ExplicitBounds = append(dp.ExplicitBounds, b.GetUpperBound())
+					dp.BucketCounts = append(dp.BucketCounts, strconv.FormatUint(b.GetCumulativeCount()-prev, 10))
+					prev = b.GetCumulativeCount()
+				}
+				dp.BucketCounts = append(dp.BucketCounts, strconv.FormatUint(h.GetSampleCount()-prev, 10))
+				m.Histogram.DataPoints = append(m.Histogram.DataPoints, dp)
+
+			case m.Summary != nil:
+				s := pm.GetSummary()
+				if !finite(s.GetSampleSum()) {
+					continue
+				}
+				dp := summaryDataPoint{
+					Attributes:        attrs,
+					StartTimeUnixNano: unixNano(start),
+					TimeUnixNano:      unixNano(ts),
+					Count:             strconv.FormatUint(s.GetSampleCount(), 10),
+					Sum:               s.GetSampleSum(),
+				}
+				for _, q := range s.GetQuantile() {
+					if !finite(q.GetValue()) {
+						continue
+					}
+					dp.QuantileValues = append(dp.QuantileValues, valueAtQuantile{Quantile: q.GetQuantile(), Value: q.GetValue()})
+				}
+				m.Summary.DataPoints = append(m.Summary.DataPoints, dp)
+
+			default:
+				var v float64
+				if pm.Gauge != nil {
+					v = pm.GetGauge().GetValue()
+				} else {
+					v = pm.GetUntyped().GetValue()
+				}
+				if !finite(v) {
+					continue
+				}
+				m.Gauge.DataPoints = append(m.Gauge.DataPoints, numberDataPoint{
+					Attributes:   attrs,
+					TimeUnixNano: unixNano(ts),
+					AsDouble:     v,
+				})
+			}
+		}
+
+		if m.numPoints() > 0 {
+			ms = append(ms, m)
+		}
+	}
+	return ms
+}
+
+func labelAttributes(labels []*dto.LabelPair) []keyValue {
+	m := make(map[string]string, len(labels))
+	for _, l := range labels {
+		m[l.GetName()] = l.GetValue()
+	}
+	return attributes(m)
+}
+
+// convertComponentMetrics converts the component metrics
+// (i.e., the rows in the metrics table) into the OTLP gauges,
+// with the component name and the secondary name as the attributes.
+func convertComponentMetrics(componentName string, cms []components.Metric) []metric {
+	byName := make(map[string]*metric)
+	for _, cm := range cms {
+		if !finite(cm.Value) {
+			continue
+		}
+		m, ok := byName[cm.MetricName]
+		if !ok {
+			m = &metric{Name: cm.MetricName, Gauge: &gauge{}}
+			byName[cm.MetricName] = m
+		}
+
+		attrs := make(map[string]string, len(cm.ExtraInfo)+2)
+		for k, v := range cm.ExtraInfo {
+			attrs[k] = v
+		}
+		attrs["component"] = componentName
+		if cm.MetricSecondaryName != "" {
+			attrs["secondary_name"] = cm.MetricSecondaryName
+		}
+		m.Gauge.DataPoints = append(m.Gauge.DataPoints, numberDataPoint{
+			Attributes:   attributes(attrs),
+			TimeUnixNano: unixNano(time.Unix(cm.UnixSeconds, 0)),
+			AsDouble:     cm.Value,
+		})
+	}
+
+	ms := make([]metric, 0, len(byName))
+	for _, m := range byName {
+		ms = append(ms, *m)
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		return ms[i].Name < ms[j].Name
+	})
+	return ms
+}
+
+// convertEvent converts the component event into the OTLP log record.
+func convertEvent(r components_events_state.Record, observed time.Time) logRecord {
+	attrs := make(map[string]string, len(r.Event.ExtraInfo)+3)
+	for k, v := range r.Event.ExtraInfo {
+		attrs[k] = v
+	}
+	attrs["component"] = r.Component
+	if r.Event.Name != "" {
+		attrs["event.name"] = r.Event.Name
+	}
+	if r.Event.Type != "" {
+		attrs["event.type"] = r.Event.Type
+	}
+
+	body := r.Event.Message
+	if body == "" {
+		body = r.Event.Name
+	}
+	lr := logRecord{
+		TimeUnixNano:         unixNano(r.Event.Time.Time),
+		ObservedTimeUnixNano: unixNano(observed),
+		SeverityText:         r.Event.Type,
+		Body:                 anyValue{StringValue: body},
+		Attributes:           attributes(attrs),
+	}
+	switch r.Event.Type {
+	case components.EventTypeError:
+		lr.SeverityNumber = severityNumberError
+	case components.EventTypeWarn:
+		lr.SeverityNumber = severityNumberWarn
+	case components.EventTypeInfo, components.EventTypeMetric:
+		lr.SeverityNumber = severityNumberInfo
+	}
+	return lr
+}
@@ -0,0 +1,313 @@
+// Package otlp exports the gpud metrics and events to an OpenTelemetry receiver
+// over OTLP/HTTP (JSON encoding), so that the node-local collector
+// does not need to scrape the Prometheus endpoint.
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	components_events_state "github.com/leptonai/gpud/components/events/state"
+	"github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/version"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	pathMetrics = "/v1/metrics"
+	pathLogs    = "/v1/logs"
+
+	scopeName = "github.com/leptonai/gpud"
+)
+
+// Exporter periodically pushes the registered Prometheus collectors,
+// the component metrics, and the component events (as log records)
+// to the OTLP/HTTP receiver.
+type Exporter struct {
+	cfg      config.OTLP
+	db       *sql.DB
+	gatherer prometheus.Gatherer
+	cli      *http.Client
+	resource resource
+
+	startTime time.Time
+
+	lastEventID int64
+	// last exported unix seconds of the component metrics, keyed by the component name
+	lastMetricUnix map[string]int64
+}
+
+// New creates the exporter, with the machine ID and the annotations
+// as the resource attributes.
+func New(cfg config.OTLP, db *sql.DB, gatherer prometheus.Gatherer, machineID string, annotations map[string]string) (*Exporter, error) {
+	cfg.SetDefaultsIfNotSet()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	attrs := make(map[string]string, len(annotations)+3)
+	for k, v := range annotations {
+		attrs[k] = v
+	}
+	attrs["service.name"] = "gpud"
+	attrs["service.version"] = version.Version
+	attrs["gpud.machine_id"] = machineID
+
+	return &Exporter{
+		cfg:            cfg,
+		db:             db,
+		gatherer:       gatherer,
+		cli:            &http.Client{Timeout: cfg.Timeout.Duration},
+		resource:       resource{Attributes: attributes(attrs)},
+		lastMetricUnix: make(map[string]int64),
+	}, nil
+}
+
+// Start starts exporting the metrics and the events
+// recorded after the start, until the context is canceled.
+func (e *Exporter) Start(ctx context.Context) error {
+	e.startTime = time.Now()
+
+	var err error
+	e.lastEventID, err = components_events_state.LastID(ctx, e.db, components_events_state.DefaultTableName)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(e.cfg.Interval.Duration)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			e.export(ctx)
+		}
+	}()
+	return nil
+}
+
+func (e *Exporter) export(ctx context.Context) {
+	if !e.cfg.DisableMetrics {
+		if err := e.exportMetrics(ctx, time.Now()); err != nil {
+			log.Logger.Warnw("failed to export otlp metrics", "endpoint", e.cfg.Endpoint, "error", err)
+		}
+	}
+	if !e.cfg.DisableEvents {
+		if err := e.exportEvents(ctx, time.Now()); err != nil {
+			log.Logger.Warnw("failed to export otlp events", "endpoint", e.cfg.Endpoint, "error", err)
+		}
+	}
+}
+
+// exportMetrics exports the current values of the Prometheus collectors,
+// and the component metrics recorded since the last export.
+// The component metrics are only exported up to the last complete second.
+// Each component is advanced once the batch with its last metric is sent,
+// so that only the components in the failed and the following batches
+// are exported again on the next interval.
+func (e *Exporter) exportMetrics(ctx context.Context, now time.Time) error {
+	var ms []metric
+	if e.gatherer != nil {
+		mfs, err := e.gatherer.Gather()
+		if err != nil {
+			// partial results are still returned with the error
+			log.Logger.Warnw("failed to gather prometheus metrics", "error", err)
+		}
+		ms = append(ms, convertMetricFamilies(mfs, e.startTime, now)...)
+	}
+
+	points := countPoints(ms)
+
+	all := components.GetAllComponents()
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	until := now.Unix()
+	// index of the batch with the last metric of the component,
+	// -1 if the component has no metric to export
+	lastBatch := make(map[string]int)
+	for _, name := range names {
+		c := all[name]
+		since := e.startTime
+		if last, ok := e.lastMetricUnix[name]; ok {
+			since = time.Unix(last+1, 0)
+		}
+		cms, err := c.Metrics(ctx, since)
+		if err != nil {
+			log.Logger.Warnw("failed to read component metrics", "component", name, "error", err)
+			continue
+		}
+
+		filtered := make([]components.Metric, 0, len(cms))
+		for _, cm := range cms {
+			if cm.UnixSeconds < since.Unix() || cm.UnixSeconds >= until {
+				continue
+			}
+			filtered = append(filtered, cm)
+		}
+		converted := convertComponentMetrics(name, filtered)
+		ms = append(ms, converted...)
+
+		lastBatch[name] = -1
+		if n := countPoints(converted); n > 0 {
+			points += n
+			// all the batches are full except the last one
+			lastBatch[name] = (points - 1) / e.cfg.MaxBatchSize
+		}
+	}
+
+	advance := func(sent int) {
+		for name, b := range lastBatch {
+			if b <= sent {
+				e.lastMetricUnix[name] = until - 1
+				delete(lastBatch, name)
+			}
+		}
+	}
+	advance(-1)
+
+	for i, batch := range batchMetrics(ms, e.cfg.MaxBatchSize) {
+		req := exportMetricsServiceRequest{
+			ResourceMetrics: []resourceMetrics{{
+				Resource: e.resource,
+				ScopeMetrics: []scopeMetrics{{
+					Scope:   instrumentationScope{Name: scopeName, Version: version.Version},
+					Metrics: batch,
+				}},
+			}},
+		}
+		if err := e.send(ctx, pathMetrics, req); err != nil {
+			return err
+		}
+		advance(i)
+	}
+	return nil
+}
+
+// exportEvents exports the events persisted since the last export.
+// The events are exported again on the next interval if the export fails.
+func (e *Exporter) exportEvents(ctx context.Context, now time.Time) error {
+	for {
+		records, err := components_events_state.ReadAfter(ctx, e.db, components_events_state.DefaultTableName, e.lastEventID, components_events_state.WithLimit(e.cfg.MaxBatchSize))
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+
+		lrs := make([]logRecord, 0, len(records))
+		for _, r := range records {
+			lrs = append(lrs, convertEvent(r, now))
+		}
+		req := exportLogsServiceRequest{
+			ResourceLogs: []resourceLogs{{
+				Resource: e.resource,
+				ScopeLogs: []scopeLogs{{
+					Scope:      instrumentationScope{Name: scopeName, Version: version.Version},
+					LogRecords: lrs,
+				}},
+			}},
+		}
+		if err := e.send(ctx, pathLogs, req); err != nil {
+			return err
+		}
+		e.lastEventID = records[len(records)-1].ID
+
+		if len(records) < e.cfg.MaxBatchSize {
+			return nil
+		}
+	}
+}
+
+// send posts the request to the receiver, retrying with the exponential backoff.
+func (e *Exporter) send(ctx context.Context, path string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if e.cfg.Gzip {
+		buf := new(bytes.Buffer)
+		gw := gzip.NewWriter(buf)
+		if _, err := gw.Write(body); err != nil {
+			return err
+		}
+		if err := gw.Close(); err != nil {
+			return err
+		}
+		body = buf.Bytes()
+	}
+
+	url := strings.TrimSuffix(e.cfg.Endpoint, "/") + path
+	backoff := e.cfg.InitialBackoff.Duration
+	for attempt := 0; ; attempt++ {
+		retryable, err := e.post(ctx, url, body)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= *e.cfg.MaxRetries {
+			return err
+		}
+
+		log.Logger.Debugw("retrying otlp export", "url", url, "attempt", attempt+1, "backoff", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > e.cfg.MaxBackoff.Duration {
+			backoff = e.cfg.MaxBackoff.Duration
+		}
+	}
+}
+
+// post returns true if the failed request can be retried.
+// ref. https://opentelemetry.io/docs/specs/otlp/#retryable-response-codes
+func (e *Exporter) post(ctx context.Context, url string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.cfg.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range e.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.cli.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return false, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+}
@@ -0,0 +1,349 @@
+package otlp
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	components_events_state "github.com/leptonai/gpud/components/events/state"
+	"github.com/leptonai/gpud/components/state"
+	"github.com/leptonai/gpud/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// receiver is a stand-in for the OTLP/HTTP receiver.
+type receiver struct {
+	mu       sync.Mutex
+	metrics  []exportMetricsServiceRequest
+	logs     []exportLogsServiceRequest
+	requests int
+	// status codes to respond in order, then 200
+	codes []int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var body io.Reader = req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		gr, err := gzip.NewReader(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body = gr
+	}
+	b, _ := io.ReadAll(body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests++
+	if len(r.codes) > 0 {
+		code := r.codes[0]
+		r.codes = r.codes[1:]
+		w.WriteHeader(code)
+		return
+	}
+
+	switch req.URL.Path {
+	case pathMetrics:
+		var m exportMetricsServiceRequest
+		if err := json.Unmarshal(b, &m); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.metrics = append(r.metrics, m)
+	case pathLogs:
+		var l exportLogsServiceRequest
+		if err := json.Unmarshal(b, &l); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.logs = append(r.logs, l)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func testConfig(endpoint string) config.OTLP {
+	cfg := config.OTLP{Endpoint: endpoint}
+	cfg.SetDefaultsIfNotSet()
+	cfg.InitialBackoff = metav1.Duration{Duration: time.Millisecond}
+	cfg.MaxBackoff = metav1.Duration{Duration: 5 * time.Millisecond}
+	return cfg
+}
+
+type testComponent struct {
+	name    string
+	metrics []components.Metric
+}
+
+func (c *testComponent) Name() string { return c.name }
+func (c *testComponent) States(context.Context) ([]components.State, error) {
+	return nil, nil
+}
+func (c *testComponent) Events(context.Context, time.Time) ([]components.Event, error) {
+	return nil, nil
+}
+func (c *testComponent) Metrics(_ context.Context, since time.Time) ([]components.Metric, error) {
+	var ms []components.Metric
+	for _, m := range c.metrics {
+		if m.UnixSeconds >= since.Unix() {
+			ms = append(ms, m)
+		}
+	}
+	return ms, nil
+}
+func (c *testComponent) Close() error { return nil }
+
+func TestExportMetrics(t *testing.T) {
+	rcv := &receiver{codes: []int{http.StatusServiceUnavailable}}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_total", Help: "test counter"}, []string{"gpu"})
+	hist := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_seconds", Buckets: []float64{1, 10}})
+	reg.MustRegister(counter, hist)
+	counter.WithLabelValues("0").Add(3)
+	hist.Observe(0.5)
+	hist.Observe(5)
+	hist.Observe(50)
+
+	now := time.Now()
+	c := &testComponent{name: "test-otlp", metrics: []components.Metric{
+		newMetric(now.Add(-2*time.Second), "test_temperature", "gpu0", 40),
+		newMetric(now.Add(-time.Second), "test_temperature", "gpu0", 41),
+		// not complete second, exported on the next interval
+		newMetric(now, "test_temperature", "gpu0", 42),
+	}}
+	if err := components.RegisterComponent(c.Name(), c); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = components.DeregisterComponent(c.Name())
+	}()
+
+	cfg := testConfig(srv.URL)
+	cfg.Gzip = true
+	e, err := New(cfg, nil, reg, "m1", map[string]string{"team": "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.startTime = now.Add(-time.Minute)
+
+	if err := e.exportMetrics(context.Background(), now); err != nil {
+		t.Fatal(err)
+	}
+	if rcv.requests != 2 || len(rcv.metrics) != 1 {
+		t.Fatalf("expected 1 retried metrics request, got %d requests", rcv.requests)
+	}
+
+	rm := rcv.metrics[0].ResourceMetrics[0]
+	res := map[string]string{}
+	for _, kv := range rm.Resource.Attributes {
+		res[kv.Key] = kv.Value.StringValue
+	}
+	if res["gpud.machine_id"] != "m1" || res["team"] != "a" {
+		t.Fatalf("unexpected resource attributes %v", res)
+	}
+
+	byName := map[string]metric{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		byName[m.Name] = m
+	}
+	if m := byName["test_total"]; m.Sum == nil || !m.Sum.IsMonotonic || m.Sum.DataPoints[0].AsDouble != 3 {
+		t.Fatalf("unexpected counter %+v", m)
+	}
+	h := byName["test_seconds"].Histogram
+	if h == nil || len(h.DataPoints[0].BucketCounts) != 3 {
+		t.Fatalf("unexpected histogram %+v", h)
+	}
+	for i, want := range []string{"1", "1", "1"} {
+		if h.DataPoints[0].BucketCounts[i] != want {
+			t.Fatalf("unexpected bucket counts %v", h.DataPoints[0].BucketCounts)
+		}
+	}
+	g := byName["test_temperature"].Gauge
+	if g == nil || len(g.DataPoints) != 2 {
+		t.Fatalf("expected 2 component metric points, got %+v", g)
+	}
+
+	// only the new component metrics
+	if err := e.exportMetrics(context.Background(), now.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range rcv.metrics[1].ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		if m.Name == "test_temperature" && (len(m.Gauge.DataPoints) != 1 || m.Gauge.DataPoints[0].AsDouble != 42) {
+			t.Fatalf("unexpected component metric %+v", m.Gauge)
+		}
+	}
+}
+
+func TestExportMetricsPartialBatches(t *testing.T) {
+	// the second batch fails without retry
+	rcv := &receiver{codes: []int{http.StatusOK, http.StatusBadRequest}}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	now := time.Now()
+	for _, name := range []string{"test-otlp-a", "test-otlp-b"} {
+		c := &testComponent{name: name, metrics: []components.Metric{
+			newMetric(now.Add(-2*time.Second), "test_temperature", "gpu0", 40),
+			newMetric(now.Add(-time.Second), "test_temperature", "gpu0", 41),
+		}}
+		if err := components.RegisterComponent(c.Name(), c); err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			_ = components.DeregisterComponent(c.Name())
+		}()
+	}
+
+	cfg := testConfig(srv.URL)
+	cfg.MaxBatchSize = 2
+	noRetry := 0
+	cfg.MaxRetries = &noRetry
+	e, err := New(cfg, nil, nil, "m1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.startTime = now.Add(-time.Minute)
+
+	if err := e.exportMetrics(context.Background(), now); err == nil {
+		t.Fatal("expected error on the second batch")
+	}
+	if rcv.requests != 2 {
+		t.Fatalf("expected 2 requests without retry, got %d", rcv.requests)
+	}
+	if _, ok := e.lastMetricUnix["test-otlp-a"]; !ok {
+		t.Fatal("expected the component in the sent batch to be advanced")
+	}
+	if _, ok := e.lastMetricUnix["test-otlp-b"]; ok {
+		t.Fatal("expected the component in the failed batch not to be advanced")
+	}
+
+	// only the metrics of the failed batch are exported again
+	if err := e.exportMetrics(context.Background(), now); err != nil {
+		t.Fatal(err)
+	}
+	if len(rcv.metrics) != 1 {
+		t.Fatalf("expected 1 metrics request, got %d", len(rcv.metrics))
+	}
+	for _, dp := range rcv.metrics[0].ResourceMetrics[0].ScopeMetrics[0].Metrics[0].Gauge.DataPoints {
+		for _, kv := range dp.Attributes {
+			if kv.Key == "component" && kv.Value.StringValue != "test-otlp-b" {
+				t.Fatalf("unexpected component %q exported again", kv.Value.StringValue)
+			}
+		}
+	}
+}
+
+func newMetric(t time.Time, name string, secondaryName string, v float64) components.Metric {
+	m := components.Metric{}
+	m.UnixSeconds = t.Unix()
+	m.MetricName = name
+	m.MetricSecondaryName = secondaryName
+	m.Value = v
+	return m
+}
+
+func TestExportEvents(t *testing.T) {
+	ctx := context.Background()
+	db, err := state.Open(filepath.Join(t.TempDir(), "gpud.state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := components_events_state.CreateTable(ctx, db, components_events_state.DefaultTableName); err != nil {
+		t.Fatal(err)
+	}
+
+	rcv := &receiver{}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	cfg := testConfig(srv.URL)
+	cfg.MaxBatchSize = 2
+	e, err := New(cfg, db, nil, "m1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := metav1.Now()
+	for _, ev := range []components.Event{
+		{Time: now, Name: "xid", Type: components.EventTypeError, Message: "xid 79"},
+		{Time: now, Name: "reboot", Type: components.EventTypeWarn},
+		{Time: now, Name: "info", Type: components.EventTypeInfo, Message: "ok", ExtraInfo: map[string]string{"gpu": "0"}},
+	} {
+		if err := components_events_state.Insert(ctx, db, components_events_state.DefaultTableName, "xid", ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// not advanced on the failed export
+	rcv.codes = []int{http.StatusBadRequest}
+	if err := e.exportEvents(ctx, time.Now()); err == nil {
+		t.Fatal("expected error")
+	}
+	if e.lastEventID != 0 {
+		t.Fatalf("expected last event id 0, got %d", e.lastEventID)
+	}
+
+	if err := e.exportEvents(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if len(rcv.logs) != 2 {
+		t.Fatalf("expected 2 batches, got %d", len(rcv.logs))
+	}
+	var lrs []logRecord
+	for _, l := range rcv.logs {
+		lrs = append(lrs, l.ResourceLogs[0].ScopeLogs[0].LogRecords...)
+	}
+	if len(lrs) != 3 {
+		t.Fatalf("expected 3 log records, got %d", len(lrs))
+	}
+	if lrs[0].SeverityNumber != severityNumberError || lrs[0].Body.StringValue != "xid 79" {
+		t.Fatalf("unexpected log record %+v", lrs[0])
+	}
+	if lrs[1].SeverityNumber != severityNumberWarn || lrs[1].Body.StringValue != "reboot" {
+		t.Fatalf("unexpected log record %+v", lrs[1])
+	}
+
+	if err := e.exportEvents(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if len(rcv.logs) != 2 {
+		t.Fatalf("expected no new batch, got %d", len(rcv.logs))
+	}
+}
+
+func TestBatchMetrics(t *testing.T) {
+	points := func(n int) metric {
+		return metric{Name: "m", Gauge: &gauge{DataPoints: make([]numberDataPoint, n)}}
+	}
+	batches := batchMetrics([]metric{points(3), points(4), points(0)}, 3)
+	want := []int{3, 3, 1}
+	if len(batches) != len(want) {
+		t.Fatalf("expected %d batches, got %d", len(want), len(batches))
+	}
+	for i, b := range batches {
+		n := 0
+		for _, m := range b {
+			n += m.numPoints()
+		}
+		if n != want[i] {
+			t.Fatalf("batch %d: expected %d points, got %d", i, want[i], n)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package otlp
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// The OTLP/HTTP JSON encoding of the request messages.
+// ref. https://github.com/open-telemetry/opentelemetry-proto/tree/main/opentelemetry/proto
+// ref. https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+
+const (
+	aggregationTemporalityCumulative = 2
+
+	severityNumberInfo  = 9
+	severityNumberWarn  = 13
+	severityNumberError = 17
+)
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes,omitempty"`
+}
+
+type instrumentationScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type exportMetricsServiceRequest struct {
+	ResourceMetrics []resourceMetrics `json:"resourceMetrics"`
+}
+
+type resourceMetrics struct {
+	Resource     resource       `json:"resource"`
+	ScopeMetrics []scopeMetrics `json:"scopeMetrics"`
+}
+
+type scopeMetrics struct {
+	Scope   instrumentationScope `json:"scope"`
+	Metrics []metric             `json:"metrics"`
+}
+
+type metric struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Unit        string     `json:"unit,omitempty"`
+	Gauge       *gauge     `json:"gauge,omitempty"`
+	Sum         *sum       `json:"sum,omitempty"`
+	Histogram   *histogram `json:"histogram,omitempty"`
+	Summary     *summary   `json:"summary,omitempty"`
+}
+
+type gauge struct {
+	DataPoints []numberDataPoint `json:"dataPoints"`
+}
+
+type sum struct {
+	DataPoints             []numberDataPoint `json:"dataPoints"`
+	AggregationTemporality int               `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+}
+
+type numberDataPoint struct {
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string     `json:"timeUnixNano"`
+	AsDouble          float64    `json:"asDouble"`
+}
+
+type histogram struct {
+	DataPoints             []histogramDataPoint `json:"dataPoints"`
+	AggregationTemporality int                  `json:"aggregationTemporality"`
+}
+
+type histogramDataPoint struct {
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string     `json:"timeUnixNano"`
+	Count             string     `json:"count"`
+	Sum               float64    `json:"sum"`
+	BucketCounts      []string   `json:"bucketCounts"`
+	ExplicitBounds    []float64  `json:"explicitBounds"`
+}
+
+type summary struct {
+	DataPoints []summaryDataPoint `json:"dataPoints"`
+}
+
+type summaryDataPoint struct {
+	Attributes        []keyValue        `json:"attributes,omitempty"`
+	StartTimeUnixNano string            `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string            `json:"timeUnixNano"`
+	Count             string            `json:"count"`
+	Sum               float64           `json:"sum"`
+	QuantileValues    []valueAtQuantile `json:"quantileValues,omitempty"`
+}
+
+type valueAtQuantile struct {
+	Quantile float64 `json:"quantile"`
+	Value    float64 `json:"value"`
+}
+
+type exportLogsServiceRequest struct {
+	ResourceLogs []resourceLogs `json:"resourceLogs"`
+}
+
+type resourceLogs struct {
+	Resource  resource    `json:"resource"`
+	ScopeLogs []scopeLogs `json:"scopeLogs"`
+}
+
+type scopeLogs struct {
+	Scope      instrumentationScope `json:"scope"`
+	LogRecords []logRecord          `json:"logRecords"`
+}
+
+type logRecord struct {
+	TimeUnixNano         string     `json:"timeUnixNano"`
+	ObservedTimeUnixNano string     `json:"observedTimeUnixNano"`
+	SeverityNumber       int        `json:"severityNumber,omitempty"`
+	SeverityText         string     `json:"severityText,omitempty"`
+	Body                 anyValue   `json:"body"`
+	Attributes           []keyValue `json:"attributes,omitempty"`
+}
+
+// numPoints returns the number of the data points in the metric.
+func (m metric) numPoints() int {
+	switch {
+	case m.Gauge != nil:
+		return len(m.Gauge.DataPoints)
+	case m.Sum != nil:
+		return len(m.Sum.DataPoints)
+	case m.Histogram != nil:
+		return len(m.Histogram.DataPoints)
+	case m.Summary != nil:
+		return len(m.Summary.DataPoints)
+	}
+	return 0
+}
+
+// slice returns the copy of the metric with the data points in [i, j).
+func (m metric) slice(i, j int) metric {
+	c := m
+	switch {
+	case m.Gauge != nil:
+		c.Gauge = &gauge{DataPoints: m.Gauge.DataPoints[i:j]}
+	case m.Sum != nil:
+		s := *m.Sum
+		s.DataPoints = m.Sum.DataPoints[i:j]
+		c.Sum = &s
+	case m.Histogram != nil:
+		h := *m.Histogram
+		h.DataPoints = m.Histogram.DataPoints[i:j]
+		c.Histogram = &h
+	case m.Summary != nil:
+		c.Summary = &summary{DataPoints: m.Summary.DataPoints[i:j]}
+	}
+	return c
+}
+
+// countPoints returns the total number of the data points of the metrics.
+func countPoints(ms []metric) int {
+	n := 0
+	for _, m := range ms {
+		n += m.numPoints()
+	}
+	return n
+}
+
+// batchMetrics splits the metrics into the batches
+// with at most "maxPoints" data points each.
+// The metrics are split in order, thus all the batches are full except the last one.
+func batchMetrics(ms []metric, maxPoints int) [][]metric {
+	var batches [][]metric
+	var cur []metric
+	n := 0
+	for _, m := range ms {
+		total := m.numPoints()
+		for i := 0; i < total; {
+			j := i + maxPoints - n
+			if j > total {
+				j = total
+			}
+			cur = append(cur, m.slice(i, j))
+			n += j - i
+			i = j
+			if n == maxPoints {
+				batches = append(batches, cur)
+				cur, n = nil, 0
+			}
+		}
+	}
+	if len(cur) > 0 {
+		batches = append(batches, cur)
+	}
+	return batches
+}
+
+// attributes returns the sorted key-value pairs of the map.
+func attributes(m map[string]string) []keyValue {
+	if len(m) == 0 {
+		return nil
+	}
+	kvs := make([]keyValue, 0, len(m))
+	for k, v := range m {
+		kvs = append(kvs, keyValue{Key: k, Value: anyValue{StringValue: v}})
+	}
+	sort.Slice(kvs, func(i, j int) bool {
+		return kvs[i].Key < kvs[j].Key
+	})
+	return kvs
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// finite returns true if the value can be encoded in JSON.
+func finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
@@ -0,0 +1,294 @@
+// Package queue implements the bounded queue of the encoded messages to send in order,
+// optionally persisted as files to survive the restarts.
+package queue
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a queued message.
+type Entry struct {
+	// ID is the file name of the message if persisted,
+	// and is kept when the message is spilled from memory to the file.
+	ID   string
+	Data []byte
+}
+
+// Queue holds the messages in order, dropping the oldest if full.
+// If the directory is set and the spill is enabled, the messages are persisted
+// as files so that they survive the restarts, otherwise kept in memory.
+// Enabling the spill moves the messages in memory to the files,
+// so the persisted messages are always older than the ones in memory.
+type Queue struct {
+	dir        string
+	ext        string
+	maxEntries int
+
+	mu    sync.Mutex
+	spill bool
+	mem   []Entry
+	seq   int64
+	// the number of the persisted messages,
+	// to not scan the directory while all the messages are in memory
+	nfiles int
+}
+
+// New creates a queue of up to maxEntries messages.
+// The persisted messages are the files with the extension in the directory.
+func New(dir string, ext string, maxEntries int, spill bool) (*Queue, error) {
+	q := &Queue{dir: dir, ext: ext, maxEntries: maxEntries, spill: spill}
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, fmt.Errorf("failed to create queue dir: %w", err)
+		}
+		// count the messages persisted before the restart
+		if _, err := q.files(); err != nil {
+			return nil, fmt.Errorf("failed to read queue dir: %w", err)
+		}
+	}
+	return q, nil
+}
+
+// SetSpill enables or disables persisting the new messages,
+// and moves the messages in memory to the files if enabled.
+// No-op if the directory is not set.
+func (q *Queue) SetSpill(spill bool) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.spill = spill
+	if !spill || q.dir == "" {
+		return nil
+	}
+	for len(q.mem) > 0 {
+		if err := q.write(q.mem[0]); err != nil {
+			return err
+		}
+		q.mem = q.mem[1:]
+	}
+	return nil
+}
+
+// Push appends the message, dropping the oldest if the queue is full.
+// Returns the number of the dropped messages.
+func (q *Queue) Push(b []byte) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// unix nano with the sequence to keep the order within the same clock tick
+	// (zero-padded to sort by name)
+	q.seq++
+	e := Entry{ID: fmt.Sprintf("%020d-%06d%s", time.Now().UnixNano(), q.seq%1000000, q.ext), Data: b}
+
+	if q.persisted() {
+		if err := q.write(e); err != nil {
+			return 0, err
+		}
+	} else {
+		q.mem = append(q.mem, e)
+	}
+	return q.trim()
+}
+
+// Peek returns the oldest message, or false if the queue is empty.
+// The message is kept until removed, in case the send fails.
+func (q *Queue) Peek() (Entry, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.hasFiles() {
+		files, err := q.files()
+		if err != nil {
+			return Entry{}, false, err
+		}
+		for _, id := range files {
+			b, err := os.ReadFile(filepath.Join(q.dir, id))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return Entry{}, false, err
+			}
+			return Entry{ID: id, Data: b}, true, nil
+		}
+	}
+	if len(q.mem) == 0 {
+		return Entry{}, false, nil
+	}
+	return q.mem[0], true, nil
+}
+
+// Remove removes the message once sent,
+// no-op if already dropped.
+func (q *Queue) Remove(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, e := range q.mem {
+		if e.ID == id {
+			q.mem = append(q.mem[:i], q.mem[i+1:]...)
+			return nil
+		}
+	}
+	if !q.hasFiles() {
+		return nil
+	}
+	if err := os.Remove(filepath.Join(q.dir, id)); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	q.nfiles--
+	return nil
+}
+
+// ForEach calls the function with the queued messages from the oldest,
+// and removes the message if the function returns nil.
+// It stops at the first error, to retry the remaining messages later.
+func (q *Queue) ForEach(f func([]byte) error) error {
+	for {
+		e, ok, err := q.Peek()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if err := f(e.Data); err != nil {
+			return err
+		}
+		if err := q.Remove(e.ID); err != nil {
+			return err
+		}
+	}
+}
+
+// Filter removes the messages for which the keep function returns false.
+// Returns the number of the removed messages.
+func (q *Queue) Filter(keep func([]byte) bool) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	removed := 0
+	kept := q.mem[:0]
+	for _, e := range q.mem {
+		if keep(e.Data) {
+			kept = append(kept, e)
+		} else {
+			removed++
+		}
+	}
+	q.mem = kept
+
+	if !q.hasFiles() {
+		return removed, nil
+	}
+	files, err := q.files()
+	if err != nil {
+		return removed, err
+	}
+	for _, id := range files {
+		file := filepath.Join(q.dir, id)
+		b, err := os.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return removed, err
+		}
+		if keep(b) {
+			continue
+		}
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		q.nfiles--
+		removed++
+	}
+	return removed, nil
+}
+
+// Len returns the number of the queued messages.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.mem) + q.nfiles
+}
+
+func (q *Queue) persisted() bool {
+	return q.dir != "" && q.spill
+}
+
+func (q *Queue) hasFiles() bool {
+	return q.dir != "" && q.nfiles > 0
+}
+
+// write persists the message atomically, so that a partial file is never read.
+func (q *Queue) write(e Entry) error {
+	tmp := filepath.Join(q.dir, "."+e.ID+".tmp")
+	if err := os.WriteFile(tmp, e.Data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(q.dir, e.ID)); err != nil {
+		return err
+	}
+	q.nfiles++
+	return nil
+}
+
+// trim drops the oldest messages over the limit, the persisted ones first.
+func (q *Queue) trim() (int, error) {
+	excess := q.nfiles + len(q.mem) - q.maxEntries
+	if excess <= 0 {
+		return 0, nil
+	}
+
+	var files []string
+	if q.hasFiles() {
+		var err error
+		files, err = q.files()
+		if err != nil {
+			return 0, err
+		}
+		excess = len(files) + len(q.mem) - q.maxEntries
+	}
+	dropped := 0
+	for ; dropped < excess && dropped < len(files); dropped++ {
+		if err := os.Remove(filepath.Join(q.dir, files[dropped])); err != nil && !os.IsNotExist(err) {
+			return dropped, err
+		}
+		q.nfiles--
+	}
+	if n := excess - dropped; n > 0 {
+		q.mem = q.mem[n:]
+		dropped += n
+	}
+	return dropped, nil
+}
+
+// files returns the persisted message file names sorted from the oldest,
+// and resets the number of the persisted messages.
+func (q *Queue) files() ([]string, error) {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), q.ext) || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		files = append(files, e.Name())
+	}
+	sort.Strings(files)
+	q.nfiles = len(files)
+	return files, nil
+}
@@ -0,0 +1,168 @@
+package queue
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func drain(t *testing.T, q *Queue) []string {
+	var got []string
+	for {
+		e, ok, err := q.Peek()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			return got
+		}
+		got = append(got, string(e.Data))
+		if err := q.Remove(e.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestQueue(t *testing.T) {
+	for _, dir := range []string{"", t.TempDir()} {
+		q, err := New(dir, ".json", 3, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total := 0
+		for _, m := range []string{"a", "b", "c", "d"} {
+			dropped, err := q.Push([]byte(m))
+			if err != nil {
+				t.Fatal(err)
+			}
+			total += dropped
+		}
+		if total != 1 {
+			t.Fatalf("dir %q: expected 1 dropped, got %d", dir, total)
+		}
+		if n := q.Len(); n != 3 {
+			t.Fatalf("dir %q: expected 3 messages, got %d", dir, n)
+		}
+		if got := drain(t, q); !reflect.DeepEqual(got, []string{"b", "c", "d"}) {
+			t.Fatalf("dir %q: expected the oldest dropped, got %v", dir, got)
+		}
+	}
+
+	// persisted messages survive the restart
+	dir := t.TempDir()
+	q, err := New(dir, ".json", 10, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Push([]byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	q, err = New(dir, ".json", 10, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := drain(t, q); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Fatalf("expected the persisted message, got %v", got)
+	}
+}
+
+func TestQueueSpill(t *testing.T) {
+	dir := t.TempDir()
+	q, err := New(dir, ".json", 3, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []string{"a", "b"} {
+		if _, err := q.Push([]byte(m)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("expected no files without the spill, got %d", len(entries))
+	}
+
+	// the messages in memory are persisted once the spill is enabled
+	if err := q.SetSpill(true); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Fatalf("expected 2 files after the spill, got %d", len(entries))
+	}
+
+	// the persisted messages are dropped first, and sent before the ones in memory
+	if err := q.SetSpill(false); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []string{"c", "d"} {
+		if _, err := q.Push([]byte(m)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := drain(t, q); !reflect.DeepEqual(got, []string{"b", "c", "d"}) {
+		t.Fatalf("expected the persisted messages first, got %v", got)
+	}
+}
+
+func TestQueueNoSpillSkipsDir(t *testing.T) {
+	dir := t.TempDir()
+	q, err := New(dir, ".json", 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing is on disk, so the directory is never read
+	// (reading the removed directory would fail)
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []string{"a", "b", "c"} {
+		if _, err := q.Push([]byte(m)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := q.Len(); n != 2 {
+		t.Fatalf("expected 2 messages, got %d", n)
+	}
+	if got := drain(t, q); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Fatalf("expected the messages in memory, got %v", got)
+	}
+}
+
+func TestQueueForEachAndFilter(t *testing.T) {
+	q, err := New(t.TempDir(), ".json", 10, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []string{"a", "b", "c"} {
+		if _, err := q.Push([]byte(m)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := q.Filter(func(b []byte) bool { return string(b) != "b" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Fatalf("expected 1 removed, got %d", removed)
+	}
+
+	// stops at the first error, to retry the remaining messages later
+	var got []string
+	err = q.ForEach(func(b []byte) error {
+		if string(b) == "c" {
+			return os.ErrDeadlineExceeded
+		}
+		got = append(got, string(b))
+		return nil
+	})
+	if err != os.ErrDeadlineExceeded {
+		t.Fatalf("expected the error, got %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"a"}) {
+		t.Fatalf("expected [a], got %v", got)
+	}
+	if n := q.Len(); n != 1 {
+		t.Fatalf("expected 1 remaining, got %d", n)
+	}
+}
@@ -0,0 +1,173 @@
+package remotewrite
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// label and sample are the remote-write protobuf messages.
+// ref. https://github.com/prometheus/prometheus/blob/main/prompb/types.proto
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	value     float64
+	timestamp int64 // in milliseconds
+}
+
+type timeSeries struct {
+	labels  []label
+	samples []sample
+}
+
+// convertMetricFamilies converts the gathered metrics into the time series,
+// following the Prometheus exposition of the histograms and summaries
+// (i.e., "_bucket", "_sum", and "_count" series).
+func convertMetricFamilies(mfs []*dto.MetricFamily, externalLabels map[string]string, now time.Time) []timeSeries {
+	var tss []timeSeries
+	for _, mf := range mfs {
+		name := mf.GetName()
+		for _, m := range mf.GetMetric() {
+			ts := now.UnixMilli()
+			if m.TimestampMs != nil {
+				ts = m.GetTimestampMs()
+			}
+			add := func(name string, v float64, extra ...label) {
+				tss = append(tss, timeSeries{
+					labels:  seriesLabels(name, m.GetLabel(), externalLabels, extra...),
+					samples: []sample{{value: v, timestamp: ts}},
+				})
+			}
+
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				add(name, m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				add(name, m.GetGauge().GetValue())
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				hasInf := false
+				for _, b := range h.GetBucket() {
+					if math.IsInf(b.GetUpperBound(), 1) {
+						hasInf = true
+					}
+					add(name+"_bucket", float64(b.GetCumulativeCount()), label{name: "le", value: formatFloat(b.GetUpperBound())})
+				}
+				if !hasInf {
+					add(name+"_bucket", float64(h.GetSampleCount()), label{name: "le", value: "+Inf"})
+				}
+				add(name+"_sum", h.GetSampleSum())
+				add(name+"_count", float64(h.GetSampleCount()))
+			case dto.MetricType_SUMMARY:
+				s := m.GetSummary()
+				for _, q := range s.GetQuantile() {
+					add(name, q.GetValue(), label{name: "quantile", value: formatFloat(q.GetQuantile())})
+				}
+				add(name+"_sum", s.GetSampleSum())
+				add(name+"_count", float64(s.GetSampleCount()))
+			default:
+				add(name, m.GetUntyped().GetValue())
+			}
+		}
+	}
+	return tss
+}
+
+// seriesLabels returns the labels sorted by name, as required by the protocol.
+// The metric labels take precedence over the external labels.
+func seriesLabels(name string, pairs []*dto.LabelPair, externalLabels map[string]string, extra ...label) []label {
+	m := make(map[string]string, len(externalLabels)+len(pairs)+len(extra)+1)
+	for k, v := range externalLabels {
+		m[k] = v
+	}
+	for _, p := range pairs {
+		m[p.GetName()] = p.GetValue()
+	}
+	for _, l := range extra {
+		m[l.name] = l.value
+	}
+	m["__name__"] = name
+
+	ls := make([]label, 0, len(m))
+	for k, v := range m {
+		ls = append(ls, label{name: k, value: v})
+	}
+	sort.Slice(ls, func(i, j int) bool {
+		return ls[i].name < ls[j].name
+	})
+	return ls
+}
+
+// sanitizeLabelName replaces the characters invalid in the label name
+// (e.g., "lepton.ai/zone" in the annotation keys) with "_",
+// since the receiver rejects the whole request with the invalid label.
+// ref. https://prometheus.io/docs/concepts/data_model/#metric-names-and-labels
+func sanitizeLabelName(name string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+	if sanitized != "" && sanitized[0] >= '0' && sanitized[0] <= '9' {
+		sanitized = "_" + sanitized
+	}
+	return sanitized
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// marshalWriteRequest encodes the "prometheus.WriteRequest" message.
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+func marshalWriteRequest(tss []timeSeries) []byte {
+	var b []byte
+	for _, ts := range tss {
+		var tsb []byte
+		for _, l := range ts.labels {
+			var lb []byte
+			lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.name)
+			lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.value)
+
+			tsb = protowire.AppendTag(tsb, 1, protowire.BytesType)
+			tsb = protowire.AppendBytes(tsb, lb)
+		}
+		for _, s := range ts.samples {
+			var sb []byte
+			sb = protowire.AppendTag(sb, 1, protowire.Fixed64Type)
+			sb = protowire.AppendFixed64(sb, math.Float64bits(s.value))
+			sb = protowire.AppendTag(sb, 2, protowire.VarintType)
+			sb = protowire.AppendVarint(sb, uint64(s.timestamp))
+
+			tsb = protowire.AppendTag(tsb, 2, protowire.BytesType)
+			tsb = protowire.AppendBytes(tsb, sb)
+		}
+
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, tsb)
+	}
+	return b
+}
@@ -0,0 +1,265 @@
+package remotewrite
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/config"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// receiver is a stand-in for the remote-write endpoint.
+type receiver struct {
+	mu sync.Mutex
+	// decoded series of the accepted requests
+	series [][]timeSeries
+	// status code to respond, 200 if zero
+	code int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.code != 0 {
+		w.WriteHeader(r.code)
+		return
+	}
+	if req.Header.Get("Content-Encoding") != "snappy" || req.Header.Get("Content-Type") != "application/x-protobuf" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	b, _ := io.ReadAll(req.Body)
+	raw, err := snappy.Decode(nil, b)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	tss, err := unmarshalWriteRequest(raw)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.series = append(r.series, tss)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (r *receiver) setCode(code int) {
+	r.mu.Lock()
+	r.code = code
+	r.mu.Unlock()
+}
+
+func (r *receiver) received() [][]timeSeries {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]timeSeries{}, r.series...)
+}
+
+// unmarshalWriteRequest decodes the message encoded by "marshalWriteRequest".
+func unmarshalWriteRequest(b []byte) ([]timeSeries, error) {
+	var tss []timeSeries
+	err := consumeFields(b, func(num protowire.Number, v []byte, _ uint64) error {
+		var ts timeSeries
+		err := consumeFields(v, func(num protowire.Number, v []byte, _ uint64) error {
+			switch num {
+			case 1:
+				var l label
+				err := consumeFields(v, func(num protowire.Number, v []byte, _ uint64) error {
+					if num == 1 {
+						l.name = string(v)
+					} else {
+						l.value = string(v)
+					}
+					return nil
+				})
+				ts.labels = append(ts.labels, l)
+				return err
+			case 2:
+				var s sample
+				err := consumeFields(v, func(num protowire.Number, _ []byte, n uint64) error {
+					if num == 1 {
+						s.value = math.Float64frombits(n)
+					} else {
+						s.timestamp = int64(n)
+					}
+					return nil
+				})
+				ts.samples = append(ts.samples, s)
+				return err
+			}
+			return nil
+		})
+		tss = append(tss, ts)
+		return err
+	})
+	return tss, err
+}
+
+func consumeFields(b []byte, f func(num protowire.Number, v []byte, n uint64) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var bytesV []byte
+		var numV uint64
+		switch typ {
+		case protowire.BytesType:
+			bytesV, n = protowire.ConsumeBytes(b)
+		case protowire.Fixed64Type:
+			numV, n = protowire.ConsumeFixed64(b)
+		case protowire.VarintType:
+			numV, n = protowire.ConsumeVarint(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if err := f(num, bytesV, numV); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func labelsMap(ls []label) map[string]string {
+	m := make(map[string]string, len(ls))
+	for _, l := range ls {
+		m[l.name] = l.value
+	}
+	return m
+}
+
+func TestWriterQueueAndRetry(t *testing.T) {
+	rcv := &receiver{code: http.StatusServiceUnavailable}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_temperature", Help: "test"}, []string{"gpu"})
+	hist := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_seconds", Help: "test", Buckets: []float64{1}})
+	reg.MustRegister(gauge, hist)
+	gauge.WithLabelValues("0").Set(42)
+	hist.Observe(2)
+
+	queueDir := filepath.Join(t.TempDir(), "remote-write")
+	cfg := config.RemoteWrite{URL: srv.URL, QueueDir: queueDir, MaxQueuedRequests: 2, ExternalLabels: map[string]string{"cluster": "c1"}}
+	w, err := New(cfg, reg, "m1", map[string]string{"team": "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := w.write(ctx, now.Add(time.Duration(i)*time.Second)); err == nil {
+			t.Fatal("expected error during the outage")
+		}
+	}
+	if n := w.queue.Len(); n != 2 {
+		t.Fatalf("expected 2 queued requests, got %d", n)
+	}
+
+	// the queue survives the restart
+	w, err = New(cfg, reg, "m1", map[string]string{"team": "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rcv.setCode(0)
+	if err := w.write(ctx, now.Add(3*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if n := w.queue.Len(); n != 0 {
+		t.Fatalf("expected empty queue, got %d", n)
+	}
+
+	reqs := rcv.received()
+	if len(reqs) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(reqs))
+	}
+	// the oldest were dropped, and the rest are sent in order
+	if got := reqs[0][0].samples[0].timestamp; got != now.Add(2*time.Second).UnixMilli() {
+		t.Fatalf("expected the third request first, got timestamp %d", got)
+	}
+
+	found := map[string]bool{}
+	for _, ts := range reqs[1] {
+		ls := labelsMap(ts.labels)
+		if ls["machine_id"] != "m1" || ls["team"] != "a" || ls["cluster"] != "c1" {
+			t.Fatalf("missing external labels %v", ls)
+		}
+		name := ls["__name__"]
+		switch {
+		case name == "test_temperature":
+			if ls["gpu"] != "0" || ts.samples[0].value != 42 {
+				t.Fatalf("unexpected series %v %v", ls, ts.samples)
+			}
+		case name == "test_seconds_bucket" && ls["le"] == "+Inf":
+			if ts.samples[0].value != 1 {
+				t.Fatalf("unexpected +Inf bucket %v", ts.samples)
+			}
+		}
+		found[name] = true
+		for i := 1; i < len(ts.labels); i++ {
+			if ts.labels[i-1].name >= ts.labels[i].name {
+				t.Fatalf("labels not sorted %v", ts.labels)
+			}
+		}
+	}
+	for _, name := range []string{"test_temperature", "test_seconds_bucket", "test_seconds_sum", "test_seconds_count"} {
+		if !found[name] {
+			t.Fatalf("expected series %q", name)
+		}
+	}
+
+	// not retried on the client errors
+	rcv.setCode(http.StatusBadRequest)
+	if err := w.write(ctx, now.Add(4*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if n := w.queue.Len(); n != 0 {
+		t.Fatalf("expected the bad request to be dropped, got %d queued", n)
+	}
+}
+
+func TestSanitizeLabelName(t *testing.T) {
+	tests := map[string]string{
+		"team":           "team",
+		"lepton.ai/zone": "lepton_ai_zone",
+		"node-pool":      "node_pool",
+		"1st":            "_1st",
+		"":               "",
+	}
+	for name, want := range tests {
+		if got := sanitizeLabelName(name); got != want {
+			t.Errorf("sanitizeLabelName(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	w, err := New(config.RemoteWrite{URL: "http://localhost"}, nil, "m1", map[string]string{"lepton.ai/zone": "z1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.externalLabels["lepton_ai_zone"] != "z1" {
+		t.Fatalf("expected the sanitized annotation label, got %v", w.externalLabels)
+	}
+
+	if _, err := New(config.RemoteWrite{URL: "http://localhost", ExternalLabels: map[string]string{"lepton.ai/zone": "z1"}}, nil, "m1", nil); err == nil {
+		t.Fatal("expected error for the invalid external label name")
+	}
+}
@@ -0,0 +1,149 @@
+// Package remotewrite pushes the gpud metrics to a Prometheus remote-write endpoint,
+// for the hosts that cannot be scraped (e.g., behind NAT).
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/internal/queue"
+	"github.com/leptonai/gpud/log"
+	"github.com/leptonai/gpud/version"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const queueFileExt = ".snappy"
+
+// errPermanent marks the request that cannot succeed on retries (e.g., 400),
+// thus dropped from the queue.
+type errPermanent struct {
+	err error
+}
+
+func (e *errPermanent) Error() string { return e.err.Error() }
+
+// Writer periodically gathers the metrics from the registry,
+// and sends them with the remote-write protocol (snappy-compressed protobuf).
+// The requests failed to send are queued, and retried on the next interval.
+type Writer struct {
+	cfg            config.RemoteWrite
+	gatherer       prometheus.Gatherer
+	externalLabels map[string]string
+	cli            *http.Client
+	queue          *queue.Queue
+}
+
+// New creates the writer, with the annotations, the machine ID ("machine_id"),
+// and the configured external labels attached to every series.
+func New(cfg config.RemoteWrite, gatherer prometheus.Gatherer, machineID string, annotations map[string]string) (*Writer, error) {
+	cfg.SetDefaultsIfNotSet()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	labels := make(map[string]string, len(annotations)+len(cfg.ExternalLabels)+1)
+	for k, v := range annotations {
+		labels[sanitizeLabelName(k)] = v
+	}
+	labels["machine_id"] = machineID
+	for k, v := range cfg.ExternalLabels {
+		labels[k] = v
+	}
+
+	q, err := queue.New(cfg.QueueDir, queueFileExt, cfg.MaxQueuedRequests, true)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{
+		cfg:            cfg,
+		gatherer:       gatherer,
+		externalLabels: labels,
+		cli:            &http.Client{Timeout: cfg.Timeout.Duration},
+		queue:          q,
+	}, nil
+}
+
+// Start starts pushing the metrics until the context is canceled.
+func (w *Writer) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.cfg.Interval.Duration)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if err := w.write(ctx, time.Now()); err != nil {
+				log.Logger.Warnw("failed to remote-write metrics", "url", w.cfg.URL, "queued", w.queue.Len(), "error", err)
+			}
+		}
+	}()
+}
+
+// write gathers the current metrics into the queue, and sends the queued requests.
+func (w *Writer) write(ctx context.Context, now time.Time) error {
+	mfs, err := w.gatherer.Gather()
+	if err != nil {
+		// partial results are still returned with the error
+		log.Logger.Warnw("failed to gather prometheus metrics", "error", err)
+	}
+	tss := convertMetricFamilies(mfs, w.externalLabels, now)
+	if len(tss) > 0 {
+		dropped, err := w.queue.Push(snappy.Encode(nil, marshalWriteRequest(tss)))
+		if err != nil {
+			return fmt.Errorf("failed to queue request: %w", err)
+		}
+		if dropped > 0 {
+			log.Logger.Warnw("dropped the oldest remote-write requests", "dropped", dropped)
+		}
+	}
+
+	return w.queue.ForEach(func(b []byte) error {
+		err := w.send(ctx, b)
+		if perr, ok := err.(*errPermanent); ok {
+			log.Logger.Warnw("dropping remote-write request", "url", w.cfg.URL, "error", perr.err)
+			return nil
+		}
+		return err
+	})
+}
+
+// send posts the compressed request.
+// ref. https://prometheus.io/docs/specs/remote_write_spec/
+func (w *Writer) send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", "gpud/"+version.Version)
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	for k, v := range w.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := w.cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("unexpected status code %d (%s)", resp.StatusCode, bytes.TrimSpace(msg))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return err
+	}
+	return &errPermanent{err: err}
+}
@@ -0,0 +1,249 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	goOS "os"
+	"strings"
+
+	lepconfig "github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/errdefs"
+	"github.com/leptonai/gpud/log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKeyRole is the gin context key of the authenticated role.
+const ContextKeyRole = "gpud-auth-role"
+
+type authenticator struct {
+	// maps the bearer token to the role
+	tokens map[string]string
+
+	adminCommonNames map[string]struct{}
+}
+
+func newAuthenticator(cfg lepconfig.Auth) (*authenticator, error) {
+	a := &authenticator{
+		tokens:           make(map[string]string),
+		adminCommonNames: make(map[string]struct{}),
+	}
+	if cfg.TokensFile != "" {
+		tokens, err := loadTokens(cfg.TokensFile)
+		if err != nil {
+			return nil, err
+		}
+		a.tokens = tokens
+	}
+	for _, cn := range cfg.AdminCommonNames {
+		a.adminCommonNames[cn] = struct{}{}
+	}
+	return a, nil
+}
+
+// loadTokens loads the static bearer tokens in the format of "<token> [role]" per line.
+func loadTokens(file string) (map[string]string, error) {
+	f, err := goOS.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tokens file: %w", err)
+	}
+	defer f.Close()
+
+	tokens := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		role := lepconfig.RoleReadOnly
+		switch len(fields) {
+		case 1:
+		case 2:
+			role = fields[1]
+		default:
+			return nil, fmt.Errorf("invalid tokens file line %d: expected \"<token> [role]\"", n)
+		}
+		if !lepconfig.IsValidRole(role) {
+			return nil, fmt.Errorf("invalid tokens file line %d: unknown role %q", n, role)
+		}
+		tokens[fields[0]] = role
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tokens file: %w", err)
+	}
+	return tokens, nil
+}
+
+type unixSocketRoleKey struct{}
+
+// role returns the role of the request, or empty if not authenticated.
+func (a *authenticator) role(req *http.Request) string {
+	if role, ok := req.Context().Value(unixSocketRoleKey{}).(string); ok {
+		return role
+	}
+
+	if token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok && token != "" {
+		// compare all in constant time to not leak the tokens
+		matched := ""
+		for t, role := range a.tokens {
+			if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+				matched = role
+			}
+		}
+		if matched != "" {
+			return matched
+		}
+	}
+
+	// only set with the client CA configured
+	if req.TLS != nil && len(req.TLS.VerifiedChains) > 0 && len(req.TLS.VerifiedChains[0]) > 0 {
+		if _, ok := a.adminCommonNames[req.TLS.VerifiedChains[0][0].Subject.CommonName]; ok {
+			return lepconfig.RoleAdmin
+		}
+		return lepconfig.RoleReadOnly
+	}
+	return ""
+}
+
+// requiresAdmin returns true if the request requires the admin role,
+// which are the admin endpoints and all the mutating requests.
+func requiresAdmin(req *http.Request) bool {
+	if req.URL.Path == "/admin" || strings.HasPrefix(req.URL.Path, "/admin/") {
+		return true
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+// unauthenticatedPaths are the liveness and readiness probe paths,
+// which the probes (e.g., kubelet, load balancers) cannot authenticate to.
+var unauthenticatedPaths = map[string]struct{}{
+	URLPathHealthz:        {},
+	"/v1" + URLPathHealth: {},
+	"/v1" + URLPathReady:  {},
+}
+
+// middleware authenticates all the requests except the health and readiness probes,
+// and authorizes the admin requests.
+func (a *authenticator) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := unauthenticatedPaths[c.Request.URL.Path]; ok {
+			c.Next()
+			return
+		}
+
+		role := a.role(c.Request)
+		if role == "" {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": errdefs.ErrInvalidArgument, "message": "unauthenticated"})
+			return
+		}
+		if requiresAdmin(c.Request) && role != lepconfig.RoleAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"code": errdefs.ErrFailedPrecondition, "message": "admin role required"})
+			return
+		}
+
+		c.Set(ContextKeyRole, role)
+		c.Next()
+	}
+}
+
+// requireAuthOrLoopback allows the admin mutations (e.g., config reload) only
+// from the loopback address if the authentication is not configured,
+// so that the remote clients cannot change the components without the credentials.
+// With the authentication, the admin role is already required by the auth middleware.
+func requireAuthOrLoopback(cfg *lepconfig.Auth) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cfg != nil {
+			c.Next()
+			return
+		}
+		host, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+		if err != nil {
+			host = c.Request.RemoteAddr
+		}
+		if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"code": errdefs.ErrFailedPrecondition, "message": "admin mutation requires the auth configured or the loopback address"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// loadClientCAs loads the CA bundle to verify the client certificates.
+func loadClientCAs(file string) (*x509.CertPool, error) {
+	b, err := goOS.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client ca file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no certificate found in client ca file %s", file)
+	}
+	return pool, nil
+}
+
+// configureClientAuth verifies the client certificates if given,
+// so that the clients can still authenticate with the bearer tokens.
+func configureClientAuth(tlsConfig *tls.Config, cfg lepconfig.Auth) error {
+	if cfg.ClientCAFile == "" {
+		return nil
+	}
+	pool, err := loadClientCAs(cfg.ClientCAFile)
+	if err != nil {
+		return err
+	}
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	return nil
+}
+
+// listenUnixSocket listens on the Unix-domain socket with the configured file mode.
+func listenUnixSocket(cfg lepconfig.Auth) (net.Listener, error) {
+	mode, err := cfg.ParseUnixSocketMode()
+	if err != nil {
+		return nil, err
+	}
+
+	// remove the stale socket from the previous run
+	if err := goOS.Remove(cfg.UnixSocket); err != nil && !goOS.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove stale unix socket: %w", err)
+	}
+	l, err := net.Listen("unix", cfg.UnixSocket)
+	if err != nil {
+		return nil, err
+	}
+	if err := goOS.Chmod(cfg.UnixSocket, goOS.FileMode(mode)); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("failed to chmod unix socket: %w", err)
+	}
+	return l, nil
+}
+
+// serveUnixSocket serves the handler on the Unix-domain socket,
+// granting the configured role to all the connections.
+func serveUnixSocket(l net.Listener, handler http.Handler, role string) {
+	srv := &http.Server{
+		Handler: handler,
+		ConnContext: func(ctx context.Context, _ net.Conn) context.Context {
+			return context.WithValue(ctx, unixSocketRoleKey{}, role)
+		},
+	}
+	log.Logger.Infof("serving unix socket %s", l.Addr())
+	if err := srv.Serve(l); err != nil && err != http.ErrServerClosed {
+		log.Logger.Warnw("unix socket server failed", "error", err)
+	}
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	lep_config "github.com/leptonai/gpud/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLoadTokens(t *testing.T) {
+	dir := t.TempDir()
+
+	file := filepath.Join(dir, "tokens")
+	if err := os.WriteFile(file, []byte("# comment\nreader\n\nwriter admin\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := loadTokens(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 || tokens["reader"] != lep_config.RoleReadOnly || tokens["writer"] != lep_config.RoleAdmin {
+		t.Fatalf("unexpected tokens %v", tokens)
+	}
+
+	if err := os.WriteFile(file, []byte("token superuser\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadTokens(file); err == nil {
+		t.Fatal("expected error for unknown role")
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	a := &authenticator{
+		tokens: map[string]string{
+			"reader": lep_config.RoleReadOnly,
+			"writer": lep_config.RoleAdmin,
+		},
+		adminCommonNames: map[string]struct{}{"ops": {}},
+	}
+	router := gin.New()
+	router.Use(a.middleware())
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+	router.GET(URLPathHealthz, ok)
+	router.GET("/v1"+URLPathHealth, ok)
+	router.GET("/v1"+URLPathReady, ok)
+	router.GET("/v1/states", ok)
+	router.POST("/v1/components/:component/refresh", ok)
+	router.GET("/admin/config", ok)
+
+	withCert := func(cn string) func(*http.Request) {
+		return func(req *http.Request) {
+			req.TLS = &tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: cn}}}},
+			}
+		}
+	}
+	withToken := func(token string) func(*http.Request) {
+		return func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	withUnixSocket := func(role string) func(*http.Request) {
+		return func(req *http.Request) {
+			*req = *req.WithContext(context.WithValue(req.Context(), unixSocketRoleKey{}, role))
+		}
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		auth   func(*http.Request)
+		want   int
+	}{
+		{"healthz without auth", http.MethodGet, URLPathHealthz, nil, http.StatusOK},
+		{"health without auth", http.MethodGet, "/v1" + URLPathHealth, nil, http.StatusOK},
+		{"ready without auth", http.MethodGet, "/v1" + URLPathReady, nil, http.StatusOK},
+		{"states without auth", http.MethodGet, "/v1/states", nil, http.StatusUnauthorized},
+		{"states with invalid token", http.MethodGet, "/v1/states", withToken("invalid"), http.StatusUnauthorized},
+		{"states with read-only token", http.MethodGet, "/v1/states", withToken("reader"), http.StatusOK},
+		{"refresh with read-only token", http.MethodPost, "/v1/components/cpu/refresh", withToken("reader"), http.StatusForbidden},
+		{"refresh with admin token", http.MethodPost, "/v1/components/cpu/refresh", withToken("writer"), http.StatusOK},
+		{"admin with read-only token", http.MethodGet, "/admin/config", withToken("reader"), http.StatusForbidden},
+		{"admin with admin token", http.MethodGet, "/admin/config", withToken("writer"), http.StatusOK},
+		{"admin with client cert", http.MethodGet, "/admin/config", withCert("node"), http.StatusForbidden},
+		{"states with client cert", http.MethodGet, "/v1/states", withCert("node"), http.StatusOK},
+		{"admin with admin client cert", http.MethodGet, "/admin/config", withCert("ops"), http.StatusOK},
+		{"admin with unix socket", http.MethodGet, "/admin/config", withUnixSocket(lep_config.RoleAdmin), http.StatusOK},
+		{"admin with read-only unix socket", http.MethodGet, "/admin/config", withUnixSocket(lep_config.RoleReadOnly), http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.auth != nil {
+				tt.auth(req)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestRequireAuthOrLoopback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+	tests := []struct {
+		name       string
+		auth       *lep_config.Auth
+		remoteAddr string
+		want       int
+	}{
+		{"remote without auth", nil, "192.0.2.1:1234", http.StatusForbidden},
+		{"loopback without auth", nil, "127.0.0.1:1234", http.StatusOK},
+		{"ipv6 loopback without auth", nil, "[::1]:1234", http.StatusOK},
+		{"remote with auth", &lep_config.Auth{}, "192.0.2.1:1234", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/admin/config/reload", requireAuthOrLoopback(tt.auth), ok)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/config/reload", nil)
+			req.RemoteAddr = tt.remoteAddr
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+
+	// the admin mutations are rejected from the remote address by default
+	router := gin.New()
+	(&Server{}).registerAdminRoutes(router.Group("/admin"), nil)
+	for _, tt := range []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPost, URLPathConfigReload},
+		{http.MethodPost, strings.Replace(URLPathAdminComponentEnable, ":component", "cpu", 1)},
+		{http.MethodPost, strings.Replace(URLPathAdminComponentDisable, ":component", "cpu", 1)},
+		{http.MethodPut, strings.Replace(URLPathAdminComponentQuery, ":component", "cpu", 1)},
+	} {
+		req := httptest.NewRequest(tt.method, "/admin"+tt.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.path, http.StatusForbidden, w.Code)
+		}
+	}
+}
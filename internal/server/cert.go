@@ -0,0 +1,329 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	goOS "os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	lepconfig "github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/log"
+)
+
+const (
+	// file names of the persisted self-signed certificate in the state directory
+	selfSignedCertFileName = "gpud.crt"
+	selfSignedKeyFileName  = "gpud.key"
+
+	selfSignedCertValidity = 365 * 24 * time.Hour
+)
+
+// certManager serves the TLS certificate of the local HTTP API,
+// and reloads it when the certificate files are rotated.
+type certManager struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	selfSigned bool
+	// the self-signed certificate is regenerated
+	// when it expires within this duration
+	expiryWarning time.Duration
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+	size    int64
+}
+
+// newCertManager loads the configured certificate,
+// or the self-signed certificate persisted in the directory of the state file.
+// If neither is configured, it generates a new self-signed certificate in memory.
+func newCertManager(cfg *lepconfig.TLS, stateFile string) (*certManager, error) {
+	m := &certManager{expiryWarning: lepconfig.DefaultTLSExpiryWarning}
+	if cfg != nil && cfg.ExpiryWarning.Duration > 0 {
+		m.expiryWarning = cfg.ExpiryWarning.Duration
+	}
+	// not to regenerate the self-signed certificate on every check
+	if m.expiryWarning > selfSignedCertValidity/2 {
+		m.expiryWarning = selfSignedCertValidity / 2
+	}
+	switch {
+	case cfg != nil && cfg.CertFile != "":
+		m.certFile, m.keyFile, m.caFile = cfg.CertFile, cfg.KeyFile, cfg.CAFile
+
+	case cfg != nil && cfg.PersistSelfSigned && stateFile != "" && stateFile != ":memory:":
+		m.selfSigned = true
+		m.certFile = filepath.Join(filepath.Dir(stateFile), selfSignedCertFileName)
+		m.keyFile = filepath.Join(filepath.Dir(stateFile), selfSignedKeyFileName)
+		if err := ensureSelfSignedCert(m.certFile, m.keyFile, m.expiryWarning); err != nil {
+			return nil, err
+		}
+
+	default:
+		m.selfSigned = true
+		certPEM, keyPEM, err := generateSelfSignedCert()
+		if err != nil {
+			return nil, err
+		}
+		cert, err := parseKeyPair(certPEM, keyPEM, nil)
+		if err != nil {
+			return nil, err
+		}
+		m.cert = cert
+		return m, nil
+	}
+
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// GetCertificate implements "tls.Config.GetCertificate".
+func (m *certManager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.cert, nil
+}
+
+// Leaf returns the currently served certificate.
+func (m *certManager) Leaf() *x509.Certificate {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.cert.Leaf
+}
+
+// watch polls the certificate files and reloads on changes.
+// The current certificate is kept if the new one fails to load
+// (e.g., the certificate is rotated before the key).
+// The self-signed certificate is regenerated before it expires.
+func (m *certManager) watch(ctx context.Context) {
+	ticker := time.NewTicker(configWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		renewed, err := m.renewSelfSigned(time.Now())
+		if err != nil {
+			log.Logger.Warnw("failed to regenerate self-signed certificate", "file", m.certFile, "error", err)
+		} else if renewed {
+			log.Logger.Infow("regenerated expiring self-signed certificate", "file", m.certFile, "notAfter", m.Leaf().NotAfter)
+		}
+		if m.certFile == "" {
+			continue
+		}
+
+		modTime, size := m.stat()
+		m.mu.RLock()
+		changed := !modTime.Equal(m.modTime) || size != m.size
+		m.mu.RUnlock()
+		if !changed {
+			continue
+		}
+
+		if err := m.reload(); err != nil {
+			log.Logger.Warnw("failed to reload tls certificate", "file", m.certFile, "error", err)
+			continue
+		}
+		log.Logger.Infow("reloaded tls certificate", "file", m.certFile, "notAfter", m.Leaf().NotAfter)
+	}
+}
+
+// renewSelfSigned regenerates the self-signed certificate if it expires
+// within the expiry warning, and returns true if regenerated.
+// The persisted one is regenerated in the files, to be kept across restarts.
+func (m *certManager) renewSelfSigned(now time.Time) (bool, error) {
+	if !m.selfSigned || now.Add(m.expiryWarning).Before(m.Leaf().NotAfter) {
+		return false, nil
+	}
+
+	if m.certFile != "" {
+		if err := writeSelfSignedCert(m.certFile, m.keyFile); err != nil {
+			return false, err
+		}
+		return true, m.reload()
+	}
+
+	certPEM, keyPEM, err := generateSelfSignedCert()
+	if err != nil {
+		return false, err
+	}
+	cert, err := parseKeyPair(certPEM, keyPEM, nil)
+	if err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	m.cert = cert
+	m.mu.Unlock()
+	return true, nil
+}
+
+// stat returns the latest modification time and the total size of the files,
+// to detect the change of any of them.
+func (m *certManager) stat() (time.Time, int64) {
+	var modTime time.Time
+	var size int64
+	for _, file := range []string{m.certFile, m.keyFile, m.caFile} {
+		t, s := statConfigFile(file)
+		if t.After(modTime) {
+			modTime = t
+		}
+		size += s
+	}
+	return modTime, size
+}
+
+func (m *certManager) reload() error {
+	modTime, size := m.stat()
+
+	certPEM, err := goOS.ReadFile(m.certFile)
+	if err != nil {
+		return fmt.Errorf("failed to read cert file: %w", err)
+	}
+	keyPEM, err := goOS.ReadFile(m.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read key file: %w", err)
+	}
+	var caPEM []byte
+	if m.caFile != "" {
+		caPEM, err = goOS.ReadFile(m.caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read ca file: %w", err)
+		}
+	}
+	cert, err := parseKeyPair(certPEM, keyPEM, caPEM)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.cert = cert
+	m.modTime, m.size = modTime, size
+	m.mu.Unlock()
+	return nil
+}
+
+// parseKeyPair parses the certificate and key pair,
+// with the CA certificates appended to the chain.
+func parseKeyPair(certPEM, keyPEM, caPEM []byte) (*tls.Certificate, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key pair: %w", err)
+	}
+	for len(caPEM) > 0 {
+		var block *pem.Block
+		block, caPEM = pem.Decode(caPEM)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			cert.Certificate = append(cert.Certificate, block.Bytes)
+		}
+	}
+	if cert.Leaf == nil {
+		cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+	}
+	return &cert, nil
+}
+
+// ensureSelfSignedCert generates and persists the self-signed certificate,
+// if not exists or expires within "expiryWarning".
+func ensureSelfSignedCert(certFile, keyFile string, expiryWarning time.Duration) error {
+	certPEM, certErr := goOS.ReadFile(certFile)
+	keyPEM, keyErr := goOS.ReadFile(keyFile)
+	if certErr == nil && keyErr == nil {
+		cert, err := parseKeyPair(certPEM, keyPEM, nil)
+		if err == nil && time.Now().Add(expiryWarning).Before(cert.Leaf.NotAfter) {
+			return nil
+		}
+		log.Logger.Warnw("regenerating invalid or expiring self-signed certificate", "file", certFile, "error", err)
+	} else if !errors.Is(certErr, goOS.ErrNotExist) && certErr != nil {
+		return fmt.Errorf("failed to read self-signed cert: %w", certErr)
+	}
+	return writeSelfSignedCert(certFile, keyFile)
+}
+
+// writeSelfSignedCert generates and persists a new self-signed certificate.
+func writeSelfSignedCert(certFile, keyFile string) error {
+	certPEM, keyPEM, err := generateSelfSignedCert()
+	if err != nil {
+		return err
+	}
+	if err := goOS.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return fmt.Errorf("failed to write self-signed key: %w", err)
+	}
+	if err := goOS.WriteFile(certFile, certPEM, 0644); err != nil {
+		return fmt.Errorf("failed to write self-signed cert: %w", err)
+	}
+	log.Logger.Infow("generated self-signed certificate", "file", certFile)
+	return nil
+}
+
+// generateSelfSignedCert returns the PEM-encoded self-signed certificate and its private key.
+func generateSelfSignedCert() ([]byte, []byte, error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Create a certificate template
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization: []string{"Lepton AI"},
+		},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(selfSignedCertValidity),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+
+	// Create the certificate
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Encode the certificate and private key to PEM format
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	privDER, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	privPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: privDER})
+
+	return certPEM, privPEM, nil
+}
+
+// certFingerprint returns the hex-encoded SHA-256 fingerprint of the certificate.
+func certFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
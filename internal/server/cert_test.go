@@ -0,0 +1,168 @@
+package server
+
+import (
+	goOS "os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	lepconfig "github.com/leptonai/gpud/config"
+)
+
+func TestCertManagerPersistSelfSigned(t *testing.T) {
+	dir := t.TempDir()
+	stateFile := filepath.Join(dir, "gpud.state")
+	cfg := &lepconfig.TLS{PersistSelfSigned: true}
+
+	m1, err := newCertManager(cfg, stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := goOS.Stat(filepath.Join(dir, selfSignedKeyFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("expected key file mode 0600, got %v", info.Mode().Perm())
+	}
+
+	m2, err := newCertManager(cfg, stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if certFingerprint(m1.Leaf()) != certFingerprint(m2.Leaf()) {
+		t.Fatal("expected the same certificate across restarts")
+	}
+
+	m3, err := newCertManager(nil, stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if certFingerprint(m1.Leaf()) == certFingerprint(m3.Leaf()) {
+		t.Fatal("expected a new in-memory certificate when not persisted")
+	}
+}
+
+func TestCertManagerRenewSelfSigned(t *testing.T) {
+	dir := t.TempDir()
+	stateFile := filepath.Join(dir, "gpud.state")
+	cfg := &lepconfig.TLS{PersistSelfSigned: true}
+
+	renewed := make([]string, 0, 2)
+	for _, newFunc := range []func() (*certManager, error){
+		func() (*certManager, error) { return newCertManager(cfg, stateFile) },
+		func() (*certManager, error) { return newCertManager(nil, "") },
+	} {
+		m, err := newFunc()
+		if err != nil {
+			t.Fatal(err)
+		}
+		before := certFingerprint(m.Leaf())
+
+		ok, err := m.renewSelfSigned(time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok || certFingerprint(m.Leaf()) != before {
+			t.Fatal("expected the valid certificate to be kept")
+		}
+
+		ok, err = m.renewSelfSigned(m.Leaf().NotAfter.Add(-time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok || certFingerprint(m.Leaf()) == before {
+			t.Fatal("expected the expiring certificate to be regenerated")
+		}
+		renewed = append(renewed, certFingerprint(m.Leaf()))
+	}
+
+	// the regenerated certificate is persisted
+	m, err := newCertManager(cfg, stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if certFingerprint(m.Leaf()) != renewed[0] {
+		t.Fatal("expected the regenerated certificate to be loaded")
+	}
+}
+
+func TestCertManagerReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	writeKeyPair := func() {
+		certPEM, keyPEM, err := generateSelfSignedCert()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := goOS.WriteFile(certFile, certPEM, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := goOS.WriteFile(keyFile, keyPEM, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeKeyPair()
+
+	m, err := newCertManager(&lepconfig.TLS{CertFile: certFile, KeyFile: keyFile}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := certFingerprint(m.Leaf())
+
+	// keep serving the current certificate if the rotated one is invalid
+	if err := goOS.WriteFile(certFile, []byte("invalid"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.reload(); err == nil {
+		t.Fatal("expected error on invalid certificate")
+	}
+	if certFingerprint(m.Leaf()) != before {
+		t.Fatal("expected the current certificate to be kept")
+	}
+
+	writeKeyPair()
+	if err := m.reload(); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := m.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if certFingerprint(cert.Leaf) == before {
+		t.Fatal("expected the rotated certificate")
+	}
+}
+
+func TestTLSCertComponentState(t *testing.T) {
+	m, err := newCertManager(nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newTLSCertComponent(m, nil)
+	notAfter := m.Leaf().NotAfter
+
+	tests := []struct {
+		name         string
+		now          time.Time
+		wantHealthy  bool
+		wantSeverity components.Severity
+	}{
+		{name: "valid", now: notAfter.Add(-2 * lepconfig.DefaultTLSExpiryWarning), wantHealthy: true, wantSeverity: components.SeverityInfo},
+		{name: "expiring", now: notAfter.Add(-time.Hour), wantHealthy: false, wantSeverity: components.SeverityWarning},
+		{name: "expired", now: notAfter.Add(time.Hour), wantHealthy: false, wantSeverity: components.SeverityCritical},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := c.state(tt.now)
+			if st.Healthy != tt.wantHealthy || st.Severity != tt.wantSeverity {
+				t.Fatalf("expected healthy %v with severity %q, got %v with %q (%s)", tt.wantHealthy, tt.wantSeverity, st.Healthy, st.Severity, st.Reason)
+			}
+			if st.ExtraInfo["fingerprint"] == "" {
+				t.Fatal("expected fingerprint")
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	lepconfig "github.com/leptonai/gpud/config"
+)
+
+// TLSCertComponentName is the name of the component
+// that reports the expiry of the TLS certificate served by gpud.
+const TLSCertComponentName = "tls-certificate"
+
+var _ components.Component = (*tlsCertComponent)(nil)
+
+type tlsCertComponent struct {
+	certs         *certManager
+	expiryWarning time.Duration
+}
+
+func newTLSCertComponent(certs *certManager, cfg *lepconfig.TLS) *tlsCertComponent {
+	expiryWarning := lepconfig.DefaultTLSExpiryWarning
+	if cfg != nil && cfg.ExpiryWarning.Duration > 0 {
+		expiryWarning = cfg.ExpiryWarning.Duration
+	}
+	return &tlsCertComponent{certs: certs, expiryWarning: expiryWarning}
+}
+
+func (c *tlsCertComponent) Name() string { return TLSCertComponentName }
+
+func (c *tlsCertComponent) States(ctx context.Context) ([]components.State, error) {
+	return []components.State{c.state(time.Now())}, nil
+}
+
+func (c *tlsCertComponent) state(now time.Time) components.State {
+	leaf := c.certs.Leaf()
+
+	extraInfo := map[string]string{
+		"subject":     leaf.Subject.String(),
+		"not_after":   leaf.NotAfter.UTC().Format(time.RFC3339),
+		"fingerprint": certFingerprint(leaf),
+		"self_signed": fmt.Sprintf("%v", c.certs.selfSigned),
+	}
+	if c.certs.certFile != "" {
+		extraInfo["file"] = c.certs.certFile
+	}
+
+	st := components.State{
+		Name:      TLSCertComponentName,
+		Healthy:   true,
+		Severity:  components.SeverityInfo,
+		ExtraInfo: extraInfo,
+	}
+	left := leaf.NotAfter.Sub(now)
+	switch {
+	case left <= 0:
+		st.Healthy = false
+		st.Severity = components.SeverityCritical
+		st.Reason = fmt.Sprintf("certificate expired at %s", leaf.NotAfter.UTC().Format(time.RFC3339))
+	case left <= c.expiryWarning:
+		st.Healthy = false
+		st.Severity = components.SeverityWarning
+		st.Reason = fmt.Sprintf("certificate expires in %s (at %s)", left.Truncate(time.Minute), leaf.NotAfter.UTC().Format(time.RFC3339))
+	default:
+		st.Reason = fmt.Sprintf("certificate valid until %s", leaf.NotAfter.UTC().Format(time.RFC3339))
+	}
+	return st
+}
+
+func (c *tlsCertComponent) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
+	return nil, nil
+}
+
+func (c *tlsCertComponent) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
+	return nil, nil
+}
+
+func (c *tlsCertComponent) Close() error { return nil }
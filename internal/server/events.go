@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	components_events_state "github.com/leptonai/gpud/components/events/state"
+	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/log"
+)
+
+// eventsSyncInterval is the interval to persist the events of all the components,
+// in addition to persisting the events of each component right after it polls.
+const eventsSyncInterval = time.Minute
+
+// syncEvents persists the events of the component as soon as its poller processes a new result,
+// and periodically persists the events of all the registered components
+// (e.g., the components without the pollers),
+// so that the events are available after restarts and beyond the in-memory queues.
+// The first sync of each component backfills the events within the retention period.
+func (s *Server) syncEvents(ctx context.Context, retention time.Duration) {
+	var (
+		polledMu sync.Mutex
+		polled   = make(map[string]struct{})
+	)
+	polledc := make(chan struct{}, 1)
+	unsubscribe := query.SubscribeResults(func(componentName string) {
+		polledMu.Lock()
+		polled[componentName] = struct{}{}
+		polledMu.Unlock()
+
+		select {
+		case polledc <- struct{}{}:
+		default:
+		}
+	})
+	defer unsubscribe()
+
+	start := time.Now().UTC()
+	lastSync := make(map[string]time.Time)
+	syncComponent := func(name string, c components.Component) {
+		// overlap with the previous sync to not miss the late events
+		// (duplicates are ignored by the table)
+		since := start.Add(-retention)
+		if t, ok := lastSync[name]; ok {
+			since = t.Add(-eventsSyncInterval)
+		}
+		now := time.Now().UTC()
+		if err := components_events_state.Sync(ctx, s.db, components_events_state.DefaultTableName, c, since); err != nil {
+			log.Logger.Warnw("failed to sync events", "component", name, "error", err)
+			return
+		}
+		lastSync[name] = now
+	}
+
+	ticker := time.NewTicker(1) // only first run is 1-ns wait
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			ticker.Reset(eventsSyncInterval)
+			for name, c := range components.GetAllComponents() {
+				syncComponent(name, c)
+			}
+
+		case <-polledc:
+			polledMu.Lock()
+			names := polled
+			polled = make(map[string]struct{})
+			polledMu.Unlock()
+
+			for name := range names {
+				c, err := components.GetComponent(name)
+				if err != nil {
+					continue
+				}
+				syncComponent(name, c)
+			}
+		}
+	}
+}
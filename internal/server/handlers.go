@@ -1,6 +1,7 @@
 package server
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -11,29 +12,33 @@ import (
 
 	lep_components "github.com/leptonai/gpud/components"
 	lep_config "github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/errdefs"
 
 	"github.com/gin-gonic/gin"
 	"sigs.k8s.io/yaml"
 )
 
 type globalHandler struct {
-	cfg            *lep_config.Config
-	components     map[string]lep_components.Component
-	componentNames []string
+	cfg *lep_config.Config
+	db  *sql.DB
 }
 
-func newGlobalHandler(cfg *lep_config.Config, components map[string]lep_components.Component) *globalHandler {
+func newGlobalHandler(cfg *lep_config.Config, db *sql.DB) *globalHandler {
+	return &globalHandler{
+		cfg: cfg,
+		db:  db,
+	}
+}
+
+// componentNames returns the sorted names of the currently registered components.
+// The set may change at runtime when the configuration is reloaded.
+func (g *globalHandler) componentNames() []string {
 	var componentNames []string
-	for name := range components {
+	for name := range lep_components.GetAllComponents() {
 		componentNames = append(componentNames, name)
 	}
 	sort.Strings(componentNames)
-
-	return &globalHandler{
-		cfg:            cfg,
-		components:     components,
-		componentNames: componentNames,
-	}
+	return componentNames
 }
 
 func (g *globalHandler) getReqTime(c *gin.Context) (time.Time, time.Time, error) {
@@ -61,7 +66,7 @@ func (g *globalHandler) getReqTime(c *gin.Context) (time.Time, time.Time, error)
 func (g *globalHandler) getReqComponents(c *gin.Context) ([]string, error) {
 	components := c.Query("components")
 	if components == "" {
-		return g.componentNames, nil
+		return g.componentNames(), nil
 	}
 
 	var ret []string
@@ -119,11 +124,12 @@ func createHealthzHandler() func(ctx *gin.Context) {
 
 const (
 	URLPathConfig     = "/config"
-	URLPathConfigDesc = "Get the configuration of the gpud instance"
+	URLPathConfigDesc = "Get the configuration of the gpud instance, with the secrets redacted"
 )
 
-func createConfigHandler(cfg *lep_config.Config) func(c *gin.Context) {
+func createConfigHandler(getConfig func() lep_config.Config) func(c *gin.Context) {
 	return func(c *gin.Context) {
+		cfg := getConfig().Redacted()
 		if c.GetHeader("Content-Type") == "application/yaml" {
 			yb, err := yaml.Marshal(cfg)
 			if err != nil {
@@ -140,3 +146,63 @@ func createConfigHandler(cfg *lep_config.Config) func(c *gin.Context) {
 		}
 	}
 }
+
+const (
+	URLPathConfigReload     = "/config/reload"
+	URLPathConfigReloadDesc = "Get the last configuration reload result (GET) or reload the configuration file (POST)"
+)
+
+// getConfigReload godoc
+// @Summary Fetch the last configuration reload result
+// @Description get the last configuration reload result
+// @ID getConfigReload
+// @Produce  json
+// @Success 200 {object} ConfigReloadResult
+// @Router /admin/config/reload [get]
+func (s *Server) getConfigReload(c *gin.Context) {
+	result := s.LastConfigReload()
+	if result == nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": errdefs.ErrNotFound, "message": "config not reloaded yet"})
+		return
+	}
+	writeConfigReloadResult(c, http.StatusOK, *result)
+}
+
+// reloadConfigHandler godoc
+// @Summary Reload the configuration file
+// @Description reload the configuration file and apply the component changes
+// @ID reloadConfig
+// @Produce  json
+// @Success 200 {object} ConfigReloadResult
+// @Router /admin/config/reload [post]
+func (s *Server) reloadConfigHandler(c *gin.Context) {
+	result, err := s.ReloadConfig(c.Request.Context(), ConfigReloadTriggerAPI)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"code": errdefs.ErrUnavailable, "message": "failed to reload config " + err.Error()})
+		return
+	}
+
+	code := http.StatusOK
+	if result.Error != "" {
+		code = http.StatusBadRequest
+	}
+	writeConfigReloadResult(c, code, result)
+}
+
+func writeConfigReloadResult(c *gin.Context, code int, result ConfigReloadResult) {
+	switch c.GetHeader(RequestHeaderContentType) {
+	case RequestHeaderYAML:
+		yb, err := yaml.Marshal(result)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to marshal config reload result " + err.Error()})
+			return
+		}
+		c.String(code, string(yb))
+	default:
+		if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+			c.IndentedJSON(code, result)
+		} else {
+			c.JSON(code, result)
+		}
+	}
+}
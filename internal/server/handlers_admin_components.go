@@ -0,0 +1,216 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	lep_components "github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/os"
+	"github.com/leptonai/gpud/components/query"
+	lep_config "github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/errdefs"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	URLPathAdminComponentEnable     = "/components/:component/enable"
+	URLPathAdminComponentEnableDesc = "Enable the component, with the optional component config in the request body"
+
+	URLPathAdminComponentDisable     = "/components/:component/disable"
+	URLPathAdminComponentDisableDesc = "Disable the component"
+
+	URLPathAdminComponentQuery     = "/components/:component/query"
+	URLPathAdminComponentQueryDesc = "Update the poll interval and queue size of the component"
+)
+
+// ComponentQueryUpdate is the request to update the component poller config.
+// Only the fields being set are updated.
+type ComponentQueryUpdate struct {
+	Interval  *metav1.Duration `json:"interval,omitempty"`
+	QueueSize *int             `json:"queue_size,omitempty"`
+}
+
+func (u ComponentQueryUpdate) Validate() error {
+	if u.Interval == nil && u.QueueSize == nil {
+		return errors.New("no field to update")
+	}
+	if u.Interval != nil && u.Interval.Duration <= 0 {
+		return fmt.Errorf("interval must be positive, got %s", u.Interval.Duration)
+	}
+	if u.QueueSize != nil && *u.QueueSize <= 0 {
+		return fmt.Errorf("queue_size must be positive, got %d", *u.QueueSize)
+	}
+	return nil
+}
+
+// enableComponent godoc
+// @Summary Enable the component
+// @Description enable the component at runtime and persist it to the config file, with the optional component config in the request body
+// @ID enableComponent
+// @Param   component     path     string     true         "Component Name"
+// @Produce  json
+// @Success 200 {object} ConfigReloadResult
+// @Router /admin/components/{component}/enable [post]
+func (s *Server) enableComponent(c *gin.Context) {
+	name := c.Param("component")
+	if _, err := lep_components.GetFactory(name); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": errdefs.ErrNotFound, "message": "unknown component " + name})
+		return
+	}
+
+	var raw any
+	b, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to read request body " + err.Error()})
+		return
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse component config " + err.Error()})
+			return
+		}
+	}
+
+	s.updateConfig(c, func(cfg *lep_config.Config) error {
+		// keep the current config of the enabled component, if not given
+		if _, ok := cfg.Components[name]; ok && raw == nil {
+			return nil
+		}
+		cfg.Components[name] = raw
+		return nil
+	})
+}
+
+// disableComponent godoc
+// @Summary Disable the component
+// @Description disable the component at runtime and persist it to the config file
+// @ID disableComponent
+// @Param   component     path     string     true         "Component Name"
+// @Produce  json
+// @Success 200 {object} ConfigReloadResult
+// @Router /admin/components/{component}/disable [post]
+func (s *Server) disableComponent(c *gin.Context) {
+	name := c.Param("component")
+	if name == os.Name {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "component " + name + " is always enabled"})
+		return
+	}
+	if _, ok := s.configSnapshot().Components[name]; !ok {
+		c.JSON(http.StatusNotFound, gin.H{"code": errdefs.ErrNotFound, "message": "component " + name + " not enabled"})
+		return
+	}
+
+	s.updateConfig(c, func(cfg *lep_config.Config) error {
+		if _, ok := cfg.Components[name]; !ok {
+			return fmt.Errorf("component %s not enabled: %w", name, errdefs.ErrNotFound)
+		}
+		delete(cfg.Components, name)
+		return nil
+	})
+}
+
+// updateComponentQuery godoc
+// @Summary Update the component poller config
+// @Description update the poll interval and queue size of the component at runtime and persist it to the config file
+// @ID updateComponentQuery
+// @Param   component     path     string     true         "Component Name"
+// @Param   request       body     ComponentQueryUpdate     true         "Fields to update"
+// @Produce  json
+// @Success 200 {object} ConfigReloadResult
+// @Router /admin/components/{component}/query [put]
+func (s *Server) updateComponentQuery(c *gin.Context) {
+	name := c.Param("component")
+	if _, ok := s.configSnapshot().Components[name]; !ok {
+		c.JSON(http.StatusNotFound, gin.H{"code": errdefs.ErrNotFound, "message": "component " + name + " not enabled"})
+		return
+	}
+
+	var req ComponentQueryUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse request " + err.Error()})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": err.Error()})
+		return
+	}
+	// the shared poller is not restarted until all the components stop it,
+	// thus the new config would not take effect
+	if shared := query.SharedWith(name); len(shared) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": fmt.Sprintf("component %s shares the poller with %s, query config cannot be changed at runtime", name, strings.Join(shared, ", "))})
+		return
+	}
+
+	s.updateConfig(c, func(cfg *lep_config.Config) error {
+		raw, ok := cfg.Components[name]
+		if !ok {
+			return fmt.Errorf("component %s not enabled: %w", name, errdefs.ErrNotFound)
+		}
+		updated, err := s.updateQueryConfig(name, raw, req)
+		if err != nil {
+			return err
+		}
+		cfg.Components[name] = updated
+		return nil
+	})
+}
+
+// updateQueryConfig returns the component config value with the "query" field updated.
+// The nil value is expanded to the default config, so that only the query fields change.
+func (s *Server) updateQueryConfig(name string, raw any, req ComponentQueryUpdate) (map[string]any, error) {
+	if raw == nil {
+		f, err := lep_components.GetFactory(name)
+		if err != nil {
+			return nil, err
+		}
+		raw, err = f.ParseConfig(nil, s.db)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse default config: %w", err)
+		}
+	}
+
+	normalized, err := normalizeJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := normalized.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("component %s config is not an object", name)
+	}
+	query, ok := m["query"].(map[string]any)
+	if !ok {
+		if _, exists := m["query"]; exists {
+			return nil, fmt.Errorf("component %s query config is not an object", name)
+		}
+		query = make(map[string]any)
+	}
+
+	if req.Interval != nil {
+		query["interval"] = req.Interval.Duration.String()
+	}
+	if req.QueueSize != nil {
+		query["queue_size"] = *req.QueueSize
+	}
+	m["query"] = query
+	return m, nil
+}
+
+func (s *Server) updateConfig(c *gin.Context, update func(*lep_config.Config) error) {
+	result, err := s.UpdateConfig(c.Request.Context(), ConfigReloadTriggerAdmin, update)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"code": errdefs.ErrUnavailable, "message": "failed to update config " + err.Error()})
+		return
+	}
+
+	code := http.StatusOK
+	if result.Error != "" {
+		code = http.StatusBadRequest
+	}
+	writeConfigReloadResult(c, code, result)
+}
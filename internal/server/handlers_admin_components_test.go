@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components/cpu"
+	"github.com/leptonai/gpud/components/query"
+	query_config "github.com/leptonai/gpud/components/query/config"
+	lep_config "github.com/leptonai/gpud/config"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateQueryConfig(t *testing.T) {
+	s := &Server{}
+	interval := metav1.Duration{Duration: 30 * time.Second}
+	queueSize := 10
+
+	tests := []struct {
+		name string
+		raw  any
+		req  ComponentQueryUpdate
+		want map[string]any
+	}{
+		{
+			name: "parsed map",
+			raw:  map[string]any{"query": map[string]any{"interval": "1m0s", "queue_size": 60}, "port": 10255},
+			req:  ComponentQueryUpdate{Interval: &interval},
+			want: map[string]any{"query": map[string]any{"interval": "30s", "queue_size": float64(60)}, "port": float64(10255)},
+		},
+		{
+			name: "no query",
+			raw:  map[string]any{"port": 10255},
+			req:  ComponentQueryUpdate{QueueSize: &queueSize},
+			want: map[string]any{"query": map[string]any{"queue_size": 10}, "port": float64(10255)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.updateQueryConfig("test", tt.raw, tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// nil is expanded to the default config
+	got, err := s.updateQueryConfig(cpu.Name, nil, ComponentQueryUpdate{Interval: &interval, QueueSize: &queueSize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := got["query"].(map[string]any)
+	if query["interval"] != "30s" || query["queue_size"] != 10 {
+		t.Fatalf("unexpected query config %v", query)
+	}
+
+	if _, err := s.updateQueryConfig("test", "invalid", ComponentQueryUpdate{Interval: &interval}); err == nil {
+		t.Fatal("expected error for non-object config")
+	}
+
+	if err := (ComponentQueryUpdate{}).Validate(); err == nil {
+		t.Fatal("expected error for empty update")
+	}
+	zero := 0
+	if err := (ComponentQueryUpdate{QueueSize: &zero}).Validate(); err == nil {
+		t.Fatal("expected error for zero queue size")
+	}
+}
+
+func TestUpdateComponentQuerySharedPoller(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pl := query.New("test-admin-shared", query_config.Config{}, func(context.Context) (any, error) { return nil, nil })
+	pl.Start(ctx, query_config.Config{Interval: metav1.Duration{Duration: time.Hour}}, "test-admin-shared-1")
+	defer pl.Stop("test-admin-shared-1")
+	pl.Start(ctx, query_config.Config{Interval: metav1.Duration{Duration: time.Hour}}, "test-admin-shared-2")
+	defer pl.Stop("test-admin-shared-2")
+
+	s := &Server{config: &lep_config.Config{Components: map[string]any{"test-admin-shared-1": nil}}}
+	router := gin.New()
+	router.PUT("/admin"+URLPathAdminComponentQuery, s.updateComponentQuery)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/admin/components/test-admin-shared-1/query", strings.NewReader(`{"interval":"30s"}`))
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "test-admin-shared-2") {
+		t.Fatalf("expected the shared component in the message, got %s", w.Body.String())
+	}
+}
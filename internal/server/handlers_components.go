@@ -2,11 +2,11 @@ package server
 
 import (
 	"net/http"
-	"sort"
 	"time"
 
 	v1 "github.com/leptonai/gpud/api/v1"
 	lep_components "github.com/leptonai/gpud/components"
+	components_events_state "github.com/leptonai/gpud/components/events/state"
 	"github.com/leptonai/gpud/errdefs"
 	"github.com/leptonai/gpud/log"
 
@@ -35,18 +35,36 @@ func (g *globalHandler) registerComponentRoutes(r gin.IRoutes) []componentHandle
 		Desc: URLPathComponentsDesc,
 	})
 
+	r.POST(URLPathComponentsRefresh, g.refreshComponent)
+	paths = append(paths, componentHandlerDescription{
+		Path: URLPathComponentsRefresh,
+		Desc: URLPathComponentsRefreshDesc,
+	})
+
 	r.GET(URLPathStates, g.getStates)
 	paths = append(paths, componentHandlerDescription{
 		Path: URLPathStates,
 		Desc: URLPathStatesDesc,
 	})
 
+	r.GET(URLPathStatesStream, g.streamStates)
+	paths = append(paths, componentHandlerDescription{
+		Path: URLPathStatesStream,
+		Desc: URLPathStatesStreamDesc,
+	})
+
 	r.GET(URLPathEvents, g.getEvents)
 	paths = append(paths, componentHandlerDescription{
 		Path: URLPathEvents,
 		Desc: URLPathEventsDesc,
 	})
 
+	r.GET(URLPathEventsStream, g.streamEvents)
+	paths = append(paths, componentHandlerDescription{
+		Path: URLPathEventsStream,
+		Desc: URLPathEventsStreamDesc,
+	})
+
 	r.GET(URLPathInfo, g.getInfo)
 	paths = append(paths, componentHandlerDescription{
 		Path: URLPathInfo,
@@ -75,11 +93,7 @@ const (
 // @Success 200 {object} []string
 // @Router /v1/components [get]
 func (g *globalHandler) getComponents(c *gin.Context) {
-	components := make([]string, 0, len(g.components))
-	for name := range g.components {
-		components = append(components, name)
-	}
-	sort.Strings(components)
+	components := g.componentNames()
 
 	switch c.GetHeader(RequestHeaderContentType) {
 	case RequestHeaderYAML:
@@ -183,6 +197,8 @@ const (
 // @Description get component Events interface by component name
 // @ID getEvents
 // @Param   component     query    string     false        "Component Name, leave empty to query all components"
+// @Param   type          query    string     false        "Event Type, leave empty to query all event types"
+// @Param   name          query    string     false        "Event Name, leave empty to query all event names"
 // @Produce  json
 // @Success 200 {object} v1.LeptonEvents
 // @Router /v1/events [get]
@@ -198,14 +214,15 @@ func (g *globalHandler) getEvents(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse time: " + err.Error()})
 		return
 	}
+	eventType := c.Query("type")
+	eventName := c.Query("name")
 	for _, componentName := range components {
 		currEvent := v1.LeptonComponentEvents{
 			Component: componentName,
 			StartTime: startTime,
 			EndTime:   endTime,
 		}
-		component, err := lep_components.GetComponent(componentName)
-		if err != nil {
+		if _, err := lep_components.GetComponent(componentName); err != nil {
 			log.Logger.Errorw("failed to get component",
 				"operation", "GetEvents",
 				"component", componentName,
@@ -214,9 +231,21 @@ func (g *globalHandler) getEvents(c *gin.Context) {
 			events = append(events, currEvent)
 			continue
 		}
-		event, err := component.Events(c, startTime)
+
+		// read the events persisted by the central sync (see "syncEvents"),
+		// which persists the events right after each poll
+		event, err := components_events_state.Read(
+			c,
+			g.db,
+			components_events_state.DefaultTableName,
+			componentName,
+			startTime,
+			components_events_state.WithUntil(endTime),
+			components_events_state.WithEventType(eventType),
+			components_events_state.WithEventName(eventName),
+		)
 		if err != nil {
-			log.Logger.Errorw("failed to invoke component events",
+			log.Logger.Errorw("failed to read component events",
 				"operation", "GetEvents",
 				"component", componentName,
 				"error", err,
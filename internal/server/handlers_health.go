@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	lep_components "github.com/leptonai/gpud/components"
+	lep_config "github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/errdefs"
+	"github.com/leptonai/gpud/log"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	URLPathHealth     = "/health"
+	URLPathHealthDesc = "Get the node health aggregated from all gpud components"
+)
+
+// createHealthHandler godoc
+// @Summary Query the node health aggregated from all components
+// @Description get the node health verdict with the per-component breakdown
+// @ID getHealth
+// @Produce  json
+// @Success 200 {object} v1.LeptonHealth
+// @Router /v1/health [get]
+func createHealthHandler(getConfig func() lep_config.Config) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		health := evaluateHealth(c, getConfig().CriticalComponents)
+		writeHealth(c, http.StatusOK, health)
+	}
+}
+
+const (
+	URLPathReady     = "/ready"
+	URLPathReadyDesc = "Get the node readiness, 503 if any critical gpud component is unhealthy"
+)
+
+// createReadyHandler godoc
+// @Summary Query the node readiness
+// @Description returns 503 if any component marked critical in the configuration is unhealthy
+// @ID getReady
+// @Produce  json
+// @Success 200 {object} v1.LeptonHealth
+// @Failure 503 {object} v1.LeptonHealth
+// @Router /v1/ready [get]
+func createReadyHandler(getConfig func() lep_config.Config) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		health := evaluateHealth(c, getConfig().CriticalComponents)
+		code := http.StatusOK
+		if !health.Ready {
+			code = http.StatusServiceUnavailable
+		}
+		writeHealth(c, code, health)
+	}
+}
+
+// evaluateHealth evaluates the states of all the registered components
+// and aggregates them into the node health.
+// The critical components that are not registered are reported as unhealthy.
+func evaluateHealth(ctx context.Context, criticalComponents []string) v1.LeptonHealth {
+	critical := make(map[string]bool, len(criticalComponents))
+	for _, name := range criticalComponents {
+		critical[name] = true
+	}
+
+	all := lep_components.GetAllComponents()
+	names := make([]string, 0, len(all)+len(critical))
+	for name := range all {
+		names = append(names, name)
+	}
+	for name := range critical {
+		if _, ok := all[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	health := v1.LeptonHealth{
+		Healthy: true,
+		Ready:   true,
+	}
+	actions := make([][]lep_components.SuggestedAction, 0, len(names))
+	for _, name := range names {
+		ch := v1.LeptonComponentHealth{
+			Component: name,
+			Critical:  critical[name],
+		}
+		if c, ok := all[name]; ok {
+			_, ch.HealthStatus = componentHealth(ctx, c)
+		} else {
+			ch.HealthStatus = lep_components.HealthStatus{
+				Error: "component not registered",
+				Time:  metav1.Time{Time: time.Now().UTC()},
+			}
+		}
+
+		if !ch.Healthy {
+			health.Healthy = false
+			if ch.Critical {
+				health.Ready = false
+			}
+		}
+		if ch.Severity.MoreSevere(health.Severity) {
+			health.Severity = ch.Severity
+		}
+		actions = append(actions, ch.SuggestedActions)
+		health.Components = append(health.Components, ch)
+	}
+	health.SuggestedActions = lep_components.MergeSuggestedActions(actions...)
+
+	return health
+}
+
+// componentHealth returns the current states of the component and its health.
+// The watchable component tracks the health of its last states,
+// otherwise the health is evaluated from the returned states.
+func componentHealth(ctx context.Context, c lep_components.Component) ([]lep_components.State, lep_components.HealthStatus) {
+	states, err := c.States(ctx)
+	if wc, ok := c.(lep_components.WatchableComponent); ok {
+		if hs, ok := wc.LastHealth(); ok {
+			return states, hs
+		}
+	}
+	if err != nil {
+		log.Logger.Errorw("failed to invoke component state",
+			"operation", "GetHealth",
+			"component", c.Name(),
+			"error", err,
+		)
+		return nil, lep_components.HealthStatus{
+			Error: err.Error(),
+			Time:  metav1.Time{Time: time.Now().UTC()},
+		}
+	}
+	return states, lep_components.EvaluateHealth(states)
+}
+
+func writeHealth(c *gin.Context, code int, health v1.LeptonHealth) {
+	switch c.GetHeader(RequestHeaderContentType) {
+	case RequestHeaderYAML:
+		yb, err := yaml.Marshal(health)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to marshal health " + err.Error()})
+			return
+		}
+		c.String(code, string(yb))
+
+	case RequestHeaderJSON, "":
+		if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+			c.IndentedJSON(code, health)
+			return
+		}
+		c.JSON(code, health)
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid content type"})
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/metrics"
+	lep_config "github.com/leptonai/gpud/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+type testComponent struct {
+	name   string
+	states []components.State
+	err    error
+}
+
+func (c *testComponent) Name() string { return c.name }
+func (c *testComponent) States(ctx context.Context) ([]components.State, error) {
+	return c.states, c.err
+}
+func (c *testComponent) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
+	return nil, nil
+}
+func (c *testComponent) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
+	return nil, nil
+}
+func (c *testComponent) Close() error { return nil }
+
+func TestHealthAndReady(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cs := []components.Component{
+		&testComponent{name: "test-healthy", states: []components.State{{Healthy: true, Severity: components.SeverityInfo}}},
+		&testComponent{name: "test-unhealthy", states: []components.State{{
+			Healthy:          false,
+			Reason:           "xid 79",
+			Severity:         components.SeverityFatal,
+			SuggestedActions: []components.SuggestedAction{components.SuggestedActionReboot},
+		}}},
+		&testComponent{name: "test-error", err: errors.New("failed")},
+	}
+	for _, c := range cs {
+		if err := components.RegisterComponent(c.Name(), metrics.NewWatchableComponent(c)); err != nil {
+			t.Fatal(err)
+		}
+		defer func(name string) {
+			_ = components.DeregisterComponent(name)
+		}(c.Name())
+	}
+
+	tests := []struct {
+		name      string
+		critical  []string
+		path      string
+		wantCode  int
+		wantReady bool
+	}{
+		{name: "health", critical: []string{"test-unhealthy"}, path: URLPathHealth, wantCode: http.StatusOK},
+		{name: "ready without critical", path: URLPathReady, wantCode: http.StatusOK, wantReady: true},
+		{name: "ready with healthy critical", critical: []string{"test-healthy"}, path: URLPathReady, wantCode: http.StatusOK, wantReady: true},
+		{name: "ready with unhealthy critical", critical: []string{"test-unhealthy"}, path: URLPathReady, wantCode: http.StatusServiceUnavailable},
+		{name: "ready with missing critical", critical: []string{"test-missing"}, path: URLPathReady, wantCode: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getConfig := func() lep_config.Config {
+				return lep_config.Config{CriticalComponents: tt.critical}
+			}
+			router := gin.New()
+			router.GET(URLPathHealth, createHealthHandler(getConfig))
+			router.GET(URLPathReady, createReadyHandler(getConfig))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected code %d, got %d", tt.wantCode, w.Code)
+			}
+
+			var health v1.LeptonHealth
+			if err := json.Unmarshal(w.Body.Bytes(), &health); err != nil {
+				t.Fatal(err)
+			}
+			if health.Healthy {
+				t.Fatal("expected unhealthy node")
+			}
+			if health.Ready != tt.wantReady {
+				t.Fatalf("expected ready %v, got %v", tt.wantReady, health.Ready)
+			}
+			if health.Severity != components.SeverityFatal {
+				t.Fatalf("expected fatal severity, got %q", health.Severity)
+			}
+
+			found := make(map[string]v1.LeptonComponentHealth)
+			for _, ch := range health.Components {
+				found[ch.Component] = ch
+			}
+			if !found["test-healthy"].Healthy {
+				t.Fatal("expected test-healthy to be healthy")
+			}
+			if found["test-error"].Healthy || found["test-error"].Error == "" {
+				t.Fatalf("expected test-error to be unhealthy with error, got %+v", found["test-error"])
+			}
+			for _, name := range tt.critical {
+				if !found[name].Critical {
+					t.Fatalf("expected %s to be critical", name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	lep_components "github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/errdefs"
+	"github.com/leptonai/gpud/log"
+
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	URLPathComponentsRefresh     = "/components/:component/refresh"
+	URLPathComponentsRefreshDesc = "Re-poll the component and return the fresh states"
+)
+
+// DefaultRefreshTimeout is the default duration to wait for the refresh.
+const DefaultRefreshTimeout = 30 * time.Second
+
+// refreshComponent godoc
+// @Summary Refresh the component states
+// @Description trigger an out-of-band poll of the component, and return the fresh states
+// @ID refreshComponent
+// @Param   component     path     string     true         "Component Name"
+// @Param   timeout       query    string     false        "Duration to wait for the refresh (e.g., 10s), default 30s"
+// @Produce  json
+// @Success 200 {object} v1.LeptonComponentStates
+// @Router /v1/components/{component}/refresh [post]
+func (g *globalHandler) refreshComponent(c *gin.Context) {
+	componentName := c.Param("component")
+	component, err := lep_components.GetComponent(componentName)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": errdefs.ErrNotFound, "message": "component not found: " + err.Error()})
+		return
+	}
+
+	timeout := DefaultRefreshTimeout
+	if timeoutRaw := c.Query("timeout"); timeoutRaw != "" {
+		timeout, err = time.ParseDuration(timeoutRaw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse timeout: " + err.Error()})
+			return
+		}
+	}
+
+	states, err := refreshStates(c, component, timeout)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			c.JSON(http.StatusGatewayTimeout, gin.H{"code": errdefs.ErrUnavailable, "message": "timed out refreshing component: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"code": errdefs.ErrUnknown, "message": "failed to refresh component: " + err.Error()})
+		return
+	}
+
+	switch c.GetHeader(RequestHeaderContentType) {
+	case RequestHeaderYAML:
+		yb, err := yaml.Marshal(states)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to marshal states " + err.Error()})
+			return
+		}
+		c.String(http.StatusOK, string(yb))
+
+	case RequestHeaderJSON, "":
+		if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+			c.IndentedJSON(http.StatusOK, states)
+			return
+		}
+		c.JSON(http.StatusOK, states)
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid content type"})
+	}
+}
+
+// refreshStates re-polls the component and returns its states.
+// The component without any poller (e.g., watching the events)
+// returns the current states as is.
+func refreshStates(ctx context.Context, component lep_components.Component, timeout time.Duration) (v1.LeptonComponentStates, error) {
+	cctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ret := v1.LeptonComponentStates{Component: component.Name()}
+	if err := query.Refresh(cctx, component.Name()); err != nil {
+		if !errdefs.IsNotFound(err) {
+			return ret, err
+		}
+		log.Logger.Debugw("no poller to refresh", "component", component.Name())
+	}
+
+	states, err := component.States(cctx)
+	if err != nil {
+		return ret, err
+	}
+	ret.States = states
+	return ret, nil
+}
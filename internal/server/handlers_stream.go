@@ -0,0 +1,227 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	components_events_state "github.com/leptonai/gpud/components/events/state"
+	components_states_state "github.com/leptonai/gpud/components/states/state"
+	"github.com/leptonai/gpud/errdefs"
+	"github.com/leptonai/gpud/log"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RequestHeaderLastEventID is set by the server-sent events client
+	// to resume the stream from the last received event.
+	RequestHeaderLastEventID = "Last-Event-ID"
+
+	// streamPollInterval is the interval to check for the new events and state changes.
+	streamPollInterval = 2 * time.Second
+	// streamKeepAliveInterval is the interval to send the keep-alive comment
+	// when there is nothing to send, so that the proxies do not close the idle connection.
+	streamKeepAliveInterval = 30 * time.Second
+	// streamReadLimit bounds the number of records to send at once
+	// (e.g., when resuming from an old event id).
+	streamReadLimit = 1000
+)
+
+const (
+	URLPathEventsStream     = "/events/stream"
+	URLPathEventsStreamDesc = "Stream the new events of gpud components as server-sent events"
+)
+
+// streamEvents godoc
+// @Summary Stream component events in gpud
+// @Description stream the new component events as server-sent events, resumable with the "Last-Event-ID" header
+// @ID streamEvents
+// @Param   components     query    string     false        "Comma-separated component names, leave empty to stream all components"
+// @Param   last_event_id  query    string     false        "Resume from the event id, same as the Last-Event-ID header"
+// @Produce  text/event-stream
+// @Success 200 {object} components_events_state.Record
+// @Router /v1/events/stream [get]
+func (g *globalHandler) streamEvents(c *gin.Context) {
+	names, lastID, ok := g.getReqStream(c, components_events_state.LastID, components_events_state.DefaultTableName)
+	if !ok {
+		return
+	}
+
+	// only tail the table, the events are persisted by the central sync (see "syncEvents")
+	// right after each poll, not to write the same events per client
+	g.stream(c, "event", lastID, func(ctx context.Context, afterID int64) ([]streamRecord, error) {
+		records, err := components_events_state.ReadAfter(
+			ctx,
+			g.db,
+			components_events_state.DefaultTableName,
+			afterID,
+			components_events_state.WithComponents(names...),
+			components_events_state.WithLimit(streamReadLimit),
+		)
+		if err != nil {
+			return nil, err
+		}
+		rs := make([]streamRecord, 0, len(records))
+		for _, r := range records {
+			rs = append(rs, streamRecord{id: r.ID, data: r})
+		}
+		return rs, nil
+	})
+}
+
+const (
+	URLPathStatesStream     = "/states/stream"
+	URLPathStatesStreamDesc = "Stream the state changes of gpud components as server-sent events"
+)
+
+// streamStates godoc
+// @Summary Stream component state changes in gpud
+// @Description stream the component health transitions with the states as server-sent events, resumable with the "Last-Event-ID" header
+// @ID streamStates
+// @Param   components     query    string     false        "Comma-separated component names, leave empty to stream all components"
+// @Param   last_event_id  query    string     false        "Resume from the event id, same as the Last-Event-ID header"
+// @Produce  text/event-stream
+// @Success 200 {object} components_states_state.Record
+// @Router /v1/states/stream [get]
+func (g *globalHandler) streamStates(c *gin.Context) {
+	names, lastID, ok := g.getReqStream(c, components_states_state.LastID, components_states_state.DefaultTableName)
+	if !ok {
+		return
+	}
+
+	g.stream(c, "state", lastID, func(ctx context.Context, afterID int64) ([]streamRecord, error) {
+		records, err := components_states_state.ReadAfter(
+			ctx,
+			g.db,
+			components_states_state.DefaultTableName,
+			afterID,
+			components_states_state.WithComponents(names...),
+			components_states_state.WithLimit(streamReadLimit),
+		)
+		if err != nil {
+			return nil, err
+		}
+		rs := make([]streamRecord, 0, len(records))
+		for _, r := range records {
+			rs = append(rs, streamRecord{id: r.ID, data: r})
+		}
+		return rs, nil
+	})
+}
+
+type streamRecord struct {
+	id   int64
+	data any
+}
+
+// getReqStream returns the component names to filter (nil for all components)
+// and the id to stream from.
+// If the client does not resume from an event id, only the new records are streamed.
+// Returns false if the request is invalid, with the error response written.
+func (g *globalHandler) getReqStream(
+	c *gin.Context,
+	lastIDFunc func(ctx context.Context, db *sql.DB, tableName string) (int64, error),
+	tableName string,
+) ([]string, int64, bool) {
+	var names []string
+	if c.Query("components") != "" {
+		var err error
+		names, err = g.getReqComponents(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse components: " + err.Error()})
+			return nil, 0, false
+		}
+	}
+
+	lastEventID := c.GetHeader(RequestHeaderLastEventID)
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	if lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || id < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid last event id " + lastEventID})
+			return nil, 0, false
+		}
+		return names, id, true
+	}
+
+	id, err := lastIDFunc(c, g.db, tableName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to read last event id " + err.Error()})
+		return nil, 0, false
+	}
+	return names, id, true
+}
+
+// stream writes the records returned by "read" as server-sent events
+// until the client disconnects.
+func (g *globalHandler) stream(c *gin.Context, event string, lastID int64, read func(ctx context.Context, afterID int64) ([]streamRecord, error)) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	ctx := c.Request.Context()
+	ticker := time.NewTicker(streamPollInterval)
+	defer ticker.Stop()
+
+	lastSent := time.Now()
+	for {
+		records, err := read(ctx, lastID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Logger.Warnw("failed to read records to stream", "event", event, "error", err)
+		}
+		for _, r := range records {
+			if err := writeServerSentEvent(c.Writer, r.id, event, r.data); err != nil {
+				log.Logger.Debugw("failed to write server-sent event", "event", event, "error", err)
+				return
+			}
+			lastID = r.id
+		}
+
+		if len(records) > 0 {
+			lastSent = time.Now()
+			c.Writer.Flush()
+		} else if time.Since(lastSent) >= streamKeepAliveInterval {
+			if _, err := io.WriteString(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			lastSent = time.Now()
+			c.Writer.Flush()
+		}
+
+		// keep reading without wait if there are more records than the limit
+		if len(records) >= streamReadLimit {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeServerSentEvent writes the event in the server-sent events format.
+// ref. https://html.spec.whatwg.org/multipage/server-sent-events.html
+func writeServerSentEvent(w io.Writer, id int64, event string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, strings.TrimSpace(string(b)))
+	return err
+}
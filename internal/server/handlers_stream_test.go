@@ -0,0 +1,139 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	components_events_state "github.com/leptonai/gpud/components/events/state"
+	"github.com/leptonai/gpud/components/state"
+	components_states_state "github.com/leptonai/gpud/components/states/state"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	db, err := state.Open(filepath.Join(t.TempDir(), "gpud.state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := components_events_state.CreateTable(ctx, db, components_events_state.DefaultTableName); err != nil {
+		t.Fatal(err)
+	}
+	if err := components_states_state.CreateTable(ctx, db, components_states_state.DefaultTableName); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &testComponent{name: "test-stream"}
+	if err := components.RegisterComponent(c.Name(), c); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = components.DeregisterComponent(c.Name())
+	}()
+
+	now := time.Now().UTC()
+	for i, name := range []string{"test-stream", "test-other", "test-stream"} {
+		ev := components.Event{Time: metav1.Time{Time: now.Add(time.Duration(i) * time.Second)}, Name: "xid", Type: components.EventTypeError}
+		if err := components_events_state.Insert(ctx, db, components_events_state.DefaultTableName, name, ev); err != nil {
+			t.Fatal(err)
+		}
+		hs := components.HealthStatus{Healthy: i%2 == 0, Time: metav1.Time{Time: now.Add(time.Duration(i) * time.Second)}}
+		if err := components_states_state.Insert(ctx, db, components_states_state.DefaultTableName, name, hs, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g := newGlobalHandler(nil, db)
+	router := gin.New()
+	v1 := router.Group("/v1")
+	v1.Use(newGzipMiddleware())
+	v1.GET(URLPathEventsStream, g.streamEvents)
+	v1.GET(URLPathStatesStream, g.streamStates)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	tests := []struct {
+		path      string
+		event     string
+		lastID    string
+		wantIDs   []string
+		wantError bool
+	}{
+		{path: URLPathEventsStream, event: "event", lastID: "0", wantIDs: []string{"1", "3"}},
+		{path: URLPathEventsStream, event: "event", lastID: "1", wantIDs: []string{"3"}},
+		{path: URLPathStatesStream, event: "state", lastID: "0", wantIDs: []string{"1", "3"}},
+		{path: URLPathStatesStream, event: "state", lastID: "invalid", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path+"/"+tt.lastID, func(t *testing.T) {
+			rctx, rcancel := context.WithCancel(ctx)
+			defer rcancel()
+
+			req, err := http.NewRequestWithContext(rctx, http.MethodGet, srv.URL+"/v1"+tt.path+"?components=test-stream", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set(RequestHeaderLastEventID, tt.lastID)
+			// without "Accept: text/event-stream", the stream must not be compressed
+			req.Header.Set("Accept-Encoding", "gzip")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if tt.wantError {
+				if resp.StatusCode != http.StatusBadRequest {
+					t.Fatalf("expected 400, got %d", resp.StatusCode)
+				}
+				return
+			}
+			if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+				t.Fatalf("unexpected content type %q", ct)
+			}
+			if ce := resp.Header.Get("Content-Encoding"); ce != "" {
+				t.Fatalf("unexpected content encoding %q", ce)
+			}
+
+			ids := make([]string, 0)
+			scanner := bufio.NewScanner(resp.Body)
+			for len(ids) < len(tt.wantIDs) && scanner.Scan() {
+				line := scanner.Text()
+				switch {
+				case strings.HasPrefix(line, "id: "):
+					ids = append(ids, strings.TrimPrefix(line, "id: "))
+				case strings.HasPrefix(line, "event: "):
+					if got := strings.TrimPrefix(line, "event: "); got != tt.event {
+						t.Fatalf("expected event %q, got %q", tt.event, got)
+					}
+				case strings.HasPrefix(line, "data: "):
+					var m map[string]any
+					if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &m); err != nil {
+						t.Fatal(err)
+					}
+					if m["component"] != "test-stream" {
+						t.Fatalf("unexpected component %v", m["component"])
+					}
+				}
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.wantIDs, ",") {
+				t.Fatalf("expected ids %v, got %v", tt.wantIDs, ids)
+			}
+		})
+	}
+}
@@ -0,0 +1,395 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	goOS "os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/metrics"
+	components_metrics_state "github.com/leptonai/gpud/components/metrics/state"
+	"github.com/leptonai/gpud/components/os"
+	"github.com/leptonai/gpud/components/state"
+	lepconfig "github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/log"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// configWatchInterval is the interval to check the configuration file for changes.
+// Poll is cheaper and more portable than inotify for a single file
+// that rarely changes.
+const configWatchInterval = 30 * time.Second
+
+const (
+	ConfigReloadTriggerSignal  = "signal"
+	ConfigReloadTriggerFile    = "file"
+	ConfigReloadTriggerAPI     = "api"
+	ConfigReloadTriggerAdmin   = "admin"
+	ConfigReloadTriggerSession = "session"
+)
+
+// ConfigReloadResult is the result of the configuration reload.
+type ConfigReloadResult struct {
+	Time    metav1.Time `json:"time"`
+	Trigger string      `json:"trigger"`
+	File    string      `json:"file"`
+
+	// Components newly created and registered.
+	Added []string `json:"added,omitempty"`
+	// Components closed and deregistered.
+	Removed []string `json:"removed,omitempty"`
+	// Components closed and recreated with the new configuration.
+	Changed []string `json:"changed,omitempty"`
+
+	// Persisted is true if the applied configuration is in the config file, thus kept after restarts.
+	// False if the update is only applied in memory (e.g., no "--config" flag), thus lost on restart.
+	Persisted bool `json:"persisted"`
+
+	Error string `json:"error,omitempty"`
+}
+
+type configReloadRequest struct {
+	trigger string
+	// update modifies the configuration before applying, if not nil
+	update  func(*lepconfig.Config) error
+	resultC chan ConfigReloadResult
+}
+
+// ReloadConfig reloads the configuration file and applies the component changes.
+// Only the components and the critical components are reloaded;
+// other fields (e.g., address) require a restart.
+// The context only bounds the wait, the components are created with the server context.
+func (s *Server) ReloadConfig(ctx context.Context, trigger string) (ConfigReloadResult, error) {
+	return s.sendConfigReload(ctx, configReloadRequest{
+		trigger: trigger,
+		resultC: make(chan ConfigReloadResult, 1),
+	})
+}
+
+// UpdateConfig modifies the configuration with "update" and applies the component changes.
+// The modified configuration is persisted to the configuration file, if any.
+// Otherwise, the currently applied configuration is modified in memory,
+// and the result is reported with "Persisted" false.
+func (s *Server) UpdateConfig(ctx context.Context, trigger string, update func(*lepconfig.Config) error) (ConfigReloadResult, error) {
+	return s.sendConfigReload(ctx, configReloadRequest{
+		trigger: trigger,
+		update:  update,
+		resultC: make(chan ConfigReloadResult, 1),
+	})
+}
+
+func (s *Server) sendConfigReload(ctx context.Context, req configReloadRequest) (ConfigReloadResult, error) {
+	select {
+	case <-ctx.Done():
+		return ConfigReloadResult{}, ctx.Err()
+	case s.configReloadC <- req:
+	}
+	select {
+	case <-ctx.Done():
+		return ConfigReloadResult{}, ctx.Err()
+	case res := <-req.resultC:
+		return res, nil
+	}
+}
+
+// configSnapshot returns a copy of the currently applied configuration.
+func (s *Server) configSnapshot() lepconfig.Config {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+
+	cfg := *s.config
+	cfg.Components = make(map[string]any, len(s.config.Components))
+	for k, v := range s.config.Components {
+		cfg.Components[k] = v
+	}
+	return cfg
+}
+
+// LastConfigReload returns the result of the last configuration reload, if any.
+func (s *Server) LastConfigReload() *ConfigReloadResult {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.lastConfigReload
+}
+
+// watchConfig serializes all the reload requests and
+// polls the configuration file for changes.
+func (s *Server) watchConfig(ctx context.Context) {
+	ticker := time.NewTicker(configWatchInterval)
+	defer ticker.Stop()
+
+	lastModTime, lastSize := statConfigFile(s.configFile)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case req := <-s.configReloadC:
+			req.resultC <- s.reloadConfig(ctx, req.trigger, req.update)
+			lastModTime, lastSize = statConfigFile(s.configFile)
+
+		case <-ticker.C:
+			if s.configFile == "" {
+				continue
+			}
+			modTime, size := statConfigFile(s.configFile)
+			if modTime.IsZero() || (modTime.Equal(lastModTime) && size == lastSize) {
+				continue
+			}
+			lastModTime, lastSize = modTime, size
+
+			log.Logger.Infow("config file changed", "file", s.configFile)
+			s.reloadConfig(ctx, ConfigReloadTriggerFile, nil)
+		}
+	}
+}
+
+func statConfigFile(file string) (time.Time, int64) {
+	if file == "" {
+		return time.Time{}, 0
+	}
+	info, err := goOS.Stat(file)
+	if err != nil {
+		log.Logger.Warnw("failed to stat config file", "file", file, "error", err)
+		return time.Time{}, 0
+	}
+	return info.ModTime(), info.Size()
+}
+
+func (s *Server) reloadConfig(ctx context.Context, trigger string, update func(*lepconfig.Config) error) ConfigReloadResult {
+	result := ConfigReloadResult{
+		Time:    metav1.Time{Time: time.Now().UTC()},
+		Trigger: trigger,
+		File:    s.configFile,
+	}
+	defer func() {
+		if result.Error != "" {
+			log.Logger.Warnw("failed to reload config", "trigger", trigger, "error", result.Error)
+		} else {
+			log.Logger.Infow("reloaded config", "trigger", trigger, "added", result.Added, "removed", result.Removed, "changed", result.Changed)
+		}
+
+		s.configMu.Lock()
+		s.lastConfigReload = &result
+		s.configMu.Unlock()
+	}()
+
+	var newCfg *lepconfig.Config
+	switch {
+	case s.configFile != "":
+		var err error
+		newCfg, err = lepconfig.LoadConfigYAML(s.configFile)
+		if err != nil {
+			result.Error = fmt.Sprintf("failed to load config: %v", err)
+			return result
+		}
+	case update != nil:
+		cfg := s.configSnapshot()
+		newCfg = &cfg
+	default:
+		result.Error = "no config file to reload from"
+		return result
+	}
+	if update != nil {
+		if newCfg.Components == nil {
+			newCfg.Components = make(map[string]any)
+		}
+		if err := update(newCfg); err != nil {
+			result.Error = fmt.Sprintf("failed to update config: %v", err)
+			return result
+		}
+	}
+	if err := newCfg.Validate(); err != nil {
+		result.Error = fmt.Sprintf("failed to validate config: %v", err)
+		return result
+	}
+	if err := checkDependencies(newCfg); err != nil {
+		result.Error = fmt.Sprintf("dependency check failed: %v", err)
+		return result
+	}
+	if update != nil && s.configFile != "" {
+		if err := newCfg.SyncYAML(s.configFile); err != nil {
+			result.Error = fmt.Sprintf("failed to persist config: %v", err)
+			return result
+		}
+	}
+	result.Persisted = s.configFile != ""
+	if !result.Persisted {
+		log.Logger.Warnw("no config file to persist the updated config, will be lost on restart", "trigger", trigger)
+	}
+
+	s.configMu.RLock()
+	prevComponents := s.config.Components
+	s.configMu.RUnlock()
+
+	added, removed, changed, err := diffComponentConfigs(withDefaultComponents(prevComponents), withDefaultComponents(newCfg.Components))
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to diff component configs: %v", err)
+		return result
+	}
+	result.Added, result.Removed, result.Changed = added, removed, changed
+
+	// close the old ones first, since the underlying pollers are shared
+	// and reference-counted by the component name
+	for _, name := range append(append([]string{}, removed...), changed...) {
+		if err := s.unregisterComponent(name); err != nil {
+			log.Logger.Warnw("failed to unregister component", "name", name, "error", err)
+		}
+	}
+
+	applied := make(map[string]any, len(newCfg.Components))
+	for k, v := range newCfg.Components {
+		applied[k] = v
+	}
+
+	errs := make([]string, 0)
+	for _, name := range append(append([]string{}, added...), changed...) {
+		c, err := s.newComponent(ctx, newCfg, name, newCfg.Components[name])
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("component %s: %v", name, err))
+			delete(applied, name)
+			continue
+		}
+		if err := s.registerComponent(c); err != nil {
+			errs = append(errs, fmt.Sprintf("component %s: %v", name, err))
+			delete(applied, name)
+			if cerr := c.Close(); cerr != nil {
+				log.Logger.Warnw("failed to close component", "name", name, "error", cerr)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		result.Error = strings.Join(errs, "; ")
+	}
+
+	s.configMu.Lock()
+	s.config.Components = applied
+	s.config.CriticalComponents = newCfg.CriticalComponents
+	s.configMu.Unlock()
+
+	componentNames := make([]string, 0)
+	for name := range components.GetAllComponents() {
+		componentNames = append(componentNames, name)
+	}
+	sort.Strings(componentNames)
+	if err := state.UpdateComponents(ctx, s.db, s.uid, strings.Join(componentNames, ",")); err != nil {
+		log.Logger.Warnw("failed to update components", "error", err)
+	}
+
+	return result
+}
+
+// registerComponent wraps the component to track its health,
+// registers it to the default set and its prometheus collectors.
+func (s *Server) registerComponent(c components.Component) error {
+	metrics.SetRegistered(c.Name())
+	c = metrics.NewWatchableComponent(c)
+
+	if err := components.RegisterComponent(c.Name(), c); err != nil {
+		return err
+	}
+
+	if orig, ok := c.(interface{ Unwrap() interface{} }); ok {
+		if prov, ok := orig.Unwrap().(components.PromRegisterer); ok {
+			// collectors are package-level, thus already registered
+			// if the component was created before
+			err := prov.RegisterCollectors(s.promReg, s.db, components_metrics_state.DefaultTableName)
+			var alreadyRegistered prometheus.AlreadyRegisteredError
+			if err != nil && !errors.As(err, &alreadyRegistered) {
+				_ = components.DeregisterComponent(c.Name())
+				return fmt.Errorf("failed to register metrics for component %s: %w", c.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// unregisterComponent closes the component and removes it from the default set.
+func (s *Server) unregisterComponent(name string) error {
+	c, err := components.GetComponent(name)
+	if err != nil {
+		return err
+	}
+	if err := components.DeregisterComponent(name); err != nil {
+		return err
+	}
+	metrics.SetUnregistered(name)
+	return c.Close()
+}
+
+// withDefaultComponents returns the component configs
+// with the components that are always enabled.
+func withDefaultComponents(cfgs map[string]any) map[string]any {
+	copied := make(map[string]any, len(cfgs)+1)
+	for k, v := range cfgs {
+		copied[k] = v
+	}
+	if _, ok := copied[os.Name]; !ok {
+		copied[os.Name] = nil
+	}
+	return copied
+}
+
+// diffComponentConfigs returns the sorted names of the components
+// that are added, removed, or changed from "prev" to "cur".
+// The configs are compared by their JSON encoding, since the previous
+// configs may be typed structs while the new ones are parsed from YAML.
+func diffComponentConfigs(prev, cur map[string]any) (added, removed, changed []string, err error) {
+	for name, curCfg := range cur {
+		prevCfg, ok := prev[name]
+		if !ok {
+			added = append(added, name)
+			continue
+		}
+
+		eq, err := equalJSON(prevCfg, curCfg)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to compare component %s config: %w", name, err)
+		}
+		if !eq {
+			changed = append(changed, name)
+		}
+	}
+	for name := range prev {
+		if _, ok := cur[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed, nil
+}
+
+func equalJSON(a, b any) (bool, error) {
+	na, err := normalizeJSON(a)
+	if err != nil {
+		return false, err
+	}
+	nb, err := normalizeJSON(b)
+	if err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(na, nb), nil
+}
+
+func normalizeJSON(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var n any
+	if err := json.Unmarshal(b, &n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
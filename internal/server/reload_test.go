@@ -0,0 +1,77 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/leptonai/gpud/components/os"
+)
+
+func TestDiffComponentConfigs(t *testing.T) {
+	type cfg struct {
+		Interval string `json:"interval"`
+		Port     int    `json:"port"`
+	}
+
+	tests := []struct {
+		name        string
+		prev        map[string]any
+		cur         map[string]any
+		wantAdded   []string
+		wantRemoved []string
+		wantChanged []string
+	}{
+		{
+			name: "no change",
+			prev: map[string]any{"cpu": nil, "disk": cfg{Interval: "1m"}},
+			cur:  map[string]any{"cpu": nil, "disk": cfg{Interval: "1m"}},
+		},
+		{
+			name: "typed struct and parsed map are equal",
+			prev: map[string]any{"k8s-pod": cfg{Interval: "1m", Port: 10255}},
+			cur:  map[string]any{"k8s-pod": map[string]any{"port": 10255, "interval": "1m"}},
+		},
+		{
+			name:        "added, removed, and changed",
+			prev:        map[string]any{"cpu": nil, "disk": nil, "k8s-pod": cfg{Port: 10255}},
+			cur:         map[string]any{"cpu": nil, "memory": nil, "fd": nil, "k8s-pod": cfg{Port: 10250}},
+			wantAdded:   []string{"fd", "memory"},
+			wantRemoved: []string{"disk"},
+			wantChanged: []string{"k8s-pod"},
+		},
+		{
+			name:        "nil to non-nil",
+			prev:        map[string]any{"cpu": nil},
+			cur:         map[string]any{"cpu": cfg{Interval: "5m"}},
+			wantChanged: []string{"cpu"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed, changed, err := diffComponentConfigs(tt.prev, tt.cur)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(added, tt.wantAdded) {
+				t.Errorf("added = %v, want %v", added, tt.wantAdded)
+			}
+			if !reflect.DeepEqual(removed, tt.wantRemoved) {
+				t.Errorf("removed = %v, want %v", removed, tt.wantRemoved)
+			}
+			if !reflect.DeepEqual(changed, tt.wantChanged) {
+				t.Errorf("changed = %v, want %v", changed, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestWithDefaultComponents(t *testing.T) {
+	prev := map[string]any{"cpu": nil}
+	cur := withDefaultComponents(prev)
+	if _, ok := cur[os.Name]; !ok {
+		t.Fatalf("expected %q to be set", os.Name)
+	}
+	if _, ok := prev[os.Name]; ok {
+		t.Fatalf("expected the original map to be unchanged")
+	}
+}
@@ -2,77 +2,48 @@ package server
 
 import (
 	"context"
-	"crypto/ecdsa"
-	"crypto/elliptic"
-	"crypto/rand"
 	"crypto/tls"
-	"crypto/x509"
-	"crypto/x509/pkix"
 	"database/sql"
-	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
-	"math/big"
+	"net"
 	"net/http"
 	"net/http/pprof"
 	goOS "os"
 	"path"
+	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/gin-contrib/gzip"
 
 	"github.com/gin-gonic/gin"
-	"github.com/nxadm/tail"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
 	"github.com/leptonai/gpud/components"
-	nvidia_clock "github.com/leptonai/gpud/components/accelerator/nvidia/clock"
-	nvidia_clockspeed "github.com/leptonai/gpud/components/accelerator/nvidia/clock-speed"
-	nvidia_ecc "github.com/leptonai/gpud/components/accelerator/nvidia/ecc"
-	nvidia_error "github.com/leptonai/gpud/components/accelerator/nvidia/error"
-	nvidia_error_sxid "github.com/leptonai/gpud/components/accelerator/nvidia/error/sxid"
-	nvidia_error_xid "github.com/leptonai/gpud/components/accelerator/nvidia/error/xid"
-	nvidia_fabric_manager "github.com/leptonai/gpud/components/accelerator/nvidia/fabric-manager"
-	nvidia_infiniband "github.com/leptonai/gpud/components/accelerator/nvidia/infiniband"
-	nvidia_info "github.com/leptonai/gpud/components/accelerator/nvidia/info"
-	nvidia_memory "github.com/leptonai/gpud/components/accelerator/nvidia/memory"
-	nvidia_nvlink "github.com/leptonai/gpud/components/accelerator/nvidia/nvlink"
-	nvidia_peermem "github.com/leptonai/gpud/components/accelerator/nvidia/peermem"
-	nvidia_power "github.com/leptonai/gpud/components/accelerator/nvidia/power"
-	nvidia_processes "github.com/leptonai/gpud/components/accelerator/nvidia/processes"
 	nvidia_query "github.com/leptonai/gpud/components/accelerator/nvidia/query"
 	nvidia_query_nvml "github.com/leptonai/gpud/components/accelerator/nvidia/query/nvml"
-	nvidia_temperature "github.com/leptonai/gpud/components/accelerator/nvidia/temperature"
-	nvidia_utilization "github.com/leptonai/gpud/components/accelerator/nvidia/utilization"
-	containerd_pod "github.com/leptonai/gpud/components/containerd/pod"
-	"github.com/leptonai/gpud/components/cpu"
-	"github.com/leptonai/gpud/components/disk"
-	"github.com/leptonai/gpud/components/dmesg"
-	docker_container "github.com/leptonai/gpud/components/docker/container"
-	"github.com/leptonai/gpud/components/fd"
-	"github.com/leptonai/gpud/components/info"
-	k8s_pod "github.com/leptonai/gpud/components/k8s/pod"
-	"github.com/leptonai/gpud/components/memory"
-	memory_metrics "github.com/leptonai/gpud/components/memory/metrics"
+	components_events_state "github.com/leptonai/gpud/components/events/state"
 	"github.com/leptonai/gpud/components/metrics"
 	components_metrics_state "github.com/leptonai/gpud/components/metrics/state"
-	network_latency "github.com/leptonai/gpud/components/network/latency"
 	"github.com/leptonai/gpud/components/os"
-	power_supply "github.com/leptonai/gpud/components/power-supply"
-	query_config "github.com/leptonai/gpud/components/query/config"
-	query_log_config "github.com/leptonai/gpud/components/query/log/config"
 	query_log_state "github.com/leptonai/gpud/components/query/log/state"
 	"github.com/leptonai/gpud/components/state"
-	component_systemd "github.com/leptonai/gpud/components/systemd"
-	"github.com/leptonai/gpud/components/tailscale"
+	components_states_state "github.com/leptonai/gpud/components/states/state"
 	lepconfig "github.com/leptonai/gpud/config"
 	_ "github.com/leptonai/gpud/docs/apis"
+	"github.com/leptonai/gpud/internal/controlplane"
+	"github.com/leptonai/gpud/internal/k8snode"
 	"github.com/leptonai/gpud/internal/login"
+	"github.com/leptonai/gpud/internal/notifier"
+	"github.com/leptonai/gpud/internal/otlp"
+	"github.com/leptonai/gpud/internal/remotewrite"
 	"github.com/leptonai/gpud/internal/session"
 	"github.com/leptonai/gpud/log"
 )
@@ -85,9 +56,17 @@ type Server struct {
 	fifoPath              string
 	fifo                  *goOS.File
 	session               *session.Session
+	promReg               *prometheus.Registry
+
+	// configFile is the file to reload the configuration from (if not empty).
+	configFile       string
+	configMu         sync.RWMutex
+	config           *lepconfig.Config
+	lastConfigReload *ConfigReloadResult
+	configReloadC    chan configReloadRequest
 }
 
-func New(ctx context.Context, config *lepconfig.Config, endpoint string) (_ *Server, retErr error) {
+func New(ctx context.Context, config *lepconfig.Config, endpoint string, configFile string) (_ *Server, retErr error) {
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("failed to validate config: %w", err)
 	}
@@ -105,8 +84,11 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string) (_ *Ser
 		return nil, fmt.Errorf("failed to get fifo path: %w", err)
 	}
 	s := &Server{
-		db:       db,
-		fifoPath: fifoPath,
+		db:            db,
+		fifoPath:      fifoPath,
+		configFile:    configFile,
+		config:        config,
+		configReloadC: make(chan configReloadRequest),
 	}
 	defer func() {
 		if retErr != nil {
@@ -135,6 +117,12 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string) (_ *Ser
 	if err := query_log_state.CreateTable(ctx, db); err != nil {
 		return nil, fmt.Errorf("failed to create query log state table: %w", err)
 	}
+	if err := components_events_state.CreateTable(ctx, db, components_events_state.DefaultTableName); err != nil {
+		return nil, fmt.Errorf("failed to create events table: %w", err)
+	}
+	if err := components_states_state.CreateTable(ctx, db, components_states_state.DefaultTableName); err != nil {
+		return nil, fmt.Errorf("failed to create states table: %w", err)
+	}
 
 	go func() {
 		dur := config.RetentionPeriod.Duration
@@ -151,477 +139,54 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string) (_ *Ser
 				} else {
 					log.Logger.Debugw("purged metrics", "purged", purged)
 				}
+
+				purged, err = components_events_state.Purge(ctx, db, components_events_state.DefaultTableName, before)
+				if err != nil {
+					log.Logger.Warnw("failed to purge events", "error", err)
+				} else {
+					log.Logger.Debugw("purged events", "purged", purged)
+				}
+
+				purged, err = components_states_state.Purge(ctx, db, components_states_state.DefaultTableName, before)
+				if err != nil {
+					log.Logger.Warnw("failed to purge states", "error", err)
+				} else {
+					log.Logger.Debugw("purged states", "purged", purged)
+				}
 			}
 		}
 	}()
 
-	defaultStateCfg := query_config.State{DB: db}
-	defaultQueryCfg := query_config.Config{State: &defaultStateCfg}
-	defaultLogCfg := query_log_config.Config{
-		Query: defaultQueryCfg,
-		DB:    db,
-		SeekInfoSyncer: func(ctx context.Context, file string, seekInfo tail.SeekInfo) {
-			if err := query_log_state.Insert(ctx, db, file, seekInfo.Offset, int64(seekInfo.Whence)); err != nil {
-				log.Logger.Errorw("failed to sync seek info", "error", err)
-			}
-		},
-	}
-
 	if err := checkDependencies(config); err != nil {
 		return nil, fmt.Errorf("dependency check failed: %w", err)
 	}
 
 	allComponents := make([]components.Component, 0)
 	if _, ok := config.Components[os.Name]; !ok {
-		allComponents = append(allComponents, os.New(ctx, os.Config{Query: defaultQueryCfg}))
+		c, err := s.newComponent(ctx, config, os.Name, nil)
+		if err != nil {
+			return nil, err
+		}
+		allComponents = append(allComponents, c)
 	}
 
 	for k, configValue := range config.Components {
-		switch k {
-		case cpu.Name:
-			cfg := cpu.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := cpu.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, cpu.New(ctx, cfg))
-
-		case disk.Name:
-			cfg := disk.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := disk.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, disk.New(ctx, cfg))
-
-		case dmesg.Name:
-			cfg := dmesg.Config{Log: defaultLogCfg}
-			if configValue != nil {
-				parsed, err := dmesg.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-
-			// nvidia_error_xid cannot be used without dmesg
-			nvrmXidFilterFound := false
-			if _, ok := config.Components[nvidia_error_xid.Name]; ok {
-				for _, f := range cfg.Log.SelectFilters {
-					if f.Name == dmesg.EventNvidiaNVRMXid {
-						nvrmXidFilterFound = true
-						break
-					}
-				}
-				if !nvrmXidFilterFound {
-					return nil, fmt.Errorf("%q enabled but dmesg config missing %q filter", nvidia_error_xid.Name, dmesg.EventNvidiaNVRMXid)
-				}
-			}
-
-			// nvidia_error_sxid cannot be used without dmesg
-			nvswitchSXidFilterFound := false
-			if _, ok := config.Components[nvidia_error_sxid.Name]; ok {
-				for _, f := range cfg.Log.SelectFilters {
-					if f.Name == dmesg.EventNvidiaNVSwitchSXid {
-						nvswitchSXidFilterFound = true
-						break
-					}
-				}
-				if !nvswitchSXidFilterFound {
-					return nil, fmt.Errorf("%q enabled but dmesg config missing %q filter", nvidia_error_sxid.Name, dmesg.EventNvidiaNVSwitchSXid)
-				}
-			}
-
-			c, err := dmesg.New(ctx, cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case fd.Name:
-			cfg := fd.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := fd.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, fd.New(ctx, cfg))
-
-		case info.Name:
-			allComponents = append(allComponents, info.New(config.Annotations))
-
-		case memory.Name:
-			memory_metrics.InitAveragers(db, components_metrics_state.DefaultTableName)
-
-			cfg := memory.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := memory.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, memory.New(ctx, cfg))
-
-		case os.Name:
-			cfg := os.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := os.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, os.New(ctx, cfg))
-
-		case power_supply.Name:
-			cfg := power_supply.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := power_supply.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			allComponents = append(allComponents, power_supply.New(ctx, cfg))
-
-		case component_systemd.Name:
-			cfg := component_systemd.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := component_systemd.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			c, err := component_systemd.New(ctx, cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case tailscale.Name:
-			cfg := tailscale.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := tailscale.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, tailscale.New(ctx, cfg))
-
-		case nvidia_info.Name:
-			cfg := nvidia_info.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := nvidia_info.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, nvidia_info.New(ctx, cfg))
-
-		case nvidia_error.Name:
-			cfg := nvidia_error.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := nvidia_error.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, nvidia_error.New(ctx, cfg))
-
-		case nvidia_error_xid.Name:
-			cfg := nvidia_error_xid.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := nvidia_error_xid.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, nvidia_error_xid.New(ctx, cfg))
-
-		case nvidia_error_sxid.Name:
-			allComponents = append(allComponents, nvidia_error_sxid.New())
-
-		case nvidia_clock.Name:
-			cfg := nvidia_clock.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := nvidia_clock.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, nvidia_clock.New(ctx, cfg))
-
-		case nvidia_clockspeed.Name:
-			cfg := nvidia_clockspeed.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := nvidia_clockspeed.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, nvidia_clockspeed.New(ctx, cfg))
-
-		case nvidia_ecc.Name:
-			cfg := nvidia_ecc.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := nvidia_ecc.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, nvidia_ecc.New(ctx, cfg))
-
-		case nvidia_memory.Name:
-			cfg := nvidia_memory.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := nvidia_memory.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, nvidia_memory.New(ctx, cfg))
-
-		case nvidia_nvlink.Name:
-			cfg := nvidia_nvlink.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := nvidia_nvlink.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, nvidia_nvlink.New(ctx, cfg))
-
-		case nvidia_power.Name:
-			cfg := nvidia_power.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := nvidia_power.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, nvidia_power.New(ctx, cfg))
-
-		case nvidia_temperature.Name:
-			cfg := nvidia_temperature.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := nvidia_temperature.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, nvidia_temperature.New(ctx, cfg))
-
-		case nvidia_utilization.Name:
-			cfg := nvidia_utilization.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := nvidia_utilization.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, nvidia_utilization.New(ctx, cfg))
-
-		case nvidia_processes.Name:
-			cfg := nvidia_processes.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := nvidia_processes.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, nvidia_processes.New(ctx, cfg))
-
-		case nvidia_fabric_manager.Name:
-			cfg := nvidia_fabric_manager.Config{Query: defaultQueryCfg, Log: nvidia_fabric_manager.DefaultLogConfig()}
-			if configValue != nil {
-				parsed, err := nvidia_fabric_manager.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			fabricManagerLogComponent, err := nvidia_fabric_manager.New(ctx, cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, fabricManagerLogComponent)
-
-		case nvidia_infiniband.Name:
-			cfg := nvidia_infiniband.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := nvidia_infiniband.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, nvidia_infiniband.New(ctx, cfg))
-
-		case nvidia_peermem.Name:
-			cfg := nvidia_peermem.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := nvidia_peermem.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, nvidia_peermem.New(ctx, cfg))
-
-		case containerd_pod.Name:
-			cfg := containerd_pod.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := containerd_pod.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, containerd_pod.New(ctx, cfg))
-
-		case docker_container.Name:
-			cfg := docker_container.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := docker_container.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, docker_container.New(ctx, cfg))
-
-		case k8s_pod.Name:
-			cfg := k8s_pod.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := k8s_pod.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, k8s_pod.New(ctx, cfg))
-
-		case network_latency.Name:
-			cfg := network_latency.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := network_latency.ParseConfig(configValue, db)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, network_latency.New(ctx, cfg))
-
-		default:
-			return nil, fmt.Errorf("unknown component %s", k)
+		c, err := s.newComponent(ctx, config, k, configValue)
+		if err != nil {
+			return nil, err
 		}
+		allComponents = append(allComponents, c)
 	}
 
+	certs, err := newCertManager(config.TLS, stateFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tls cert: %w", err)
+	}
+	go certs.watch(ctx)
+	allComponents = append(allComponents, newTLSCertComponent(certs, config.TLS))
+
 	promReg := prometheus.NewRegistry()
+	s.promReg = promReg
 
 	if err := metrics.Register(promReg); err != nil {
 		return nil, fmt.Errorf("failed to register metrics: %w", err)
@@ -629,6 +194,9 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string) (_ *Ser
 	if err := state.Register(promReg); err != nil {
 		return nil, fmt.Errorf("failed to register state metrics: %w", err)
 	}
+	if err := session.Register(promReg); err != nil {
+		return nil, fmt.Errorf("failed to register session metrics: %w", err)
+	}
 	go func() {
 		ticker := time.NewTicker(time.Minute) // only first run is 1-minute wait
 		defer ticker.Stop()
@@ -762,26 +330,96 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string) (_ *Ser
 		return nil, fmt.Errorf("failed to update components: %w", err)
 	}
 
-	// TODO: implement configuration file refresh + apply
+	// the notifier also pushes the notifications over the session, even without the webhooks
+	notifierCfg := lepconfig.Notifier{}
+	if config.Notifier != nil {
+		notifierCfg = *config.Notifier
+	}
+	notif, err := notifier.New(notifierCfg, db, uid, config.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create notifier: %w", err)
+	}
+	if err := notif.Start(ctx); err != nil {
+		return nil, fmt.Errorf("failed to start notifier: %w", err)
+	}
 
-	router := gin.Default()
-	router.SetHTMLTemplate(rootTmpl)
+	if config.OTLP != nil {
+		e, err := otlp.New(*config.OTLP, db, promReg, uid, config.Annotations)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+		}
+		if err := e.Start(ctx); err != nil {
+			return nil, fmt.Errorf("failed to start otlp exporter: %w", err)
+		}
+	}
 
-	cert, err := s.generateSelfSignedCert()
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate tls cert: %w", err)
+	if config.RemoteWrite != nil {
+		rwCfg := *config.RemoteWrite
+		if rwCfg.QueueDir == "" && stateFile != ":memory:" {
+			rwCfg.QueueDir = filepath.Join(filepath.Dir(stateFile), "remote-write")
+		}
+		w, err := remotewrite.New(rwCfg, promReg, uid, config.Annotations)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create remote-write: %w", err)
+		}
+		w.Start(ctx)
+	}
+
+	if config.K8sNode != nil {
+		client, err := k8snode.NewClientset(config.K8sNode.Kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+		}
+		u, err := k8snode.New(*config.K8sNode, db, client)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create kubernetes node updater: %w", err)
+		}
+		if err := u.Start(ctx); err != nil {
+			return nil, fmt.Errorf("failed to start kubernetes node updater: %w", err)
+		}
 	}
 
+	go s.watchConfig(ctx)
+	go s.syncEvents(ctx, config.RetentionPeriod.Duration)
+	go s.recordStates(ctx)
+
+	router := gin.Default()
+	router.SetHTMLTemplate(rootTmpl)
+
 	installRootGinMiddlewares(router)
 	installCommonGinMiddlewares(router, log.Logger.Desugar())
 
+	tlsConfig := &tls.Config{
+		GetCertificate: certs.GetCertificate,
+	}
+	var unixListener net.Listener
+	if config.Auth != nil {
+		config.Auth.SetDefaultsIfNotSet()
+
+		auth, err := newAuthenticator(*config.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create authenticator: %w", err)
+		}
+		router.Use(auth.middleware())
+
+		if err := configureClientAuth(tlsConfig, *config.Auth); err != nil {
+			return nil, fmt.Errorf("failed to configure client auth: %w", err)
+		}
+		if config.Auth.UnixSocket != "" {
+			unixListener, err = listenUnixSocket(*config.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("failed to listen unix socket: %w", err)
+			}
+		}
+	}
+
 	v1 := router.Group("/v1")
 
 	// if the request header is set "Accept-Encoding: gzip",
 	// the middleware automatically gzip-compresses the response with the response header "Content-Encoding: gzip"
-	v1.Use(gzip.Gzip(gzip.DefaultCompression, gzip.WithExcludedPaths([]string{"/update/"})))
+	v1.Use(newGzipMiddleware())
 
-	registeredPaths := newGlobalHandler(config, components.GetAllComponents()).registerComponentRoutes(v1)
+	registeredPaths := newGlobalHandler(config, db).registerComponentRoutes(v1)
 	for i := range registeredPaths {
 		registeredPaths[i].Path = path.Join(v1.BasePath(), registeredPaths[i].Path)
 	}
@@ -795,6 +433,17 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string) (_ *Ser
 		promHandler.ServeHTTP(ctx.Writer, ctx.Request)
 	})
 
+	v1.GET(URLPathHealth, createHealthHandler(s.configSnapshot))
+	registeredPaths = append(registeredPaths, componentHandlerDescription{
+		Path: path.Join(v1.BasePath(), URLPathHealth),
+		Desc: URLPathHealthDesc,
+	})
+	v1.GET(URLPathReady, createReadyHandler(s.configSnapshot))
+	registeredPaths = append(registeredPaths, componentHandlerDescription{
+		Path: path.Join(v1.BasePath(), URLPathReady),
+		Desc: URLPathReadyDesc,
+	})
+
 	router.GET(URLPathSwagger, ginSwagger.WrapHandler(swaggerFiles.Handler))
 	router.GET(URLPathHealthz, createHealthzHandler())
 	registeredPaths = append(registeredPaths, componentHandlerDescription{
@@ -803,12 +452,7 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string) (_ *Ser
 	})
 
 	admin := router.Group("/admin")
-
-	admin.GET(URLPathConfig, createConfigHandler(config))
-	registeredPaths = append(registeredPaths, componentHandlerDescription{
-		Path: path.Join("/admin", URLPathConfig),
-		Desc: URLPathConfigDesc,
-	})
+	registeredPaths = append(registeredPaths, s.registerAdminRoutes(admin, config.Auth)...)
 
 	if config.Pprof {
 		log.Logger.Debugw("registering pprof handlers")
@@ -832,15 +476,28 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string) (_ *Ser
 		}
 	}
 
-	go s.updateToken(ctx, db, uid, endpoint)
+	sessionOpts := []session.OpOption{
+		session.WithDB(db),
+		session.WithConfig(s.configSnapshot, s.updateConfigFromSession),
+		session.WithNotifier(notif),
+	}
+	if stateFile != ":memory:" {
+		// hold the responses and notifications across the restarts
+		// while the control plane is unreachable
+		sessionOpts = append(sessionOpts, session.WithOutboxDir(filepath.Join(filepath.Dir(stateFile), "session-outbox")))
+	}
+	cp := controlplane.New(endpoint)
+	go s.updateToken(ctx, db, uid, cp, sessionOpts...)
+
+	if unixListener != nil {
+		go serveUnixSocket(unixListener, router, config.Auth.UnixSocketRole)
+	}
 
 	go func() {
 		srv := &http.Server{
-			Addr:    config.Address,
-			Handler: router,
-			TLSConfig: &tls.Config{
-				Certificates: []tls.Certificate{cert},
-			},
+			Addr:      config.Address,
+			Handler:   router,
+			TLSConfig: tlsConfig,
 		}
 		log.Logger.Infof("serving %s", config.Address)
 		// Start HTTPS server
@@ -851,7 +508,7 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string) (_ *Ser
 		}
 	}()
 
-	if err = login.Gossip(endpoint, uid, config.Address); err != nil {
+	if err = login.Gossip(ctx, cp, uid, config.Address); err != nil {
 		log.Logger.Debugf("failed to gossip: %v", err)
 	}
 	return s, nil
@@ -859,6 +516,36 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string) (_ *Ser
 
 const checkMark = "\033[32m✔\033[0m"
 
+// newComponent creates the component of the given name from its raw config value
+// using the registered component factory.
+// A nil config value creates the component with its default configuration.
+func (s *Server) newComponent(ctx context.Context, config *lepconfig.Config, name string, configValue any) (components.Component, error) {
+	f, err := components.GetFactory(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown component %s: %w", name, err)
+	}
+
+	cfg, err := f.ParseConfig(configValue, s.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse component %s config: %w", name, err)
+	}
+	if v, ok := cfg.(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return nil, fmt.Errorf("failed to validate component %s config: %w", name, err)
+		}
+	}
+
+	c, err := f.New(ctx, cfg, components.FactoryOptions{
+		DB:          s.db,
+		Annotations: config.Annotations,
+		Components:  config.Components,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create component %s: %w", name, err)
+	}
+	return c, nil
+}
+
 func (s *Server) Stop() {
 	if s.session != nil {
 		s.session.Stop()
@@ -892,56 +579,36 @@ func (s *Server) Stop() {
 	}
 }
 
-func (s *Server) generateSelfSignedCert() (tls.Certificate, error) {
-	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-	if err != nil {
-		return tls.Certificate{}, err
-	}
-
-	// Create a certificate template
-	template := x509.Certificate{
-		SerialNumber: big.NewInt(1),
-		Subject: pkix.Name{
-			Organization: []string{"Lepton AI"},
-		},
-		NotBefore:             time.Now(),
-		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
-		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
-		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
-		BasicConstraintsValid: true,
-	}
-
-	// Create the certificate
-	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+// updateConfigFromSession applies the configuration update requested by the control plane.
+func (s *Server) updateConfigFromSession(ctx context.Context, update func(*lepconfig.Config) error) (any, error) {
+	result, err := s.UpdateConfig(ctx, ConfigReloadTriggerSession, update)
 	if err != nil {
-		return tls.Certificate{}, err
+		return nil, err
 	}
-
-	// Encode the certificate and private key to PEM format
-	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
-	privDER, err := x509.MarshalECPrivateKey(priv)
-	if err != nil {
-		return tls.Certificate{}, err
-	}
-	privPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: privDER})
-
-	// Load the certificate
-	cert, err := tls.X509KeyPair(certPEM, privPEM)
-	if err != nil {
-		return tls.Certificate{}, err
+	if result.Error != "" {
+		return result, errors.New(result.Error)
 	}
+	return result, nil
+}
 
-	return cert, nil
+// newGzipMiddleware compresses the responses except the server-sent event streams,
+// which would be held in the gzip buffer rather than flushed to the client.
+func newGzipMiddleware() gin.HandlerFunc {
+	return gzip.Gzip(gzip.DefaultCompression, gzip.WithExcludedPaths([]string{
+		"/update/",
+		"/v1" + URLPathEventsStream,
+		"/v1" + URLPathStatesStream,
+	}))
 }
 
-func (s *Server) updateToken(ctx context.Context, db *sql.DB, uid string, endpoint string) {
+func (s *Server) updateToken(ctx context.Context, db *sql.DB, uid string, cp controlplane.ControlPlane, sessionOpts ...session.OpOption) {
 	var userToken string
 	pipePath := s.fifoPath
 	if dbToken, err := state.GetLoginInfo(ctx, db, uid); err == nil {
 		userToken = dbToken
 	}
 	if userToken != "" {
-		s.session = session.NewSession(ctx, fmt.Sprintf("https://%s/api/v1/session", endpoint), uid, 3*time.Second)
+		s.session = session.NewSession(ctx, cp.SessionURL(), uid, 3*time.Second, sessionOpts...)
 	}
 	if _, err := goOS.Stat(pipePath); err == nil {
 		if err = goOS.Remove(pipePath); err != nil {
@@ -975,7 +642,7 @@ func (s *Server) updateToken(ctx context.Context, db *sql.DB, uid string, endpoi
 			if s.session != nil {
 				s.session.Stop()
 			}
-			s.session = session.NewSession(ctx, fmt.Sprintf("https://%s/api/v1/session", endpoint), uid, 3*time.Second)
+			s.session = session.NewSession(ctx, cp.SessionURL(), uid, 3*time.Second, sessionOpts...)
 		}
 		time.Sleep(1 * time.Second)
 	}
@@ -1011,3 +678,41 @@ func WriteToken(token string, fifoFile string) error {
 	}
 	return nil
 }
+
+// registerAdminRoutes registers the admin routes, and returns the registered paths.
+// The mutations are only allowed from the loopback address
+// if the authentication is not configured.
+func (s *Server) registerAdminRoutes(admin *gin.RouterGroup, auth *lepconfig.Auth) []componentHandlerDescription {
+	var paths []componentHandlerDescription
+	mutate := admin.Group("", requireAuthOrLoopback(auth))
+
+	admin.GET(URLPathConfig, createConfigHandler(s.configSnapshot))
+	paths = append(paths, componentHandlerDescription{
+		Path: path.Join("/admin", URLPathConfig),
+		Desc: URLPathConfigDesc,
+	})
+	admin.GET(URLPathConfigReload, s.getConfigReload)
+	mutate.POST(URLPathConfigReload, s.reloadConfigHandler)
+	paths = append(paths, componentHandlerDescription{
+		Path: path.Join("/admin", URLPathConfigReload),
+		Desc: URLPathConfigReloadDesc,
+	})
+
+	mutate.POST(URLPathAdminComponentEnable, s.enableComponent)
+	paths = append(paths, componentHandlerDescription{
+		Path: path.Join("/admin", URLPathAdminComponentEnable),
+		Desc: URLPathAdminComponentEnableDesc,
+	})
+	mutate.POST(URLPathAdminComponentDisable, s.disableComponent)
+	paths = append(paths, componentHandlerDescription{
+		Path: path.Join("/admin", URLPathAdminComponentDisable),
+		Desc: URLPathAdminComponentDisableDesc,
+	})
+	mutate.PUT(URLPathAdminComponentQuery, s.updateComponentQuery)
+	paths = append(paths, componentHandlerDescription{
+		Path: path.Join("/admin", URLPathAdminComponentQuery),
+		Desc: URLPathAdminComponentQueryDesc,
+	})
+
+	return paths
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	components_states_state "github.com/leptonai/gpud/components/states/state"
+	"github.com/leptonai/gpud/log"
+)
+
+// statesRecordInterval is the interval to evaluate and persist the component state changes.
+const statesRecordInterval = 5 * time.Second
+
+// recordStates periodically evaluates the states of all the registered components,
+// and persists the states whenever the component health changes.
+func (s *Server) recordStates(ctx context.Context) {
+	ticker := time.NewTicker(statesRecordInterval)
+	defer ticker.Stop()
+
+	last := make(map[string]components.HealthStatus)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for name, c := range components.GetAllComponents() {
+			prev, ok := last[name]
+			if !ok {
+				// resume from the last persisted health to not record the same state on restarts
+				r, err := components_states_state.ReadLast(ctx, s.db, components_states_state.DefaultTableName, name)
+				if err != nil {
+					log.Logger.Warnw("failed to read last state", "component", name, "error", err)
+					continue
+				}
+				if r != nil {
+					prev, ok = r.Health, true
+				}
+			}
+
+			cctx, cancel := context.WithTimeout(ctx, statesRecordInterval)
+			states, cur := componentHealth(cctx, c)
+			cancel()
+
+			if ok && !healthChanged(prev, cur) {
+				last[name] = cur
+				continue
+			}
+			if err := components_states_state.Insert(ctx, s.db, components_states_state.DefaultTableName, name, cur, states); err != nil {
+				log.Logger.Warnw("failed to record state", "component", name, "error", err)
+				continue
+			}
+			last[name] = cur
+		}
+	}
+}
+
+// healthChanged returns true if the health has changed, ignoring the evaluated time.
+func healthChanged(prev, cur components.HealthStatus) bool {
+	return prev.Healthy != cur.Healthy ||
+		prev.Severity != cur.Severity ||
+		prev.Reason != cur.Reason ||
+		prev.Error != cur.Error ||
+		!reflect.DeepEqual(prev.SuggestedActions, cur.SuggestedActions)
+}
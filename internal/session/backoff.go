@@ -0,0 +1,48 @@
+package session
+
+import (
+	"math/rand"
+	"time"
+)
+
+// DefaultMaxBackoff is the maximum interval to reconnect the session.
+const DefaultMaxBackoff = 2 * time.Minute
+
+// backoff computes the exponential reconnect intervals with jitter,
+// so that the agents do not reconnect all at once after the control plane outage.
+type backoff struct {
+	initial time.Duration
+	max     time.Duration
+	attempt int
+}
+
+func newBackoff(initial time.Duration, max time.Duration) *backoff {
+	if initial <= 0 {
+		initial = time.Second
+	}
+	if max < initial {
+		max = initial
+	}
+	return &backoff{initial: initial, max: max}
+}
+
+// next returns the interval to wait before the next attempt,
+// between the half and the full of the exponential interval.
+func (b *backoff) next() time.Duration {
+	d := b.initial
+	for i := 0; i < b.attempt && d < b.max; i++ {
+		d *= 2
+	}
+	if d > b.max {
+		d = b.max
+	}
+	b.attempt++
+
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// reset resets the interval to the initial, once connected.
+func (b *backoff) reset() {
+	b.attempt = 0
+}
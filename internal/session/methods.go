@@ -0,0 +1,382 @@
+package session
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/diagnose"
+	query_log "github.com/leptonai/gpud/components/query/log"
+	query_log_tail "github.com/leptonai/gpud/components/query/log/tail"
+	"github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/log"
+)
+
+const (
+	kib = 1024
+	mib = 1024 * kib
+
+	defaultMaxResponseBytes = 64 * kib
+
+	// DiagnoseChunkSize is the size of each diagnose tarball chunk.
+	DiagnoseChunkSize = 1 * mib
+
+	// DefaultLogLines is the number of the lines to tail if not specified.
+	DefaultLogLines = 100
+	// MaxLogLines is the maximum number of the lines to tail.
+	MaxLogLines = 5000
+)
+
+// methodSpec declares the limits of a session method.
+type methodSpec struct {
+	// timeout is the maximum duration to handle the request.
+	timeout time.Duration
+	// maxRequestBytes is the maximum size of the request data.
+	maxRequestBytes int
+	// maxResponseBytes is the maximum size of the response data,
+	// or the total size of the chunks if streamed.
+	maxResponseBytes int
+	// async handles the request in the background
+	// not to block the other requests.
+	async bool
+}
+
+var methods = map[string]methodSpec{
+	"metrics":  {timeout: time.Minute, maxRequestBytes: 64 * kib, maxResponseBytes: 32 * mib},
+	"states":   {timeout: time.Minute, maxRequestBytes: 64 * kib, maxResponseBytes: 8 * mib},
+	"events":   {timeout: time.Minute, maxRequestBytes: 64 * kib, maxResponseBytes: 32 * mib},
+	"refresh":  {timeout: time.Minute, maxRequestBytes: 64 * kib, maxResponseBytes: 8 * mib},
+	"update":   {timeout: 5 * time.Minute, maxRequestBytes: 4 * kib, maxResponseBytes: defaultMaxResponseBytes},
+	"diagnose": {timeout: 10 * time.Minute, maxRequestBytes: 4 * kib, maxResponseBytes: 256 * mib, async: true},
+	"logs":     {timeout: time.Minute, maxRequestBytes: 4 * kib, maxResponseBytes: 8 * mib},
+	"config":   {timeout: time.Minute, maxRequestBytes: 1 * mib, maxResponseBytes: 4 * mib},
+	"info":     {timeout: time.Minute, maxRequestBytes: 64 * kib, maxResponseBytes: 64 * mib},
+}
+
+// diagnose runs the diagnosis and streams the gzipped tarball in chunks,
+// one diagnosis at a time.
+func (s *Session) diagnose(ctx context.Context, reqId string, spec methodSpec) error {
+	if !s.diagnosing.CompareAndSwap(false, true) {
+		return errors.New("diagnose is already running")
+	}
+	defer s.diagnosing.Store(false)
+
+	dir, err := os.MkdirTemp("", "gpud-session-diagnose")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := diagnose.Run(ctx, diagnose.WithOutputDir(dir)); err != nil {
+		return fmt.Errorf("failed to diagnose: %w", err)
+	}
+	tars, err := filepath.Glob(filepath.Join(dir, "*.tar"))
+	if err != nil {
+		return err
+	}
+	if len(tars) != 1 {
+		return fmt.Errorf("expected one diagnose tarball, found %d", len(tars))
+	}
+
+	gz := tars[0] + ".gz"
+	if err := gzipFile(tars[0], gz); err != nil {
+		return fmt.Errorf("failed to compress diagnose tarball: %w", err)
+	}
+	return s.sendChunks(ctx, reqId, gz, DiagnoseChunkSize, spec.maxResponseBytes)
+}
+
+// sendChunks sends the file in chunks as the responses to the request,
+// setting "chunk_last" in the last one.
+func (s *Session) sendChunks(ctx context.Context, reqId string, file string, chunkSize int, maxBytes int) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	if info.Size() > int64(maxBytes) {
+		return fmt.Errorf("file size %d exceeds the limit %d", info.Size(), maxBytes)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	buf := make([]byte, chunkSize)
+	var sent int64
+	for idx := 0; ; idx++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, err := io.ReadFull(f, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+		sent += int64(n)
+		last := sent >= info.Size() || n < chunkSize
+
+		s.respond(reqId, &Response{
+			Chunk:      append([]byte(nil), buf[:n]...),
+			ChunkIndex: idx,
+			ChunkLast:  last,
+		}, 2*chunkSize+kib)
+		if last {
+			return nil
+		}
+	}
+}
+
+func gzipFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// getLogs tails the gpud log file or the selected log poller,
+// and returns the lines from the oldest.
+func (s *Session) getLogs(ctx context.Context, payload Request) ([]string, error) {
+	n := payload.LogLines
+	if n <= 0 {
+		n = DefaultLogLines
+	}
+	if n > MaxLogLines {
+		return nil, fmt.Errorf("log_lines %d exceeds the limit %d", n, MaxLogLines)
+	}
+
+	// lines are scanned from the latest
+	lines := make([]string, 0, n)
+	if payload.LogFile == "" {
+		if _, err := os.Stat(s.logFile); err != nil {
+			return nil, fmt.Errorf("log file %s does not exist", s.logFile)
+		}
+		if _, err := query_log_tail.Scan(
+			ctx,
+			query_log_tail.WithFile(s.logFile),
+			query_log_tail.WithLinesToTail(n),
+			query_log_tail.WithPerLineFunc(func(line []byte) {
+				lines = append(lines, string(line))
+			}),
+		); err != nil {
+			return nil, fmt.Errorf("failed to tail log file: %w", err)
+		}
+	} else {
+		// only the registered pollers, not to read arbitrary files
+		poller := query_log.GetPoller(payload.LogFile)
+		if poller == nil {
+			return nil, fmt.Errorf("log poller %s not found", payload.LogFile)
+		}
+		items, err := poller.TailScan(ctx, query_log_tail.WithLinesToTail(n))
+		if err != nil {
+			return nil, fmt.Errorf("failed to tail log poller: %w", err)
+		}
+		for _, item := range items {
+			lines = append(lines, item.Line)
+		}
+	}
+
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines, nil
+}
+
+// applyConfig returns the current configuration with the secrets redacted,
+// after applying the patch if any.
+func (s *Session) applyConfig(ctx context.Context, payload Request) (*config.Config, any, error) {
+	if s.getConfig == nil {
+		return nil, nil, errors.New("config method not supported")
+	}
+	if len(payload.ConfigPatch) == 0 {
+		cfg := s.getConfig().Redacted()
+		return &cfg, nil, nil
+	}
+
+	if s.updateConfig == nil {
+		return nil, nil, errors.New("config update not supported")
+	}
+	var patch any
+	if err := json.Unmarshal(payload.ConfigPatch, &patch); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config patch: %w", err)
+	}
+	if err := validateConfigPatch(patch); err != nil {
+		return nil, nil, err
+	}
+	log.Logger.Infow("applying config patch from the session", "patch", string(payload.ConfigPatch))
+
+	result, err := s.updateConfig(ctx, func(cfg *config.Config) error {
+		return mergeConfigPatch(cfg, patch)
+	})
+	cfg := s.getConfig().Redacted()
+	return &cfg, result, err
+}
+
+// reloadableConfigKeys are the top-level config keys applied without the restart.
+// The other keys would be persisted but silently ignored until the restart,
+// thus rejected.
+var reloadableConfigKeys = map[string]bool{
+	"components":          true,
+	"critical_components": true,
+}
+
+// validateConfigPatch returns an error if the patch is not an object,
+// or updates the config keys that cannot be reloaded.
+func validateConfigPatch(patch any) error {
+	pm, ok := patch.(map[string]any)
+	if !ok {
+		return errors.New("config patch must be a JSON object")
+	}
+	var rejected []string
+	for k := range pm {
+		if !reloadableConfigKeys[k] {
+			rejected = append(rejected, k)
+		}
+	}
+	if len(rejected) > 0 {
+		sort.Strings(rejected)
+		return fmt.Errorf("config patch keys %v require the restart, only components and critical_components can be updated", rejected)
+	}
+	return nil
+}
+
+// mergeConfigPatch applies the JSON merge patch to the configuration.
+// ref. https://datatracker.ietf.org/doc/html/rfc7386
+func mergeConfigPatch(cfg *config.Config, patch any) error {
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	var doc any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return err
+	}
+
+	b, err = json.Marshal(mergePatch(doc, patch))
+	if err != nil {
+		return err
+	}
+	patched := new(config.Config)
+	if err := json.Unmarshal(b, patched); err != nil {
+		return fmt.Errorf("failed to decode patched config: %w", err)
+	}
+	*cfg = *patched
+	return nil
+}
+
+func mergePatch(target any, patch any) any {
+	pm, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	tm, ok := target.(map[string]any)
+	if !ok {
+		tm = make(map[string]any)
+	}
+	for k, v := range pm {
+		if v == nil {
+			delete(tm, k)
+			continue
+		}
+		tm[k] = mergePatch(tm[k], v)
+	}
+	return tm
+}
+
+// getInfo returns the states, events, and metrics of the components.
+func (s *Session) getInfo(ctx context.Context, payload Request) (v1.LeptonInfo, error) {
+	allComponents := componentNames()
+	if len(payload.Components) > 0 {
+		allComponents = payload.Components
+	}
+
+	now := time.Now().UTC()
+	startTime := now.Add(-DefaultQuerySince)
+	if payload.Since > 0 {
+		startTime = now.Add(-payload.Since)
+	}
+	if !payload.StartTime.IsZero() {
+		startTime = payload.StartTime
+	}
+	endTime := now
+	if !payload.EndTime.IsZero() {
+		endTime = payload.EndTime
+	}
+
+	var infos v1.LeptonInfo
+	for _, componentName := range allComponents {
+		currInfo := v1.LeptonComponentInfo{
+			Component: componentName,
+			StartTime: startTime,
+			EndTime:   endTime,
+			Info:      components.Info{},
+		}
+		component, err := components.GetComponent(componentName)
+		if err != nil {
+			log.Logger.Errorw("failed to get component",
+				"operation", "GetInfo",
+				"component", componentName,
+				"error", err,
+			)
+			infos = append(infos, currInfo)
+			continue
+		}
+
+		events, err := s.readEvents(ctx, component, startTime, endTime, payload)
+		if err != nil {
+			log.Logger.Errorw("failed to invoke component events",
+				"operation", "GetInfo",
+				"component", componentName,
+				"error", err,
+			)
+		} else {
+			currInfo.Info.Events = events
+		}
+		states, err := component.States(ctx)
+		if err != nil {
+			log.Logger.Errorw("failed to invoke component states",
+				"operation", "GetInfo",
+				"component", componentName,
+				"error", err,
+			)
+		} else {
+			currInfo.Info.States = states
+		}
+		metrics, err := component.Metrics(ctx, startTime)
+		if err != nil {
+			log.Logger.Errorw("failed to invoke component metrics",
+				"operation", "GetInfo",
+				"component", componentName,
+				"error", err,
+			)
+		} else {
+			currInfo.Info.Metrics = metrics
+		}
+		infos = append(infos, currInfo)
+	}
+	return infos, nil
+}
@@ -0,0 +1,224 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/config"
+)
+
+// recvResponses reads the responses sent to the outbox.
+func recvResponses(t *testing.T, s *Session) []Body {
+	var bodies []Body
+	for {
+		e, ok, err := s.outbox.peek()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			return bodies
+		}
+		var b Body
+		if err := json.Unmarshal(e.Data, &b); err != nil {
+			t.Fatal(err)
+		}
+		bodies = append(bodies, b)
+		if err := s.outbox.remove(e.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func newTestSession(t *testing.T, opts ...OpOption) *Session {
+	op := &Op{}
+	op.applyOpts(opts)
+	ob, err := newOutbox("", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Session{
+		logFile:      op.logFile,
+		getConfig:    op.getConfig,
+		updateConfig: op.updateConfig,
+		outbox:       ob,
+	}
+}
+
+func TestServeMethodLimits(t *testing.T) {
+	s := newTestSession(t)
+	s.reader = make(chan Body, 2)
+	s.reader <- Body{ReqID: "1", Data: []byte(`{"method":"unknown"}`)}
+	s.reader <- Body{ReqID: "2", Data: []byte(`{"method":"logs","log_file":"` + strings.Repeat("a", 5*kib) + `"}`)}
+	close(s.reader)
+	s.serve()
+
+	bodies := recvResponses(t, s)
+	if len(bodies) != 2 {
+		t.Fatalf("expected 2 responses, got %d", len(bodies))
+	}
+	for i, want := range []string{"unsupported method", "exceeds the limit"} {
+		var resp Response
+		if err := json.Unmarshal(bodies[i].Data, &resp); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(resp.ErrorMessage, want) {
+			t.Fatalf("expected error %q, got %q", want, resp.ErrorMessage)
+		}
+	}
+}
+
+func TestSendChunks(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 25)
+	file := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, chunkSize := range []int{10, 64, 250, 1000} {
+		s := newTestSession(t)
+		if err := s.sendChunks(context.Background(), "req", file, chunkSize, len(data)); err != nil {
+			t.Fatal(err)
+		}
+
+		var got []byte
+		bodies := recvResponses(t, s)
+		for i, b := range bodies {
+			if b.ReqID != "req" {
+				t.Fatalf("expected req id %q, got %q", "req", b.ReqID)
+			}
+			var resp Response
+			if err := json.Unmarshal(b.Data, &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.ChunkIndex != i {
+				t.Fatalf("expected chunk index %d, got %d", i, resp.ChunkIndex)
+			}
+			if resp.ChunkLast != (i == len(bodies)-1) {
+				t.Fatalf("chunk size %d: unexpected last chunk %d of %d", chunkSize, i, len(bodies))
+			}
+			got = append(got, resp.Chunk...)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("chunk size %d: expected the original data, got %d bytes", chunkSize, len(got))
+		}
+	}
+
+	s := newTestSession(t)
+	if err := s.sendChunks(context.Background(), "req", file, 10, len(data)-1); err == nil {
+		t.Fatal("expected size limit error, got nil")
+	}
+}
+
+func TestGetLogs(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "gpud.log")
+	if err := os.WriteFile(file, []byte("line1\nline2\nline3\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s := newTestSession(t, WithLogFile(file))
+
+	lines, err := s.getLogs(context.Background(), Request{Method: "logs", LogLines: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(lines, ",") != "line2,line3" {
+		t.Fatalf("expected the latest 2 lines from the oldest, got %v", lines)
+	}
+
+	if _, err := s.getLogs(context.Background(), Request{Method: "logs", LogLines: MaxLogLines + 1}); err == nil {
+		t.Fatal("expected lines limit error, got nil")
+	}
+	if _, err := s.getLogs(context.Background(), Request{Method: "logs", LogFile: "/etc/shadow"}); err == nil {
+		t.Fatal("expected unregistered poller error, got nil")
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	cur := config.Config{
+		Address:         "0.0.0.0:15132",
+		Components:      map[string]any{"cpu": nil, "disk": map[string]any{"query": map[string]any{"interval": "1m"}}},
+		RetentionPeriod: config.DefaultRetentionPeriod,
+		Notifier: &config.Notifier{Webhooks: []config.Webhook{
+			{URL: "https://hooks.example.com/x", Headers: map[string]string{"Authorization": "Bearer secret"}},
+		}},
+	}
+	s := newTestSession(t, WithConfig(
+		func() config.Config { return cur },
+		func(ctx context.Context, update func(*config.Config) error) (any, error) {
+			cfg := cur
+			if err := update(&cfg); err != nil {
+				return nil, err
+			}
+			cur = cfg
+			return "applied", nil
+		},
+	))
+
+	cfg, result, err := s.applyConfig(context.Background(), Request{Method: "config"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Address != cur.Address || result != nil {
+		t.Fatalf("unexpected config %+v, result %v", cfg, result)
+	}
+	if h := cfg.Notifier.Webhooks[0].Headers["Authorization"]; h != config.RedactedValue {
+		t.Fatalf("expected the webhook header redacted, got %q", h)
+	}
+
+	patch := json.RawMessage(`{"components":{"cpu":null,"memory":{},"disk":{"query":{"interval":"5m"}}}}`)
+	cfg, result, err = s.applyConfig(context.Background(), Request{Method: "config", ConfigPatch: patch})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "applied" {
+		t.Fatalf("expected the update result, got %v", result)
+	}
+	if _, ok := cfg.Components["cpu"]; ok {
+		t.Fatal("expected cpu removed by the null patch")
+	}
+	if _, ok := cfg.Components["memory"]; !ok {
+		t.Fatal("expected memory added")
+	}
+	disk, _ := cfg.Components["disk"].(map[string]any)
+	if q, _ := disk["query"].(map[string]any); q["interval"] != "5m" {
+		t.Fatalf("expected disk interval patched, got %v", disk)
+	}
+	if cfg.Address != "0.0.0.0:15132" || cfg.RetentionPeriod.Duration != config.DefaultRetentionPeriod.Duration {
+		t.Fatalf("expected the other fields unchanged, got %+v", cfg)
+	}
+	if h := cfg.Notifier.Webhooks[0].Headers["Authorization"]; h != config.RedactedValue {
+		t.Fatalf("expected the webhook header redacted, got %q", h)
+	}
+	if h := cur.Notifier.Webhooks[0].Headers["Authorization"]; h != "Bearer secret" {
+		t.Fatalf("expected the current config not redacted, got %q", h)
+	}
+
+	for _, patch := range []string{`{"address":"0.0.0.0:1234"}`, `{"components":{},"retention_period":"1h"}`, `[]`} {
+		if _, _, err := s.applyConfig(context.Background(), Request{Method: "config", ConfigPatch: json.RawMessage(patch)}); err == nil {
+			t.Fatalf("expected the patch %s rejected, got nil", patch)
+		}
+	}
+	if cur.Address != "0.0.0.0:15132" || len(cur.Components) != 2 {
+		t.Fatalf("expected the config unchanged by the rejected patches, got %+v", cur)
+	}
+
+	if _, _, err := newTestSession(t).applyConfig(context.Background(), Request{Method: "config"}); err == nil {
+		t.Fatal("expected unsupported error, got nil")
+	}
+}
+
+func TestMethodSpecs(t *testing.T) {
+	for name, spec := range methods {
+		if spec.timeout <= 0 || spec.timeout > time.Hour {
+			t.Errorf("method %q: invalid timeout %v", name, spec.timeout)
+		}
+		if spec.maxRequestBytes <= 0 || spec.maxResponseBytes <= 0 {
+			t.Errorf("method %q: payload limits not declared", name)
+		}
+	}
+}
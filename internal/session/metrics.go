@@ -0,0 +1,73 @@
+package session
+
+import (
+	"github.com/leptonai/gpud/internal/controlplane"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	sessionTypeRead  = controlplane.SessionTypeRead
+	sessionTypeWrite = controlplane.SessionTypeWrite
+)
+
+var (
+	connected = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "gpud",
+			Subsystem: "session",
+			Name:      "connected",
+			Help:      "current session connection state (1 if connected to the control plane)",
+		},
+		[]string{"session_type"},
+	)
+	reconnects = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "gpud",
+			Subsystem: "session",
+			Name:      "reconnects_total",
+			Help:      "total number of the session reconnects",
+		},
+		[]string{"session_type"},
+	)
+	outboxPending = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "gpud",
+			Subsystem: "session",
+			Name:      "outbox_pending",
+			Help:      "current number of the messages waiting to be sent to the control plane",
+		},
+	)
+	outboxDropped = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "gpud",
+			Subsystem: "session",
+			Name:      "outbox_dropped_total",
+			Help:      "total number of the oldest messages dropped from the full outbox",
+		},
+	)
+)
+
+func Register(reg *prometheus.Registry) error {
+	if err := reg.Register(connected); err != nil {
+		return err
+	}
+	if err := reg.Register(reconnects); err != nil {
+		return err
+	}
+	if err := reg.Register(outboxPending); err != nil {
+		return err
+	}
+	if err := reg.Register(outboxDropped); err != nil {
+		return err
+	}
+	return nil
+}
+
+func setConnected(sessionType string, ok bool) {
+	v := 0.0
+	if ok {
+		v = 1.0
+	}
+	connected.WithLabelValues(sessionType).Set(v)
+}
@@ -0,0 +1,116 @@
+package session
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/internal/notifier"
+	"github.com/leptonai/gpud/log"
+)
+
+// DefaultNotifyInterval is the interval to push the coalesced notifications.
+// The first notification of the same component and event is pushed right away,
+// and only the repeated ones within the interval are coalesced.
+const DefaultNotifyInterval = 10 * time.Second
+
+// Notification is the message pushed to the control plane without the request ID,
+// on the component health transition or the error/warn event.
+type Notification struct {
+	notifier.Payload
+
+	// Count is the number of the notifications coalesced into this one within the interval
+	// (e.g., the same event matched repeatedly),
+	// with the latest health or event.
+	Count int `json:"count"`
+}
+
+// enqueueNotification pushes the notification right away if none of the same key
+// was pushed within the interval, otherwise coalesces it with the pending one
+// to push on the next flush.
+func (s *Session) enqueueNotification(p notifier.Payload) {
+	var key string
+	switch p.Kind {
+	case notifier.KindState:
+		key = p.Kind + "/" + p.Component
+	case notifier.KindEvent:
+		if p.Event == nil || (p.Event.Type != components.EventTypeError && p.Event.Type != components.EventTypeWarn) {
+			return
+		}
+		key = p.Kind + "/" + p.Component + "/" + p.Event.Name + "/" + p.Event.Type
+	default:
+		return
+	}
+
+	s.notifyMu.Lock()
+	n, ok := s.pendingNotifications[key]
+	if !ok {
+		// the health transition or the new event is not delayed,
+		// only the repeated ones are coalesced not to flood the control plane
+		if last, notified := s.notifiedAt[key]; !notified || time.Since(last) >= s.notifyInterval {
+			s.notifiedAt[key] = time.Now()
+			s.notifyMu.Unlock()
+			s.sendNotification(key, &Notification{Payload: p, Count: 1})
+			return
+		}
+		s.pendingNotifications[key] = &Notification{Payload: p, Count: 1}
+		s.pendingNotificationKeys = append(s.pendingNotificationKeys, key)
+		s.notifyMu.Unlock()
+		return
+	}
+	defer s.notifyMu.Unlock()
+
+	// keep the health before the first transition in the interval
+	prev := n.PreviousHealth
+	n.Payload = p
+	if prev != nil {
+		n.PreviousHealth = prev
+	}
+	n.Count++
+}
+
+// flushNotifications pushes the pending notifications in the order of the first occurrence.
+func (s *Session) flushNotifications() {
+	now := time.Now()
+
+	s.notifyMu.Lock()
+	keys := s.pendingNotificationKeys
+	pending := s.pendingNotifications
+	s.pendingNotificationKeys = nil
+	s.pendingNotifications = make(map[string]*Notification)
+	for key, last := range s.notifiedAt {
+		if now.Sub(last) >= s.notifyInterval {
+			delete(s.notifiedAt, key)
+		}
+	}
+	for _, key := range keys {
+		s.notifiedAt[key] = now
+	}
+	s.notifyMu.Unlock()
+
+	for _, key := range keys {
+		s.sendNotification(key, pending[key])
+	}
+}
+
+func (s *Session) sendNotification(key string, n *Notification) {
+	data, err := json.Marshal(n)
+	if err != nil {
+		log.Logger.Errorw("failed to marshal notification", "key", key, "error", err)
+		return
+	}
+	s.Send(Body{Data: data})
+}
+
+func (s *Session) startNotifier() {
+	ticker := time.NewTicker(s.notifyInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.flushNotifications()
+		}
+	}
+}
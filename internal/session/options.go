@@ -0,0 +1,114 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/internal/notifier"
+	"github.com/leptonai/gpud/log"
+)
+
+type Op struct {
+	db         *sql.DB
+	maxBackoff time.Duration
+	outboxDir  string
+	outboxSize int
+
+	logFile      string
+	getConfig    func() config.Config
+	updateConfig ConfigUpdateFunc
+
+	notifier       *notifier.Notifier
+	notifyInterval time.Duration
+}
+
+// ConfigUpdateFunc modifies the configuration with "update" and applies the changes,
+// returning the result of the applied changes.
+type ConfigUpdateFunc func(ctx context.Context, update func(*config.Config) error) (any, error)
+
+type OpOption func(*Op)
+
+func (op *Op) applyOpts(opts []OpOption) {
+	for _, opt := range opts {
+		opt(op)
+	}
+
+	if op.maxBackoff == 0 {
+		op.maxBackoff = DefaultMaxBackoff
+	}
+	if op.outboxSize == 0 {
+		op.outboxSize = DefaultOutboxSize
+	}
+	if op.logFile == "" {
+		op.logFile = log.DefaultLogFile
+	}
+	if op.notifyInterval == 0 {
+		op.notifyInterval = DefaultNotifyInterval
+	}
+}
+
+// WithDB sets the state database to read the persisted events from.
+// If not set, the events are read from the components directly.
+func WithDB(db *sql.DB) OpOption {
+	return func(op *Op) {
+		op.db = db
+	}
+}
+
+// WithMaxBackoff sets the maximum interval to reconnect the session.
+func WithMaxBackoff(d time.Duration) OpOption {
+	return func(op *Op) {
+		op.maxBackoff = d
+	}
+}
+
+// WithOutboxDir sets the directory to persist the messages
+// waiting for the session to reconnect.
+// If not set, the messages are kept in memory.
+func WithOutboxDir(dir string) OpOption {
+	return func(op *Op) {
+		op.outboxDir = dir
+	}
+}
+
+// WithOutboxSize sets the maximum number of the messages
+// waiting for the session to reconnect, dropping the oldest when full.
+func WithOutboxSize(n int) OpOption {
+	return func(op *Op) {
+		op.outboxSize = n
+	}
+}
+
+// WithLogFile sets the gpud log file to tail for the "logs" method.
+func WithLogFile(file string) OpOption {
+	return func(op *Op) {
+		op.logFile = file
+	}
+}
+
+// WithConfig sets the functions to get and update the configuration for the "config" method.
+// If not set, the "config" method returns an error.
+func WithConfig(get func() config.Config, update ConfigUpdateFunc) OpOption {
+	return func(op *Op) {
+		op.getConfig = get
+		op.updateConfig = update
+	}
+}
+
+// WithNotifier pushes the component health transitions and the error/warn events
+// from the notifier to the control plane, without the request ID.
+func WithNotifier(n *notifier.Notifier) OpOption {
+	return func(op *Op) {
+		op.notifier = n
+	}
+}
+
+// WithNotifyInterval sets the interval to push the notifications,
+// the same notifications within the interval are coalesced into one.
+func WithNotifyInterval(d time.Duration) OpOption {
+	return func(op *Op) {
+		op.notifyInterval = d
+	}
+}
@@ -0,0 +1,94 @@
+package session
+
+import (
+	"encoding/json"
+
+	"github.com/leptonai/gpud/internal/queue"
+	"github.com/leptonai/gpud/log"
+)
+
+// DefaultOutboxSize is the maximum number of the messages held
+// while the control plane is unreachable.
+const DefaultOutboxSize = 1000
+
+const outboxFileExt = ".json"
+
+// outbox holds the encoded messages to send to the control plane in order,
+// until the writer successfully writes them to the session.
+// If the directory is set, the messages are persisted as files only while
+// the writer is disconnected, so that they survive the restarts.
+type outbox struct {
+	q *queue.Queue
+
+	// notified when a new message is pushed
+	notifyc chan struct{}
+}
+
+func newOutbox(dir string, maxEntries int) (*outbox, error) {
+	if maxEntries <= 0 {
+		maxEntries = DefaultOutboxSize
+	}
+	// disconnected until the writer connects
+	q, err := queue.New(dir, outboxFileExt, maxEntries, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// the responses to the requests of the previous process are never awaited
+	// by the control plane, only the notifications are still meaningful
+	dropped, err := q.Filter(func(b []byte) bool {
+		var body Body
+		return json.Unmarshal(b, &body) == nil && body.ReqID == ""
+	})
+	if err != nil {
+		return nil, err
+	}
+	if dropped > 0 {
+		log.Logger.Infow("dropped the stale responses from the outbox", "dropped", dropped)
+	}
+
+	o := &outbox{q: q, notifyc: make(chan struct{}, 1)}
+	outboxPending.Set(float64(q.Len()))
+	return o, nil
+}
+
+// setConnected persists the pending messages while the writer is disconnected.
+func (o *outbox) setConnected(connected bool) {
+	if err := o.q.SetSpill(!connected); err != nil {
+		log.Logger.Errorw("session writer: failed to persist outbox", "error", err)
+	}
+}
+
+// push appends the message, dropping the oldest if the outbox is full.
+func (o *outbox) push(b []byte) error {
+	defer func() {
+		select {
+		case o.notifyc <- struct{}{}:
+		default:
+		}
+	}()
+
+	dropped, err := o.q.Push(b)
+	outboxDropped.Add(float64(dropped))
+	outboxPending.Set(float64(o.q.Len()))
+	return err
+}
+
+// peek returns the oldest message, or false if the outbox is empty.
+// The message is kept until removed, in case the write fails.
+func (o *outbox) peek() (queue.Entry, bool, error) {
+	return o.q.Peek()
+}
+
+// remove removes the message once written,
+// no-op if already dropped.
+func (o *outbox) remove(id string) error {
+	err := o.q.Remove(id)
+	outboxPending.Set(float64(o.q.Len()))
+	return err
+}
+
+// len returns the number of the pending messages.
+func (o *outbox) len() int {
+	return o.q.Len()
+}
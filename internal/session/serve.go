@@ -9,6 +9,10 @@ import (
 
 	v1 "github.com/leptonai/gpud/api/v1"
 	"github.com/leptonai/gpud/components"
+	components_events_state "github.com/leptonai/gpud/components/events/state"
+	"github.com/leptonai/gpud/components/query"
+	"github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/errdefs"
 	"github.com/leptonai/gpud/log"
 	"github.com/leptonai/gpud/pkg/systemd"
 	"github.com/leptonai/gpud/pkg/update"
@@ -23,71 +27,171 @@ type Request struct {
 	EndTime       time.Time     `json:"end_time"`
 	Since         time.Duration `json:"since"`
 	UpdateVersion string        `json:"update_version,omitempty"`
+
+	// EventType and EventName filter the events, if set.
+	EventType string `json:"event_type,omitempty"`
+	EventName string `json:"event_name,omitempty"`
+
+	// LogFile selects the log poller to tail for the "logs" method,
+	// by the file name that the poller watches on.
+	// If empty, tails the gpud log file.
+	LogFile string `json:"log_file,omitempty"`
+	// LogLines is the number of the latest lines to tail for the "logs" method.
+	LogLines int `json:"log_lines,omitempty"`
+
+	// ConfigPatch is the JSON merge patch (RFC 7386) to apply to the configuration
+	// for the "config" method. If empty, returns the current configuration.
+	// Only the "components" and "critical_components" keys can be patched,
+	// since the other fields are not reloaded until the restart.
+	ConfigPatch json.RawMessage `json:"config_patch,omitempty"`
 }
 
 type Response struct {
-	Error   error            `json:"error,omitempty"`
+	// Error is serialized as the message in "ErrorMessage",
+	// since the error value itself is not serializable.
+	Error   error            `json:"-"`
 	States  v1.LeptonStates  `json:"states,omitempty"`
 	Events  v1.LeptonEvents  `json:"events,omitempty"`
 	Metrics v1.LeptonMetrics `json:"metrics,omitempty"`
+
+	// ErrorMessage is the message of the error, if any.
+	ErrorMessage string `json:"error,omitempty"`
+
+	Info v1.LeptonInfo `json:"info,omitempty"`
+	// Logs are the tailed lines from the oldest.
+	Logs []string `json:"logs,omitempty"`
+
+	// Config is the current configuration, after the patch if any.
+	Config *config.Config `json:"config,omitempty"`
+	// ConfigReload is the result of applying the configuration patch.
+	ConfigReload any `json:"config_reload,omitempty"`
+
+	// Chunk is a part of the streamed payload (e.g., the diagnose tarball),
+	// sent in the order of the index, with the same request ID.
+	Chunk      []byte `json:"chunk,omitempty"`
+	ChunkIndex int    `json:"chunk_index,omitempty"`
+	// ChunkLast is true for the last response of the stream.
+	ChunkLast bool `json:"chunk_last,omitempty"`
 }
 
 func (s *Session) serve() {
 	for body := range s.reader {
-		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
 		reqId := body.ReqID
 		data := body.Data
 		// 1. unmarshal data
 		var payload Request
 		if err := json.Unmarshal(data, &payload); err != nil {
-			cancel()
 			continue
 		}
-		response := &Response{}
-		switch payload.Method {
-		case "metrics":
-			metrics, err := s.getMetrics(ctx, payload)
-			response.Error = err
-			response.Metrics = metrics
-		case "states":
-			states, err := s.getStates(ctx, payload)
-			response.Error = err
-			response.States = states
-		case "events":
-			events, err := s.getEvents(ctx, payload)
-			response.Error = err
-			response.Events = events
-		case "update":
-			systemdManaged, _ := systemd.IsActive("gpud.service")
-			if !systemdManaged {
-				log.Logger.Debugw("gpud is not managed with systemd")
-				response.Error = fmt.Errorf("gpud is not managed with systemd")
+
+		// 2. check the method limits
+		spec, ok := methods[payload.Method]
+		if !ok {
+			s.respond(reqId, &Response{Error: fmt.Errorf("unsupported method %q", payload.Method)}, defaultMaxResponseBytes)
+			continue
+		}
+		if len(data) > spec.maxRequestBytes {
+			s.respond(reqId, &Response{Error: fmt.Errorf("request size %d exceeds the limit %d of method %q", len(data), spec.maxRequestBytes, payload.Method)}, spec.maxResponseBytes)
+			continue
+		}
+
+		// 3. handle the request, in the background if long-running
+		// not to block the other requests
+		if spec.async {
+			go s.handle(reqId, payload, spec)
+			continue
+		}
+		s.handle(reqId, payload, spec)
+	}
+}
+
+func (s *Session) handle(reqId string, payload Request, spec methodSpec) {
+	ctx, cancel := context.WithTimeout(context.Background(), spec.timeout)
+	defer cancel()
+
+	response := &Response{}
+	switch payload.Method {
+	case "metrics":
+		metrics, err := s.getMetrics(ctx, payload)
+		response.Error = err
+		response.Metrics = metrics
+	case "states":
+		states, err := s.getStates(ctx, payload)
+		response.Error = err
+		response.States = states
+	case "events":
+		events, err := s.getEvents(ctx, payload)
+		response.Error = err
+		response.Events = events
+	case "refresh":
+		states, err := s.refreshStates(ctx, payload)
+		response.Error = err
+		response.States = states
+	case "update":
+		systemdManaged, _ := systemd.IsActive("gpud.service")
+		if !systemdManaged {
+			log.Logger.Debugw("gpud is not managed with systemd")
+			response.Error = fmt.Errorf("gpud is not managed with systemd")
+		} else {
+			nextVersion := payload.UpdateVersion
+			if nextVersion == "" {
+				response.Error = fmt.Errorf("update_version is empty")
 			} else {
-				nextVersion := payload.UpdateVersion
-				if nextVersion == "" {
-					response.Error = fmt.Errorf("update_version is empty")
-				} else {
-					err := update.Update(nextVersion, update.DefaultUpdateURL)
-					if err != nil {
-						response.Error = err
-					}
+				err := update.Update(nextVersion, update.DefaultUpdateURL)
+				if err != nil {
+					response.Error = err
 				}
 			}
 		}
-		responseRaw, _ := json.Marshal(response)
-		s.writer <- Body{
-			Data:  responseRaw,
-			ReqID: reqId,
+	case "diagnose":
+		// the chunks are sent as the responses, the last one with "chunk_last"
+		if err := s.diagnose(ctx, reqId, spec); err != nil {
+			response.Error = err
+			response.ChunkLast = true
+		} else {
+			return
 		}
-		cancel()
+	case "logs":
+		logs, err := s.getLogs(ctx, payload)
+		response.Error = err
+		response.Logs = logs
+	case "config":
+		cfg, result, err := s.applyConfig(ctx, payload)
+		response.Error = err
+		response.Config = cfg
+		response.ConfigReload = result
+	case "info":
+		info, err := s.getInfo(ctx, payload)
+		response.Error = err
+		response.Info = info
+	}
+	s.respond(reqId, response, spec.maxResponseBytes)
+}
+
+// respond sends the response tagged with the request ID,
+// replaced with an error if the encoded response exceeds the limit.
+func (s *Session) respond(reqId string, response *Response, maxResponseBytes int) {
+	if response.Error != nil {
+		response.ErrorMessage = response.Error.Error()
+	}
+	responseRaw, err := json.Marshal(response)
+	if err != nil {
+		responseRaw, _ = json.Marshal(&Response{ErrorMessage: fmt.Sprintf("failed to marshal response: %v", err)})
+	}
+	if len(responseRaw) > maxResponseBytes {
+		responseRaw, _ = json.Marshal(&Response{ErrorMessage: fmt.Sprintf("response size %d exceeds the limit %d", len(responseRaw), maxResponseBytes)})
 	}
+	s.Send(Body{
+		Data:  responseRaw,
+		ReqID: reqId,
+	})
 }
 
 func (s *Session) getEvents(ctx context.Context, payload Request) (v1.LeptonEvents, error) {
 	if payload.Method != "events" {
 		return nil, errors.New("mismatch method")
 	}
-	allComponents := s.components
+	allComponents := componentNames()
 	if len(payload.Components) > 0 {
 		allComponents = payload.Components
 	}
@@ -97,7 +201,7 @@ func (s *Session) getEvents(ctx context.Context, payload Request) (v1.LeptonEven
 		startTime = payload.StartTime
 	}
 	if !payload.EndTime.IsZero() {
-		startTime = payload.EndTime
+		endTime = payload.EndTime
 	}
 	var events v1.LeptonEvents
 	for _, componentName := range allComponents {
@@ -116,7 +220,7 @@ func (s *Session) getEvents(ctx context.Context, payload Request) (v1.LeptonEven
 			events = append(events, currEvent)
 			continue
 		}
-		event, err := component.Events(ctx, startTime)
+		event, err := s.readEvents(ctx, component, startTime, endTime, payload)
 		if err != nil {
 			log.Logger.Errorw("failed to invoke component events",
 				"operation", "GetEvents",
@@ -131,11 +235,45 @@ func (s *Session) getEvents(ctx context.Context, payload Request) (v1.LeptonEven
 	return events, nil
 }
 
+// readEvents reads the events from the state database if set,
+// otherwise from the component directly.
+func (s *Session) readEvents(ctx context.Context, component components.Component, startTime time.Time, endTime time.Time, payload Request) ([]components.Event, error) {
+	if s.db == nil {
+		evs, err := component.Events(ctx, startTime)
+		if err != nil {
+			return nil, err
+		}
+		filtered := make([]components.Event, 0, len(evs))
+		for _, ev := range evs {
+			if payload.EventType != "" && ev.Type != payload.EventType {
+				continue
+			}
+			if payload.EventName != "" && ev.Name != payload.EventName {
+				continue
+			}
+			filtered = append(filtered, ev)
+		}
+		return filtered, nil
+	}
+
+	// read the events persisted by the central sync of the server
+	return components_events_state.Read(
+		ctx,
+		s.db,
+		components_events_state.DefaultTableName,
+		component.Name(),
+		startTime,
+		components_events_state.WithUntil(endTime),
+		components_events_state.WithEventType(payload.EventType),
+		components_events_state.WithEventName(payload.EventName),
+	)
+}
+
 func (s *Session) getMetrics(ctx context.Context, payload Request) (v1.LeptonMetrics, error) {
 	if payload.Method != "metrics" {
 		return nil, errors.New("mismatch method")
 	}
-	allComponents := s.components
+	allComponents := componentNames()
 	if len(payload.Components) > 0 {
 		allComponents = payload.Components
 	}
@@ -180,7 +318,7 @@ func (s *Session) getStates(ctx context.Context, payload Request) (v1.LeptonStat
 	if payload.Method != "states" {
 		return nil, errors.New("mismatch method")
 	}
-	allComponents := s.components
+	allComponents := componentNames()
 	if len(payload.Components) > 0 {
 		allComponents = payload.Components
 	}
@@ -216,3 +354,60 @@ func (s *Session) getStates(ctx context.Context, payload Request) (v1.LeptonStat
 	}
 	return states, nil
 }
+
+// refreshStates re-polls the components and returns the fresh states.
+func (s *Session) refreshStates(ctx context.Context, payload Request) (v1.LeptonStates, error) {
+	if payload.Method != "refresh" {
+		return nil, errors.New("mismatch method")
+	}
+	allComponents := componentNames()
+	if len(payload.Components) > 0 {
+		allComponents = payload.Components
+	}
+	var states v1.LeptonStates
+	for _, componentName := range allComponents {
+		currState := v1.LeptonComponentStates{
+			Component: componentName,
+		}
+		component, err := components.GetComponent(componentName)
+		if err != nil {
+			log.Logger.Errorw("failed to get component",
+				"operation", "Refresh",
+				"component", componentName,
+				"error", err,
+			)
+			states = append(states, currState)
+			continue
+		}
+
+		if err := query.Refresh(ctx, componentName); err != nil && !errdefs.IsNotFound(err) {
+			log.Logger.Errorw("failed to refresh component",
+				"operation", "Refresh",
+				"component", componentName,
+				"error", err,
+			)
+		}
+		state, err := component.States(ctx)
+		if err != nil {
+			log.Logger.Errorw("failed to invoke component state",
+				"operation", "Refresh",
+				"component", componentName,
+				"error", err,
+			)
+		} else {
+			currState.States = state
+		}
+		states = append(states, currState)
+	}
+	return states, nil
+}
+
+// componentNames returns the names of the currently registered components,
+// which may change when the configuration is reloaded.
+func componentNames() []string {
+	names := make([]string, 0)
+	for name := range components.GetAllComponents() {
+		names = append(names, name)
+	}
+	return names
+}
@@ -2,14 +2,19 @@ package session
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/config"
+	"github.com/leptonai/gpud/internal/controlplane"
 	"github.com/leptonai/gpud/log"
 )
 
@@ -17,27 +22,49 @@ type Session struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 
+	// pipeInterval is the initial interval to reconnect,
+	// doubled on every failed attempt up to maxBackoff.
 	pipeInterval time.Duration
+	maxBackoff   time.Duration
 
 	machineID string
 	endpoint  string
 
-	components []string
+	db *sql.DB
 
-	writer         chan Body
+	logFile      string
+	getConfig    func() config.Config
+	updateConfig ConfigUpdateFunc
+	// set while the "diagnose" method is running, to run one at a time
+	diagnosing atomic.Bool
+
+	// outbox holds the messages to write until the session is connected
+	outbox         *outbox
 	writerCloseCh  chan bool
 	writerClosedCh chan bool
 
 	reader         chan Body
 	readerCloseCh  chan bool
 	readerClosedCh chan bool
+
+	// the notifications pending to push, coalesced by the component and event
+	notifyInterval          time.Duration
+	notifyMu                sync.Mutex
+	pendingNotifications    map[string]*Notification
+	pendingNotificationKeys []string
+	// the last time pushed within the interval, by the notification key
+	notifiedAt  map[string]time.Time
+	unsubscribe func()
 }
 
-func NewSession(ctx context.Context, endpoint string, machineID string, pipeInterval time.Duration) *Session {
-	cps := make([]string, 0)
-	allComponents := components.GetAllComponents()
-	for key := range allComponents {
-		cps = append(cps, key)
+func NewSession(ctx context.Context, endpoint string, machineID string, pipeInterval time.Duration, opts ...OpOption) *Session {
+	op := &Op{}
+	op.applyOpts(opts)
+
+	ob, err := newOutbox(op.outboxDir, op.outboxSize)
+	if err != nil {
+		log.Logger.Warnw("failed to create session outbox, keeping messages in memory", "dir", op.outboxDir, "error", err)
+		ob, _ = newOutbox("", op.outboxSize)
 	}
 
 	cctx, ccancel := context.WithCancel(ctx)
@@ -46,15 +73,25 @@ func NewSession(ctx context.Context, endpoint string, machineID string, pipeInte
 		cancel: ccancel,
 
 		pipeInterval: pipeInterval,
+		maxBackoff:   op.maxBackoff,
 
 		endpoint:  endpoint,
 		machineID: machineID,
 
-		components: cps,
+		db: op.db,
+
+		logFile:      op.logFile,
+		getConfig:    op.getConfig,
+		updateConfig: op.updateConfig,
+
+		outbox: ob,
+
+		notifyInterval:       op.notifyInterval,
+		pendingNotifications: make(map[string]*Notification),
+		notifiedAt:           make(map[string]time.Time),
 	}
 
 	s.reader = make(chan Body, 20)
-	s.writer = make(chan Body, 20)
 	s.writerCloseCh = make(chan bool, 2)
 	s.writerClosedCh = make(chan bool)
 	s.readerCloseCh = make(chan bool, 2)
@@ -62,6 +99,11 @@ func NewSession(ctx context.Context, endpoint string, machineID string, pipeInte
 	s.keepAlive()
 	go s.serve()
 
+	if op.notifier != nil {
+		s.unsubscribe = op.notifier.Subscribe(s.enqueueNotification)
+		go s.startNotifier()
+	}
+
 	return s
 }
 
@@ -70,14 +112,30 @@ type Body struct {
 	ReqID string `json:"req_id,omitempty"`
 }
 
+// Send queues the message to write to the control plane,
+// either the response to a request or an unsolicited notification (without the request ID).
+// The message is held in the outbox until the session is connected,
+// and the oldest message is dropped if the outbox is full.
+func (s *Session) Send(body Body) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		log.Logger.Errorw("session writer: failed to marshal body", "error", err)
+		return
+	}
+	if err := s.outbox.push(b); err != nil {
+		log.Logger.Errorw("session writer: failed to queue body", "reqID", body.ReqID, "error", err)
+	}
+}
+
 func (s *Session) keepAlive() {
 	go s.startWriter()
 	go s.startReader()
 }
 
 func (s *Session) startWriter() {
-	ticker := time.NewTicker(1)
-	defer ticker.Stop()
+	bo := newBackoff(s.pipeInterval, s.maxBackoff)
+
+	var delay time.Duration
 	for {
 		select {
 		case <-s.ctx.Done():
@@ -86,63 +144,106 @@ func (s *Session) startWriter() {
 			log.Logger.Debug("session writer: closed writer")
 			return
 
-		case <-ticker.C:
-			ticker.Reset(s.pipeInterval)
+		case <-time.After(delay):
 		}
 
-		reader, writer := io.Pipe()
-		goroutineCloseCh := make(chan any)
-		go func() {
-			log.Logger.Debug("session writer created")
-			for {
-				select {
-				case <-s.writerCloseCh:
-					log.Logger.Debug("session writer closed")
-					writer.Close()
-					return
-				case <-goroutineCloseCh:
-					return
-				case body := <-s.writer:
-					bytes, err := json.Marshal(body)
-					if err != nil {
-						log.Logger.Errorf("session writer: failed to marshal body: %v", err)
-						continue
-					}
-					if _, err := writer.Write(bytes); err != nil {
-						log.Logger.Errorf("session writer: failed to write to pipe: %v", err)
-						continue
-					}
-				}
-			}
-		}()
-
-		req, err := http.NewRequestWithContext(s.ctx, "POST", s.endpoint, reader)
-		if err != nil {
-			log.Logger.Errorf("session writer: error creating request: %v, retrying in 3s...", err)
-			close(goroutineCloseCh)
-			continue
+		if s.connectWriter() {
+			bo.reset()
 		}
-		req.Header.Set("machine_id", s.machineID)
-		req.Header.Set("session_type", "write")
+		delay = bo.next()
+		reconnects.WithLabelValues(sessionTypeWrite).Inc()
+		log.Logger.Debugw("session writer: reconnecting", "after", delay)
+	}
+}
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			log.Logger.Errorf("session writer: error making request: %v, retrying", err)
-			close(goroutineCloseCh)
-			continue
-		}
+// connectWriter streams the outbox messages to the control plane until the connection is closed.
+// Returns true if the connection was established, to reset the backoff.
+func (s *Session) connectWriter() bool {
+	reader, writer := io.Pipe()
+	goroutineCloseCh := make(chan any)
+	go s.pipeOutbox(writer, goroutineCloseCh)
+	defer func() {
+		// unblock the pending write, the message is kept in the outbox for the next connection
+		reader.Close()
+		close(goroutineCloseCh)
+	}()
+
+	var gotConn atomic.Bool
+	ctx := httptrace.WithClientTrace(s.ctx, &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) {
+			gotConn.Store(true)
+			setConnected(sessionTypeWrite, true)
+			// keep the messages in memory while connected, to write them without the disk round trip
+			s.outbox.setConnected(true)
+		},
+	})
+	defer func() {
+		setConnected(sessionTypeWrite, false)
+		s.outbox.setConnected(false)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.endpoint, reader)
+	if err != nil {
+		log.Logger.Errorf("session writer: error creating request: %v, retrying", err)
+		return false
+	}
+	req.Header.Set(controlplane.HeaderMachineID, s.machineID)
+	req.Header.Set(controlplane.HeaderSessionType, controlplane.SessionTypeWrite)
+	// do not send the body until the server accepts the session,
+	// otherwise the messages written to the rejected request are lost
+	req.Header.Set("Expect", "100-continue")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Logger.Errorf("session writer: error making request: %v, retrying", err)
+		return gotConn.Load()
+	}
+	resp.Body.Close()
 
-		log.Logger.Debugf("session writer: unexpected closed, resp: %v %v, reconnecting...", resp.Status, resp.StatusCode)
+	log.Logger.Debugf("session writer: unexpected closed, resp: %v %v, reconnecting...", resp.Status, resp.StatusCode)
+	return gotConn.Load() && resp.StatusCode == http.StatusOK
+}
 
-		// no need to close goroutineCloseCh
-		// to ensure only one pair of read/write connection is there
+// pipeOutbox writes the outbox messages to the pipe from the oldest,
+// removing each message only after written.
+func (s *Session) pipeOutbox(writer *io.PipeWriter, goroutineCloseCh chan any) {
+	log.Logger.Debug("session writer created")
+	for {
+		for {
+			entry, ok, err := s.outbox.peek()
+			if err != nil {
+				log.Logger.Errorf("session writer: failed to read outbox: %v", err)
+				break
+			}
+			if !ok {
+				break
+			}
+			if _, err := writer.Write(entry.Data); err != nil {
+				log.Logger.Errorf("session writer: failed to write to pipe: %v", err)
+				return
+			}
+			if err := s.outbox.remove(entry.ID); err != nil {
+				log.Logger.Errorf("session writer: failed to remove from outbox: %v", err)
+			}
+		}
+
+		select {
+		case <-s.writerCloseCh:
+			log.Logger.Debug("session writer closed")
+			writer.Close()
+			return
+		case <-goroutineCloseCh:
+			return
+		case <-s.outbox.notifyc:
+		}
 	}
 }
 
 func (s *Session) startReader() {
-	ticker := time.NewTicker(1)
-	defer ticker.Stop()
+	bo := newBackoff(s.pipeInterval, s.maxBackoff)
+
+	var delay time.Duration
 	for {
 		select {
 		case <-s.ctx.Done():
@@ -151,64 +252,88 @@ func (s *Session) startReader() {
 			log.Logger.Debug("session reader: closed reader")
 			return
 
-		case <-ticker.C:
-			ticker.Reset(s.pipeInterval)
+		case <-time.After(delay):
 		}
 
-		req, err := http.NewRequestWithContext(s.ctx, "POST", s.endpoint, nil)
-		if err != nil {
-			log.Logger.Errorf("session reader: error creating request: %v, retrying", err)
-			continue
+		if s.connectReader() {
+			bo.reset()
 		}
-		req.Header.Set("machine_id", s.machineID)
-		req.Header.Set("session_type", "read")
+		delay = bo.next()
+		reconnects.WithLabelValues(sessionTypeRead).Inc()
+		log.Logger.Debugw("session reader: reconnecting", "after", delay)
+	}
+}
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			log.Logger.Errorf("session reader: error making request: %v, retrying", err)
-			continue
-		}
-		if resp.StatusCode != http.StatusOK {
-			log.Logger.Errorf("session reader: error making request: %v %v, retrying", resp.StatusCode, resp.Status)
-			continue
-		}
+// connectReader reads the requests from the control plane until the connection is closed.
+// Returns true if the connection was established, to reset the backoff.
+func (s *Session) connectReader() bool {
+	req, err := http.NewRequestWithContext(s.ctx, "POST", s.endpoint, nil)
+	if err != nil {
+		log.Logger.Errorf("session reader: error creating request: %v, retrying", err)
+		return false
+	}
+	req.Header.Set(controlplane.HeaderMachineID, s.machineID)
+	req.Header.Set(controlplane.HeaderSessionType, controlplane.SessionTypeRead)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Logger.Errorf("session reader: error making request: %v, retrying", err)
+		return false
+	}
+	if resp.StatusCode != http.StatusOK {
+		log.Logger.Errorf("session reader: error making request: %v %v, retrying", resp.StatusCode, resp.Status)
+		resp.Body.Close()
+		return false
+	}
 
-		goroutineCloseCh := make(chan any)
-		go func() {
-			log.Logger.Debug("session reader created")
-			for {
-				select {
-				case <-goroutineCloseCh:
-					return
-				case <-s.readerCloseCh:
-					log.Logger.Debug("session reader closed")
-					resp.Body.Close()
-					return
-				}
-			}
-		}()
+	setConnected(sessionTypeRead, true)
+	defer setConnected(sessionTypeRead, false)
 
-		decoder := json.NewDecoder(resp.Body)
+	goroutineCloseCh := make(chan any)
+	go func() {
+		log.Logger.Debug("session reader created")
 		for {
-			var content Body
-			err = decoder.Decode(&content)
-			if err != nil {
-				if !errors.Is(err, io.EOF) {
-					fmt.Println("Error reading response:", err)
-				}
+			select {
+			case <-goroutineCloseCh:
+				return
+			case <-s.readerCloseCh:
+				log.Logger.Debug("session reader closed")
+				resp.Body.Close()
+				return
+			}
+		}
+	}()
 
-				s.writerCloseCh <- true
-				break
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var content Body
+		err = decoder.Decode(&content)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				fmt.Println("Error reading response:", err)
 			}
 
-			s.reader <- content
+			// reconnect the writer as well, unless already requested
+			select {
+			case s.writerCloseCh <- true:
+			default:
+			}
+			break
 		}
-		close(goroutineCloseCh)
+
+		s.reader <- content
 	}
+	close(goroutineCloseCh)
+	resp.Body.Close()
+
+	return true
 }
 
 func (s *Session) Stop() {
+	if s.unsubscribe != nil {
+		s.unsubscribe()
+	}
 	s.cancel()
 
 	s.writerCloseCh <- true
@@ -219,5 +344,4 @@ func (s *Session) Stop() {
 	<-s.readerClosedCh
 
 	close(s.reader)
-	close(s.writer)
 }
@@ -3,10 +3,16 @@ package session
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/internal/notifier"
 )
 
 func TestNewSession(t *testing.T) {
@@ -36,7 +42,6 @@ func TestStop(t *testing.T) {
 	s := &Session{
 		ctx:            ctx,
 		cancel:         cancel,
-		writer:         make(chan Body, 20),
 		reader:         make(chan Body, 20),
 		writerCloseCh:  make(chan bool, 2),
 		readerCloseCh:  make(chan bool, 2),
@@ -60,9 +65,6 @@ func TestStop(t *testing.T) {
 	if _, ok := <-s.reader; ok {
 		t.Errorf("Reader channel should be closed")
 	}
-	if _, ok := <-s.writer; ok {
-		t.Errorf("Writer channel should be closed")
-	}
 }
 
 func TestStartWriterAndReader(t *testing.T) {
@@ -93,13 +95,18 @@ func TestStartWriterAndReader(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	ob, err := newOutbox("", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
 	s := &Session{
 		ctx:            ctx,
 		cancel:         cancel,
 		pipeInterval:   10 * time.Millisecond, // Reduce interval for faster testing
+		maxBackoff:     20 * time.Millisecond,
 		endpoint:       server.URL,
 		machineID:      "test_machine",
-		writer:         make(chan Body, 100),
+		outbox:         ob,
 		reader:         make(chan Body, 100),
 		writerCloseCh:  make(chan bool, 5),
 		readerCloseCh:  make(chan bool, 5),
@@ -127,11 +134,7 @@ func TestStartWriterAndReader(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			select {
-			case s.writer <- Body{ReqID: tc.sendReqID}:
-			default:
-				t.Fatal("writer timeout")
-			}
+			s.Send(Body{ReqID: tc.sendReqID})
 
 			select {
 			case body := <-s.reader:
@@ -145,3 +148,199 @@ func TestStartWriterAndReader(t *testing.T) {
 		})
 	}
 }
+
+func TestBackoff(t *testing.T) {
+	b := newBackoff(100*time.Millisecond, time.Second)
+	for i, want := range []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	} {
+		d := b.next()
+		if d < want/2 || d > want {
+			t.Fatalf("attempt %d: expected between %v and %v, got %v", i, want/2, want, d)
+		}
+	}
+
+	b.reset()
+	if d := b.next(); d > 100*time.Millisecond {
+		t.Fatalf("expected the initial interval after reset, got %v", d)
+	}
+}
+
+func TestOutbox(t *testing.T) {
+	dir := t.TempDir()
+	ob, err := newOutbox(dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// messages are kept in memory while connected
+	ob.setConnected(true)
+	if err := ob.push([]byte(`{"req_id":"1"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("expected no files while connected, got %d", len(entries))
+	}
+
+	// and persisted while disconnected
+	ob.setConnected(false)
+	if err := ob.push([]byte(`{"data":"bm90aWZpY2F0aW9u"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Fatalf("expected 2 files while disconnected, got %d", len(entries))
+	}
+
+	// the responses to the previous process are dropped on restart
+	ob, err = newOutbox(dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := ob.len(); n != 1 {
+		t.Fatalf("expected only the notification kept, got %d", n)
+	}
+	e, ok, err := ob.peek()
+	if err != nil || !ok {
+		t.Fatalf("expected the persisted notification, got %v %v", ok, err)
+	}
+	var body Body
+	if err := json.Unmarshal(e.Data, &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.ReqID != "" || string(body.Data) != "notification" {
+		t.Fatalf("expected the notification, got %+v", body)
+	}
+}
+
+func TestSendWhileDisconnected(t *testing.T) {
+	var available atomic.Bool
+	received := make(chan Body, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !available.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		switch r.Header.Get("session_type") {
+		case "write":
+			decoder := json.NewDecoder(r.Body)
+			for {
+				var body Body
+				if err := decoder.Decode(&body); err != nil {
+					return
+				}
+				received <- body
+			}
+		case "read":
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := NewSession(ctx, server.URL, "test_machine", 10*time.Millisecond, WithMaxBackoff(50*time.Millisecond), WithOutboxDir(t.TempDir()))
+	defer s.Stop()
+
+	// responses produced while the control plane is unreachable are held
+	s.Send(Body{ReqID: "1"})
+	s.Send(Body{ReqID: "2"})
+	time.Sleep(100 * time.Millisecond)
+	if n := s.outbox.len(); n != 2 {
+		t.Fatalf("expected 2 pending messages, got %d", n)
+	}
+
+	available.Store(true)
+	for _, want := range []string{"1", "2"} {
+		select {
+		case body := <-received:
+			if body.ReqID != want {
+				t.Fatalf("expected ReqID %q, got %q", want, body.ReqID)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+}
+
+// decodeNotifications decodes the notifications sent to the outbox.
+func decodeNotifications(t *testing.T, s *Session) []Notification {
+	var got []Notification
+	for _, b := range recvResponses(t, s) {
+		if b.ReqID != "" {
+			t.Fatalf("expected no request ID, got %q", b.ReqID)
+		}
+		var n Notification
+		if err := json.Unmarshal(b.Data, &n); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, n)
+	}
+	return got
+}
+
+func TestNotificationCoalescing(t *testing.T) {
+	s := newTestSession(t)
+	s.notifyInterval = time.Minute
+	s.pendingNotifications = make(map[string]*Notification)
+	s.notifiedAt = make(map[string]time.Time)
+
+	healthy := components.HealthStatus{Healthy: true}
+	unhealthy := components.HealthStatus{Healthy: false, Severity: components.SeverityFatal, Reason: "xid 79"}
+	s.enqueueNotification(notifier.Payload{Kind: notifier.KindState, Component: "xid", Health: &unhealthy, PreviousHealth: &healthy})
+	for i := 0; i < 100; i++ {
+		s.enqueueNotification(notifier.Payload{Kind: notifier.KindEvent, Component: "xid", Event: &components.Event{Name: "xid", Type: components.EventTypeError, Message: fmt.Sprintf("xid 79 (%d)", i)}})
+	}
+	s.enqueueNotification(notifier.Payload{Kind: notifier.KindEvent, Component: "xid", Event: &components.Event{Name: "boot", Type: components.EventTypeInfo}})
+	s.enqueueNotification(notifier.Payload{Kind: notifier.KindState, Component: "xid", Health: &healthy, PreviousHealth: &unhealthy})
+
+	// the first notifications are pushed right away, without waiting for the flush
+	got := decodeNotifications(t, s)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 immediate notifications, got %d", len(got))
+	}
+	if st := got[0]; st.Kind != notifier.KindState || st.Count != 1 || st.Health.Healthy || !st.PreviousHealth.Healthy {
+		t.Fatalf("unexpected immediate state notification %+v", st)
+	}
+	if ev := got[1]; ev.Kind != notifier.KindEvent || ev.Count != 1 || ev.Event.Message != "xid 79 (0)" {
+		t.Fatalf("unexpected immediate event notification %+v", ev)
+	}
+
+	// the repeated ones within the interval are coalesced
+	s.flushNotifications()
+	got = decodeNotifications(t, s)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 coalesced notifications, got %d", len(got))
+	}
+
+	// in the order of the first coalesced occurrence
+	ev := got[0]
+	if ev.Kind != notifier.KindEvent || ev.Component != "xid" || ev.Count != 99 || ev.Event.Message != "xid 79 (99)" {
+		t.Fatalf("unexpected event notification %+v", ev)
+	}
+	// the state transition keeps the health before the interval, and the latest health
+	st := got[1]
+	if st.Kind != notifier.KindState || st.Count != 1 || !st.Health.Healthy || st.PreviousHealth.Healthy {
+		t.Fatalf("unexpected state notification %+v", st)
+	}
+
+	// nothing pending
+	s.flushNotifications()
+	if got := decodeNotifications(t, s); len(got) != 0 {
+		t.Fatalf("expected no notification, got %d", len(got))
+	}
+
+	// pushed right away again after the interval
+	s.notifiedAt[notifier.KindEvent+"/xid/xid/"+components.EventTypeError] = time.Now().Add(-time.Minute)
+	s.enqueueNotification(notifier.Payload{Kind: notifier.KindEvent, Component: "xid", Event: &components.Event{Name: "xid", Type: components.EventTypeError, Message: "xid 79 (100)"}})
+	if got := decodeNotifications(t, s); len(got) != 1 || got[0].Event.Message != "xid 79 (100)" {
+		t.Fatalf("expected the immediate notification after the interval, got %+v", got)
+	}
+}
@@ -9,6 +9,9 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
+// DefaultLogFile is the file that the systemd unit writes the gpud logs to.
+const DefaultLogFile = "/var/log/gpud.log"
+
 var (
 	Logger *LeptonLogger
 )